@@ -0,0 +1,68 @@
+package gqbd
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// ArgInfo describes one bound argument of a built query.
+type ArgInfo struct {
+	Position int    // 1-based placeholder position
+	Type     string // Go type of the value, "nil" for nil
+	Column   string // Column the value is written to or compared with, empty if unknown
+}
+
+/*
+ArgsTyped
+
+@ Return: Position, Go type and destination column of every argument of the built query, and error if the
+query cannot be built. Columns are known for Insert, Update and the typed Where helpers; raw conditions report none
+*/
+func (qb *QueryBuilder) ArgsTyped() ([]ArgInfo, error) {
+	var columns []string
+	typed := *qb
+	typed.argColumns = &columns
+	_, args, err := typed.Build()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ArgInfo, len(args))
+	for i, arg := range args {
+		infos[i] = ArgInfo{Position: i + 1, Type: fmt.Sprintf("%T", arg), Column: columns[i]}
+		if arg == nil {
+			infos[i].Type = "nil"
+		}
+	}
+	return infos, nil
+}
+
+/*
+StrictArgs
+
+@ Return: *QueryBuilder whose Build fails when an argument cannot be converted by database/sql,
+such as channels, funcs, maps and structs without driver.Valuer
+*/
+func (qb *QueryBuilder) StrictArgs() *QueryBuilder {
+	qb = qb.mutable()
+	qb.strictArgs = true
+	return qb
+}
+
+/*
+checkArgs
+
+@ args: Arguments of the built query
+@ Return: Error naming the first argument database/sql's default converter rejects
+*/
+func checkArgs(args []interface{}) error {
+	for i, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			arg = named.Value
+		}
+		if _, err := driver.DefaultParameterConverter.ConvertValue(arg); err != nil {
+			return fmt.Errorf("arg %d of type %T is not supported: %v", i+1, arg, err)
+		}
+	}
+	return nil
+}
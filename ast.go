@@ -0,0 +1,213 @@
+package gqbd
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Fragment is a piece of SQL with "?" placeholders and its arguments.
+// Identifiers in SQL are already quoted for the statement's DBType.
+type Fragment struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Condition is a WHERE or HAVING predicate; conditions are combined with AND.
+type Condition struct {
+	SQL    string
+	Args   []interface{}
+	Native bool // SQL uses the dialect's own placeholders, as added by WhereRaw
+}
+
+// Join is a JOIN clause.
+type Join struct {
+	Type  string // "LEFT", "INNER" or "RIGHT"
+	Table string // Unescaped table name
+	On    string // Join condition
+}
+
+// OrderItem is one ORDER BY term, e.g. a quoted column followed by its direction.
+type OrderItem struct {
+	SQL  string
+	Args []interface{}
+}
+
+// SelectStmt is the structure of a SELECT builder, for tools that inspect or
+// rewrite queries without parsing SQL text.
+type SelectStmt struct {
+	DBType     DBType
+	Schema     string    // Schema of Table set with WithSchema, empty for the default schema
+	Table      string    // Unescaped table name, or the alias of From
+	From       *Fragment // Parenthesized subquery used as the FROM source, nil for a plain table
+	Distinct   bool
+	Columns    []Fragment
+	Joins      []Join
+	Where      []Condition
+	GroupBy    []string // Quoted GROUP BY terms
+	WithRollup bool     // MariaDB/Mysql GROUP BY ... WITH ROLLUP
+	Having     []Condition
+	OrderBy    []OrderItem
+	Collation  string // Collation set with WithCollation, already applied to the generated clauses
+	Limit      int    // 0 for no LIMIT
+	Offset     int    // 0 for no OFFSET
+}
+
+/*
+Statement
+
+@ Return: *SelectStmt describing the builder with the global scopes of its table applied, and error if the builder
+is not a valid SELECT or uses a clause SelectStmt cannot describe. The soft-delete filter is included in Where and
+the WithMaxLimit cap in Limit. The returned statement does not share memory with the builder
+*/
+func (qb *QueryBuilder) Statement() (*SelectStmt, error) {
+	if qb = qb.withScopes(); qb.err != nil {
+		return nil, qb.err
+	}
+	if qb.op != "SELECT" {
+		return nil, fmt.Errorf("Statement() can only be used with SELECT operation")
+	}
+	if qb.valuesFrom || slices.ContainsFunc(qb.joins, func(j joinClause) bool { return len(j.args) > 0 }) {
+		return nil, fmt.Errorf("Statement() does not support Values() sources")
+	}
+	var unsupported string
+	switch {
+	case qb.asOfSystemTime != "":
+		unsupported = "AsOfSystemTime()"
+	case qb.indexHint != "":
+		unsupported = "ForceIndex()"
+	case qb.final:
+		unsupported = "Final()"
+	case qb.sample != "":
+		unsupported = "Sample()"
+	case qb.limitBy != nil:
+		unsupported = "LimitBy()"
+	}
+	if unsupported != "" {
+		return nil, fmt.Errorf("Statement() does not support %s", unsupported)
+	}
+	limit := qb.limit
+	if qb.maxLimit > 0 && (limit == 0 || limit > qb.maxLimit) {
+		limit = qb.maxLimit
+	}
+	stmt := &SelectStmt{
+		DBType:     qb.dbType,
+		Schema:     qb.schema,
+		Table:      qb.tableName,
+		Distinct:   qb.distinct,
+		GroupBy:    slices.Clone(qb.groupBy),
+		WithRollup: qb.withRollup,
+		Collation:  qb.collation,
+		Limit:      limit,
+		Offset:     qb.offset,
+	}
+	if qb.fromSub != nil {
+		stmt.From = &Fragment{SQL: qb.fromSub.sql, Args: slices.Clone(qb.fromSub.args)}
+	}
+	for _, c := range qb.columns {
+		stmt.Columns = append(stmt.Columns, Fragment{SQL: c.sql, Args: slices.Clone(c.args)})
+	}
+	for _, j := range qb.joins {
+		stmt.Joins = append(stmt.Joins, Join{Type: j.kind, Table: j.table, On: j.on})
+	}
+	for _, c := range qb.selectConditions() {
+		stmt.Where = append(stmt.Where, Condition{SQL: c.sql, Args: slices.Clone(c.args), Native: c.native})
+	}
+	for _, c := range qb.having {
+		stmt.Having = append(stmt.Having, Condition{SQL: c.sql, Args: slices.Clone(c.args), Native: c.native})
+	}
+	for _, c := range qb.orderBy {
+		stmt.OrderBy = append(stmt.OrderBy, OrderItem{SQL: c.sql, Args: slices.Clone(c.args)})
+	}
+	return stmt, nil
+}
+
+/*
+Render
+
+@ Return: Query string for the statement's DBType, arguments slice, and error if any
+*/
+func (s *SelectStmt) Render() (string, []interface{}, error) {
+	table := s.Table
+	if s.Schema != "" {
+		table = s.Schema + "." + table
+	}
+	safeTable, err := EscapeIdentifier(s.DBType, table)
+	if err != nil {
+		return "", nil, err
+	}
+	qb := &QueryBuilder{
+		op:         "SELECT",
+		dbType:     s.DBType,
+		table:      safeTable,
+		tableName:  s.Table,
+		schema:     s.Schema,
+		distinct:   s.Distinct,
+		groupBy:    s.GroupBy,
+		withRollup: s.WithRollup,
+		collation:  s.Collation,
+		limit:      s.Limit,
+		offset:     s.Offset,
+	}
+	if s.From != nil {
+		qb.fromSub = &clause{sql: s.From.SQL, args: s.From.Args}
+	}
+	for _, c := range s.Columns {
+		qb.columns = append(qb.columns, clause{sql: c.SQL, args: c.Args})
+	}
+	if len(qb.columns) == 0 {
+		qb.columns = []clause{{sql: "*"}}
+	}
+	for _, j := range s.Joins {
+		if j.Type != "LEFT" && j.Type != "INNER" && j.Type != "RIGHT" {
+			return "", nil, fmt.Errorf("unsupported join type: %s", j.Type)
+		}
+		safeJoin, err := EscapeIdentifier(s.DBType, j.Table)
+		if err != nil {
+			return "", nil, err
+		}
+		qb.joins = append(qb.joins, joinClause{kind: j.Type, table: j.Table, safeTable: safeJoin, on: j.On})
+	}
+	for _, c := range s.Where {
+		qb.conditions = append(qb.conditions, clause{sql: c.SQL, args: c.Args, native: c.Native})
+	}
+	for _, c := range s.Having {
+		qb.having = append(qb.having, clause{sql: c.SQL, args: c.Args, native: c.Native})
+	}
+	for _, c := range s.OrderBy {
+		qb.orderBy = append(qb.orderBy, clause{sql: c.SQL, args: c.Args})
+	}
+	w := newQueryWriter(s.DBType)
+	qb.writeSelect(w)
+	return w.String(), w.args, nil
+}
+
+/*
+WhereFragment
+
+@ Return: WHERE conditions of the builder and the global scopes of its table joined with AND, without the WHERE
+keyword, as a Fragment with "?" placeholders, for appending to a query written elsewhere. Error if the builder is invalid or uses WhereRaw
+*/
+func (qb *QueryBuilder) WhereFragment() (Fragment, error) {
+	if qb = qb.withScopes(); qb.err != nil {
+		return Fragment{}, qb.err
+	}
+	conditions := qb.selectConditions()
+	if slices.ContainsFunc(conditions, func(c clause) bool { return c.native }) {
+		return Fragment{}, fmt.Errorf("WhereRaw() conditions cannot be used with WhereFragment()")
+	}
+	w := newQueryWriter(qb.dbType)
+	w.embed = true
+	w.writeClauses(conditions, " AND ")
+	return Fragment{SQL: w.String(), Args: w.args}, nil
+}
+
+/*
+Render
+
+@ dbType: Database type of the surrounding query
+@ startIdx: Index of the first placeholder, e.g. N+1 when the surrounding query already uses $1..$N
+@ Return: SQL of the fragment with the dialect's placeholders
+*/
+func (f Fragment) Render(dbType DBType, startIdx int) string {
+	return ReplacePlaceholders(dbType, f.SQL, startIdx)
+}
@@ -0,0 +1,216 @@
+package gqbd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Batch is one statement of a chunked insert.
+type Batch struct {
+	Query string
+	Args  []interface{}
+}
+
+/*
+ToSql
+
+@ Return: Query and args of the batch, so a Batch can be passed to Tx.RunBatch
+*/
+func (b Batch) ToSql() (string, []interface{}, error) {
+	return b.Query, b.Args, nil
+}
+
+/*
+InsertBatch
+
+@ rows: []map[string]interface{} with the same keys in every map, or a slice of structs (or struct pointers)
+with `db:"column"` tags; struct fields are inserted even when they hold zero values
+@ Return: *QueryBuilder with the rows set as a multi-row insert, for Build, BuildBatches or ExecBatches
+*/
+func (qb *QueryBuilder) InsertBatch(rows interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("InsertBatch() can only be used with INSERT operation")
+		return qb
+	}
+	cols, values, err := batchRows(rows)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.insertCols = cols
+	qb.rows = values
+	return qb
+}
+
+/*
+batchRows
+
+@ rows: Rows given to InsertBatch
+@ Return: Columns and row values, and error if the rows are empty or their columns differ
+*/
+func batchRows(rows interface{}) ([]string, [][]interface{}, error) {
+	if maps, ok := rows.([]map[string]interface{}); ok {
+		if len(maps) == 0 {
+			return nil, nil, fmt.Errorf("InsertBatch() requires at least one row")
+		}
+		cols := sortedKeys(maps[0])
+		values := make([][]interface{}, len(maps))
+		for i, m := range maps {
+			if len(m) != len(cols) {
+				return nil, nil, fmt.Errorf("InsertBatch() row %d has %d columns, expected %d", i, len(m), len(cols))
+			}
+			values[i] = make([]interface{}, len(cols))
+			for j, col := range cols {
+				v, ok := m[col]
+				if !ok {
+					return nil, nil, fmt.Errorf("InsertBatch() row %d is missing column %s", i, col)
+				}
+				values[i][j] = v
+			}
+		}
+		return cols, values, nil
+	}
+
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("InsertBatch() expects a slice of maps or structs, got %T", rows)
+	}
+	if rv.Len() == 0 {
+		return nil, nil, fmt.Errorf("InsertBatch() requires at least one row")
+	}
+	var cols []string
+	values := make([][]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		rowCols, row, err := structColumns(rv.Index(i).Interface(), []StructOption{IncludeZeroValues(), skipAutoColumns})
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			cols = rowCols
+		} else if !slices.Equal(cols, rowCols) {
+			return nil, nil, fmt.Errorf("InsertBatch() row %d has columns %v, expected %v", i, rowCols, cols)
+		}
+		values[i] = row
+	}
+	return cols, values, nil
+}
+
+/*
+chunks
+
+@ Return: Copies of the builder with one chunk of rows each, sized to the dialect's bind parameter limit
+from the parameters each row really binds, and error if the rows are missing or one row exceeds the limit
+*/
+func (qb *QueryBuilder) chunks() ([]*QueryBuilder, error) {
+	if qb.err != nil {
+		return nil, qb.err
+	}
+	if qb.op != "INSERT" {
+		return nil, fmt.Errorf("batches can only be built for INSERT operation")
+	}
+	// Protected columns are checked here, before defaults become explicit columns of the chunks.
+	if err := qb.checkProtected(); err != nil {
+		return nil, err
+	}
+	cols, rows, err := qb.insertRows()
+	if err != nil {
+		return nil, err
+	}
+	var chunks []*QueryBuilder
+	add := func(rows [][]interface{}) {
+		c := qb.Clone()
+		c.insertCols, c.rows, c.data, c.defaults, c.protected = cols, rows, nil, nil, nil
+		chunks = append(chunks, c)
+	}
+	maxParams := dialectOf(qb.dbType).Features().MaxParams
+	if maxParams <= 0 {
+		add(rows)
+		return chunks, nil
+	}
+	start, params := 0, 0
+	for i, row := range rows {
+		n := rowParams(row)
+		if n > maxParams {
+			return nil, fmt.Errorf("row %d binds %d parameters, more than the limit of %d for db type: %v", i, n, maxParams, qb.dbType)
+		}
+		if params+n > maxParams {
+			add(rows[start:i])
+			start, params = i, 0
+		}
+		params += n
+	}
+	add(rows[start:])
+	return chunks, nil
+}
+
+/*
+rowParams
+
+@ row: Values of one inserted row
+@ Return: Number of bind parameters the row uses; an Expression binds its own args instead of one parameter
+*/
+func rowParams(row []interface{}) int {
+	n := 0
+	for _, v := range row {
+		if expr, ok := v.(Expression); ok {
+			n += len(expr.args)
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+/*
+BuildBatches
+
+@ Return: One statement per chunk of rows, each within the dialect's bind parameter limit
+(65535 on PostgreSQL and MariaDB/Mysql, 2100 on MSSQL), and error if any
+*/
+func (qb *QueryBuilder) BuildBatches() ([]Batch, error) {
+	chunks, err := qb.chunks()
+	if err != nil {
+		return nil, err
+	}
+	batches := make([]Batch, len(chunks))
+	for i, c := range chunks {
+		if batches[i].Query, batches[i].Args, err = c.Build(); err != nil {
+			return nil, err
+		}
+	}
+	return batches, nil
+}
+
+/*
+ExecBatches
+
+@ ctx: Context for the statements
+@ db: Database handle to run the statements against; pass a *sql.Tx to insert all chunks atomically
+@ opts: Execution options applied to every chunk
+@ Return: Total number of affected rows, and error from the first failing chunk
+*/
+func (qb *QueryBuilder) ExecBatches(ctx context.Context, db Executor, opts ...ExecOption) (int64, error) {
+	chunks, err := qb.chunks()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, c := range chunks {
+		result, err := c.Exec(ctx, db, opts...)
+		if err != nil {
+			return total, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
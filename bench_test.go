@@ -0,0 +1,175 @@
+package gqbd_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+BenchmarkBuildSelect
+
+@ Return: Cost of building a filtered, ordered and paginated SELECT
+*/
+func BenchmarkBuildSelect(b *testing.B) {
+	for _, dbType := range []gqbd.DBType{gqbd.PostgreSQL, gqbd.MariaDB, gqbd.MSSQL} {
+		b.Run(string(dbType), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_, _, err := gqbd.BuildSelect(dbType, "users", "id", "name", "email").
+					LeftJoin("orders o", "o.user_id = users.id").
+					Where("status = ?", "active").
+					Where("created_at > ?", "2024-01-01").
+					WhereIn("role", []interface{}{"admin", "owner", "member"}).
+					OrderBy("id", "DESC", nil).
+					Limit(20).
+					Offset(40).
+					Build()
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+/*
+BenchmarkBuildInsert
+
+@ Return: Cost of building a single-row INSERT
+*/
+func BenchmarkBuildInsert(b *testing.B) {
+	data := map[string]interface{}{"name": "Alice", "email": "alice@example.com", "age": 30, "active": true}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").Values(data).Build(); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+/*
+BenchmarkBuildUpdate
+
+@ Return: Cost of building an UPDATE with a WHERE condition
+*/
+func BenchmarkBuildUpdate(b *testing.B) {
+	data := map[string]interface{}{"name": "Alice", "email": "alice@example.com", "age": 30}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").Set(data).Where("id = ?", 1).Build(); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+/*
+BenchmarkGeneratePlaceholders
+
+@ Return: Cost of numbering placeholders for a 100-value list
+*/
+func BenchmarkGeneratePlaceholders(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		gqbd.GeneratePlaceholders(gqbd.PostgreSQL, 1, 100)
+	}
+}
+
+/*
+BenchmarkGetRelease
+
+@ Return: Cost of building a SELECT with a pooled builder
+*/
+func BenchmarkGetRelease(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		qb := gqbd.Get(gqbd.PostgreSQL, "users", "id", "name").Where("status = ?", "active").OrderBy("id", "DESC", nil).Limit(20)
+		if _, _, err := qb.Build(); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		qb.Release()
+	}
+}
+
+// byteWriter is an io.Writer without WriteString, such as a network connection or a hash.
+type byteWriter struct {
+	n int
+}
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	return len(p), nil
+}
+
+// benchWriter is the destination of BenchmarkBuildTo, kept in a variable so the write is not devirtualized.
+var benchWriter io.Writer = &byteWriter{}
+
+/*
+BenchmarkBuildTo
+
+@ Return: Cost of writing a built query to an io.Writer without WriteString with BuildTo, compared with Build followed
+by a write of the query converted to bytes
+*/
+func BenchmarkBuildTo(b *testing.B) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name", "email").
+		Where("status = ?", "active").
+		WhereIn("role", []interface{}{"admin", "owner", "member"}).
+		OrderBy("id", "DESC", nil).
+		Limit(20)
+	b.Run("BuildTo", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := qb.BuildTo(benchWriter); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+	b.Run("Build", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			query, _, err := qb.Build()
+			if err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := benchWriter.Write([]byte(query)); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+}
+
+/*
+BenchmarkShapeCache
+
+@ Return: Cost of building a SELECT through a warm ShapeCache, compared with building it without the cache
+*/
+func BenchmarkShapeCache(b *testing.B) {
+	cache := gqbd.NewShapeCache()
+	find := func() *gqbd.QueryBuilder {
+		return gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name", "email").
+			LeftJoin("orders o", "o.user_id = users.id").
+			Where("status = ?", "active").
+			Where("created_at > ?", "2024-01-01").
+			WhereIn("role", []interface{}{"admin", "owner", "member"}).
+			OrderBy("id", "DESC", nil).
+			Limit(20).
+			Offset(40)
+	}
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, _, err := find().CacheShape(cache, "find_users").Build(); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, _, err := find().Build(); err != nil {
+				b.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+}
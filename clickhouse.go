@@ -0,0 +1,93 @@
+package gqbd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Final
+
+@ Return: *QueryBuilder reading the table with FINAL, merging rows of ReplacingMergeTree and similar engines (ClickHouse only)
+*/
+func (qb *QueryBuilder) Final() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if err := qb.checkClickHouse("Final"); err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.final = true
+	return qb
+}
+
+/*
+Sample
+
+@ ratio: Fraction of the data to read, between 0 and 1, or an approximate number of rows when greater than 1
+@ Return: *QueryBuilder reading the table with SAMPLE (ClickHouse only); the table must declare a sampling key
+*/
+func (qb *QueryBuilder) Sample(ratio float64) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if err := qb.checkClickHouse("Sample"); err != nil {
+		qb.err = err
+		return qb
+	}
+	if ratio <= 0 {
+		qb.err = fmt.Errorf("Sample() requires a positive ratio, got %v", ratio)
+		return qb
+	}
+	qb.sample = strconv.FormatFloat(ratio, 'f', -1, 64)
+	return qb
+}
+
+/*
+LimitBy
+
+@ limit: Maximum number of rows per distinct combination of columns
+@ columns: Columns the rows are grouped by
+@ Return: *QueryBuilder with LIMIT n BY columns added after ORDER BY (ClickHouse only)
+*/
+func (qb *QueryBuilder) LimitBy(limit int, columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if err := qb.checkClickHouse("LimitBy"); err != nil {
+		qb.err = err
+		return qb
+	}
+	if limit <= 0 || len(columns) == 0 {
+		qb.err = fmt.Errorf("LimitBy() requires a positive limit and at least one column")
+		return qb
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.limitBy = &clause{sql: "LIMIT ? BY " + strings.Join(safeCols, ", "), args: []interface{}{limit}}
+	return qb
+}
+
+/*
+checkClickHouse
+
+@ method: Name of the calling method, for the error message
+@ Return: Error unless the builder is a ClickHouse SELECT
+*/
+func (qb *QueryBuilder) checkClickHouse(method string) error {
+	if qb.op != "SELECT" {
+		return fmt.Errorf("%s() can only be used with SELECT operation", method)
+	}
+	if qb.dbType != ClickHouse {
+		return fmt.Errorf("%s() is not supported for db type: %v", method, qb.dbType)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package gqbd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+BuildSelect
+
+@ Return: Backtick-quoted SELECT with ? placeholders, FINAL and SAMPLE after the table and LIMIT n BY before LIMIT
+*/
+func TestBuildSelectClickHouse(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.ClickHouse, "events", "user_id", "kind", "ts").
+		Final().
+		Sample(0.1).
+		Where("kind = ?", "click").
+		OrderBy("ts", "DESC", map[string]bool{"ts": true}).
+		LimitBy(3, "user_id").
+		Limit(100).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `user_id`, `kind`, `ts` FROM `events` FINAL SAMPLE 0.1 WHERE kind = ? ORDER BY `ts` DESC LIMIT ? BY `user_id` LIMIT ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"click", 3, 100}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.MariaDB, "events").Final().Build(); err == nil {
+		t.Error("expected error for Final on MariaDB")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.ClickHouse, "events").Sample(0).Build(); err == nil {
+		t.Error("expected error for non-positive sample ratio")
+	}
+	_, _, err = gqbd.BuildInsert(gqbd.ClickHouse, "events").
+		Values(map[string]interface{}{"kind": "click"}).
+		Returning("id").
+		Build()
+	if err == nil {
+		t.Error("expected error for Returning on ClickHouse")
+	}
+}
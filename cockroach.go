@@ -0,0 +1,136 @@
+package gqbd
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// asOfSystemTimeRegexp matches AS OF SYSTEM TIME literals such as "-10s", "2024-01-02 15:04:05" or a decimal HLC timestamp.
+var asOfSystemTimeRegexp = regexp.MustCompile(`^[-+]?[0-9A-Za-z.:_ ]+$`)
+
+/*
+AsOfSystemTime
+
+@ ts: Timestamp or interval literal, e.g. "-10s" or "2024-01-02 15:04:05"
+@ Return: *QueryBuilder reading the tables as of the given time (CockroachDB only)
+*/
+func (qb *QueryBuilder) AsOfSystemTime(ts string) *QueryBuilder {
+	qb = qb.asOfSystemTimeCheck("AsOfSystemTime")
+	if qb.err != nil {
+		return qb
+	}
+	if !asOfSystemTimeRegexp.MatchString(ts) {
+		qb.err = fmt.Errorf("invalid AS OF SYSTEM TIME value: %s", ts)
+		return qb
+	}
+	qb.asOfSystemTime = "'" + ts + "'"
+	return qb
+}
+
+/*
+AsOfSystemTimeRaw
+
+@ expr: Raw timestamp expression, e.g. Raw("follower_read_timestamp()")
+@ Return: *QueryBuilder reading the tables as of the given time (CockroachDB only)
+*/
+func (qb *QueryBuilder) AsOfSystemTimeRaw(expr RawExpr) *QueryBuilder {
+	qb = qb.asOfSystemTimeCheck("AsOfSystemTimeRaw")
+	if qb.err != nil {
+		return qb
+	}
+	qb.asOfSystemTime = expr.sql
+	return qb
+}
+
+/*
+asOfSystemTimeCheck
+
+@ method: Name of the calling method, for errors
+@ Return: Mutable *QueryBuilder, with an error recorded unless it is a CockroachDB SELECT
+*/
+func (qb *QueryBuilder) asOfSystemTimeCheck(method string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "SELECT" {
+		qb.err = fmt.Errorf("%s() can only be used with SELECT operation", method)
+		return qb
+	}
+	if qb.dbType != CockroachDB {
+		qb.err = fmt.Errorf("%s() is not supported for db type: %v", method, qb.dbType)
+	}
+	return qb
+}
+
+/*
+ForceIndex
+
+@ index: Index to read the table through, e.g. a hash-sharded index
+@ Return: *QueryBuilder with the table written as table@{FORCE_INDEX=index} (CockroachDB only)
+*/
+func (qb *QueryBuilder) ForceIndex(index string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op == "INSERT" {
+		qb.err = fmt.Errorf("ForceIndex() cannot be used with INSERT operation")
+		return qb
+	}
+	if qb.dbType != CockroachDB {
+		qb.err = fmt.Errorf("ForceIndex() is not supported for db type: %v", qb.dbType)
+		return qb
+	}
+	if qb.fromSub != nil {
+		qb.err = fmt.Errorf("ForceIndex() cannot be used with a subquery source")
+		return qb
+	}
+	safeIndex, err := QuoteIdentifier(qb.dbType, index)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.indexHint = "@{FORCE_INDEX=" + safeIndex + "}"
+	return qb
+}
+
+/*
+ReturningNothing
+
+@ Return: *QueryBuilder ending the INSERT, UPDATE or DELETE with RETURNING NOTHING (CockroachDB only),
+which lets the statement run in parallel with later statements of the transaction
+*/
+func (qb *QueryBuilder) ReturningNothing() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op == "SELECT" {
+		qb.err = fmt.Errorf("ReturningNothing() can only be used with write operations")
+		return qb
+	}
+	if qb.dbType != CockroachDB {
+		qb.err = fmt.Errorf("ReturningNothing() is not supported for db type: %v", qb.dbType)
+		return qb
+	}
+	qb.returningNothing = true
+	return qb
+}
+
+/*
+writeReturningNothing
+
+@ w: Writer positioned at the end of the statement
+@ Return: Error if both Returning and ReturningNothing were used
+*/
+func (qb *QueryBuilder) writeReturningNothing(w *queryWriter) error {
+	if !qb.returningNothing {
+		return nil
+	}
+	if qb.returning != "" {
+		return fmt.Errorf("Returning() and ReturningNothing() cannot be used together")
+	}
+	w.write(" RETURNING NOTHING")
+	return nil
+}
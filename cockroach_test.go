@@ -0,0 +1,142 @@
+package gqbd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+BuildSelect
+
+@ Return: PostgreSQL-style SELECT with a forced index and AS OF SYSTEM TIME after the FROM clause
+*/
+func TestBuildSelectCockroachDB(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.CockroachDB, "events", "id", "kind").
+		ForceIndex("events_ts_hash_idx").
+		AsOfSystemTime("-10s").
+		Where("kind = ?", "click").
+		Limit(10).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id", "kind" FROM "events"@{FORCE_INDEX="events_ts_hash_idx"} AS OF SYSTEM TIME '-10s' WHERE kind = $1 LIMIT $2`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"click", 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildSelect(gqbd.CockroachDB, "events", "id").
+		AsOfSystemTimeRaw(gqbd.Raw("follower_read_timestamp()")).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = `SELECT "id" FROM "events" AS OF SYSTEM TIME follower_read_timestamp()`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.CockroachDB, "events").AsOfSystemTime("'; DROP TABLE events; --").Build(); err == nil {
+		t.Error("expected error for invalid AS OF SYSTEM TIME value")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "events").AsOfSystemTime("-10s").Build(); err == nil {
+		t.Error("expected error for AsOfSystemTime on PostgreSQL")
+	}
+}
+
+/*
+ReturningNothing
+
+@ Return: Write statements ending with RETURNING NOTHING, rejected together with Returning
+*/
+func TestReturningNothingCockroachDB(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.CockroachDB, "events").
+		Values(map[string]interface{}{"kind": "click"}).
+		OnConflict("id").
+		DoNothing().
+		ReturningNothing().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `INSERT INTO "events" ("kind") VALUES ($1) ON CONFLICT ("id") DO NOTHING RETURNING NOTHING`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"click"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildDelete(gqbd.CockroachDB, "events").
+		ForceIndex("events_ts_hash_idx").
+		Where("ts < ?", "2024-01-01").
+		ReturningNothing().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = `DELETE FROM "events"@{FORCE_INDEX="events_ts_hash_idx"} WHERE ts < $1 RETURNING NOTHING`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	_, _, err = gqbd.BuildInsert(gqbd.CockroachDB, "events").
+		Values(map[string]interface{}{"kind": "click"}).
+		Returning("id").
+		ReturningNothing().
+		Build()
+	if err == nil {
+		t.Error("expected error for Returning with ReturningNothing")
+	}
+}
+
+/*
+JSON helpers
+
+@ Return: jsonb containment, path existence and field extraction, as on PostgreSQL
+*/
+func TestJSONHelpersCockroachDB(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.CockroachDB, "users", "id").
+		SelectJSONField("meta", "$.name", "name").
+		WhereJSONContains("meta", map[string]interface{}{"role": "admin"}).
+		WhereJSONPathExists("meta", "$.a.b").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id", jsonb_path_query_first("meta", $1::jsonpath) #>> '{}' AS "name" FROM "users" WHERE "meta" @> $2::jsonb AND jsonb_path_exists("meta", $3::jsonpath)`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"$.name", `{"role":"admin"}`, "$.a.b"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+WhereFullText
+
+@ Return: to_tsvector over the columns matched with plainto_tsquery, ranked with ts_rank, as on PostgreSQL
+*/
+func TestWhereFullTextCockroachDB(t *testing.T) {
+	columns := []string{"title", "body"}
+	query, args, err := gqbd.BuildSelect(gqbd.CockroachDB, "posts", "id").
+		SelectExpr(gqbd.FullTextRank(gqbd.CockroachDB, columns, "go sql").As("rank")).
+		WhereFullText(columns, "go sql").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id", ts_rank(to_tsvector(concat_ws(' ', "title", "body")), plainto_tsquery($1)) AS "rank" FROM "posts" WHERE to_tsvector(concat_ws(' ', "title", "body")) @@ plainto_tsquery($2)`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"go sql", "go sql"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
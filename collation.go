@@ -0,0 +1,94 @@
+package gqbd
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// collationNameRegexp matches collation names that need no quoting, such as utf8mb4_unicode_ci.
+var collationNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+/*
+plainCollation
+
+@ name: Collation name
+@ Return: Name unchanged, and error if it is not a plain word
+*/
+func plainCollation(name string) (string, error) {
+	if !collationNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid collation name: %s", name)
+	}
+	return name, nil
+}
+
+/*
+WithCollation
+
+@ collation: Collation name, e.g. "utf8mb4_unicode_ci" (MariaDB/Mysql) or "und-x-icu" (PostgreSQL)
+@ Return: *QueryBuilder adding COLLATE to comparisons with string values and to ORDER BY columns
+generated by the methods called after it. Raw conditions passed to Where are left unchanged
+*/
+func (qb *QueryBuilder) WithCollation(collation string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	d, err := dialectFor(qb.dbType)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	safeName, err := d.Collation(collation)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.collation = safeName
+	return qb
+}
+
+/*
+collate
+
+@ safeCol: Escaped column
+@ value: Value the column is compared with
+@ Return: Column with COLLATE applied when a collation is set and the value is a string
+*/
+func (qb *QueryBuilder) collate(safeCol string, value interface{}) string {
+	if _, ok := value.(string); !ok || qb.collation == "" {
+		return safeCol
+	}
+	return safeCol + " COLLATE " + qb.collation
+}
+
+/*
+collateOrder
+
+@ safeCol: Escaped column
+@ Return: Column with COLLATE applied when a collation is set, for ORDER BY
+*/
+func (qb *QueryBuilder) collateOrder(safeCol string) string {
+	if qb.collation == "" {
+		return safeCol
+	}
+	return safeCol + " COLLATE " + qb.collation
+}
+
+/*
+collateIn
+
+@ safeCol: Escaped column
+@ values: Values of an IN list
+@ Return: Column with COLLATE applied when a collation is set and every value is a string
+*/
+func (qb *QueryBuilder) collateIn(safeCol string, values []interface{}) string {
+	if len(values) == 0 {
+		return safeCol
+	}
+	for _, v := range values {
+		if _, ok := v.(string); !ok {
+			return safeCol
+		}
+	}
+	return qb.collate(safeCol, values[0])
+}
@@ -0,0 +1,89 @@
+package gqbd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqliteCompatRewrites translate PostgreSQL functions and operators to their SQLite equivalents for BuildSQLite.
+var sqliteCompatRewrites = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)\bNOT\s+ILIKE\b`), "NOT LIKE"},
+	{regexp.MustCompile(`(?i)\bILIKE\b`), "LIKE"},
+	{regexp.MustCompile(`(?i)\bNOW\(\)`), "CURRENT_TIMESTAMP"},
+	{regexp.MustCompile(`(?i)\bgen_random_uuid\(\)`), "lower(hex(randomblob(16)))"},
+	{regexp.MustCompile(`(?i)\bstring_agg\(`), "group_concat("},
+	{regexp.MustCompile(`::[A-Za-z_][A-Za-z0-9_]*(\[\])?`), ""},
+}
+
+/*
+BuildSQLite
+
+@ Return: Query string and arguments of a PostgreSQL builder rendered for SQLite, and error if the query
+uses features outside the supported subset. Meant for unit tests running against an in-memory SQLite.
+Identifiers keep their double quotes, placeholders become "?" (WhereRaw's $n are expanded in order),
+ILIKE becomes LIKE, NOW() becomes CURRENT_TIMESTAMP, gen_random_uuid() becomes lower(hex(randomblob(16))),
+string_agg becomes group_concat and ::type casts are dropped. ROLLUP, CUBE, GROUPING SETS, constraint
+conflict targets and collations are rejected
+*/
+func (qb *QueryBuilder) BuildSQLite() (string, []interface{}, error) {
+	if qb.err != nil {
+		return "", nil, qb.err
+	}
+	if qb.dbType != PostgreSQL {
+		return "", nil, fmt.Errorf("BuildSQLite() can only be used with PostgreSQL builders, got %v", qb.dbType)
+	}
+	for _, group := range qb.groupBy {
+		for _, unsupported := range []string{"ROLLUP (", "CUBE (", "GROUPING SETS ("} {
+			if strings.HasPrefix(group, unsupported) {
+				return "", nil, fmt.Errorf("BuildSQLite() does not support %s", strings.TrimSuffix(unsupported, " ("))
+			}
+		}
+	}
+	if qb.conflict != nil && qb.conflict.constraint != "" {
+		return "", nil, fmt.Errorf("BuildSQLite() does not support OnConflictConstraint()")
+	}
+	if qb.collation != "" {
+		return "", nil, fmt.Errorf("BuildSQLite() does not support WithCollation()")
+	}
+	compat := qb.Clone()
+	compat.dbType = SQLite
+	for _, clauses := range [][]clause{compat.conditions, compat.having} {
+		for i, c := range clauses {
+			if c.native {
+				clauses[i] = positionalClause(c)
+			}
+		}
+	}
+	query, args, err := compat.Build()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, rw := range sqliteCompatRewrites {
+		query = rw.re.ReplaceAllString(query, rw.repl)
+	}
+	return query, args, nil
+}
+
+// postgresPlaceholderRegexp matches the $n placeholders of a native PostgreSQL clause.
+var postgresPlaceholderRegexp = regexp.MustCompile(`\$(\d+)`)
+
+/*
+positionalClause
+
+@ c: Native PostgreSQL clause with $n placeholders relative to the clause
+@ Return: Clause with "?" placeholders and the args repeated and reordered to match them
+*/
+func positionalClause(c clause) clause {
+	var args []interface{}
+	sql := postgresPlaceholderRegexp.ReplaceAllStringFunc(c.sql, func(m string) string {
+		n, _ := strconv.Atoi(m[1:])
+		args = append(args, c.args[n-1])
+		return "?"
+	})
+	return clause{sql: sql, args: args}
+}
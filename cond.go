@@ -0,0 +1,152 @@
+package gqbd
+
+import "slices"
+
+// Cond is a set of WHERE conditions declared once and attached to several builders, e.g. the list, count and
+// export queries of one search form. Columns are escaped for each builder's DBType when attached.
+// A Cond is immutable: every method returns a new Cond, so shared values can be extended safely.
+type Cond struct {
+	preds []func(*QueryBuilder) *QueryBuilder
+}
+
+/*
+NewCond
+
+@ Return: Empty Cond
+*/
+func NewCond() Cond {
+	return Cond{}
+}
+
+/*
+add
+
+@ pred: Builder method adding the condition
+@ Return: Copy of the Cond with pred appended
+*/
+func (c Cond) add(pred func(*QueryBuilder) *QueryBuilder) Cond {
+	return Cond{preds: append(slices.Clip(c.preds), pred)}
+}
+
+/*
+Where
+
+@ condition: Condition string with "?" or :name placeholders
+@ args: Query parameters, as for QueryBuilder.Where
+@ Return: Cond with the condition added
+*/
+func (c Cond) Where(condition string, args ...interface{}) Cond {
+	return c.add(func(qb *QueryBuilder) *QueryBuilder { return qb.Where(condition, args...) })
+}
+
+/*
+WhereIf
+
+@ cond: Whether to add the condition
+@ condition: Condition string with placeholders
+@ args: Query parameters
+@ Return: Cond with the condition added when cond is true
+*/
+func (c Cond) WhereIf(cond bool, condition string, args ...interface{}) Cond {
+	if !cond {
+		return c
+	}
+	return c.Where(condition, args...)
+}
+
+/*
+WhereEq
+
+@ column: Column name
+@ value: Value to compare against; nil matches with IS NULL
+@ Return: Cond with column = value added
+*/
+func (c Cond) WhereEq(column string, value interface{}) Cond {
+	return c.add(func(qb *QueryBuilder) *QueryBuilder { return qb.WhereEq(column, value) })
+}
+
+/*
+WhereIn
+
+@ column: Column name for IN clause
+@ values: Values for IN clause
+@ Return: Cond with the IN condition added
+*/
+func (c Cond) WhereIn(column string, values []interface{}) Cond {
+	return c.add(func(qb *QueryBuilder) *QueryBuilder { return qb.WhereIn(column, values) })
+}
+
+/*
+WhereBetween
+
+@ column: Column name for BETWEEN clause
+@ start: Start value
+@ end: End value
+@ Return: Cond with the BETWEEN condition added
+*/
+func (c Cond) WhereBetween(column string, start, end interface{}) Cond {
+	return c.add(func(qb *QueryBuilder) *QueryBuilder { return qb.WhereBetween(column, start, end) })
+}
+
+/*
+WhereLike
+
+@ column: Column name
+@ pattern: LIKE pattern
+@ Return: Cond with the LIKE condition added
+*/
+func (c Cond) WhereLike(column string, pattern string) Cond {
+	return c.add(func(qb *QueryBuilder) *QueryBuilder { return qb.WhereLike(column, pattern) })
+}
+
+/*
+WhereMap
+
+@ conditions: Map of column names to values; nil values match with IS NULL
+@ Return: Cond with one equality condition per column added
+*/
+func (c Cond) WhereMap(conditions map[string]interface{}) Cond {
+	return c.add(func(qb *QueryBuilder) *QueryBuilder { return qb.WhereMap(conditions) })
+}
+
+/*
+And
+
+@ others: Conditions to combine with c
+@ Return: Cond with the conditions of others appended
+*/
+func (c Cond) And(others ...Cond) Cond {
+	preds := slices.Clip(c.preds)
+	for _, other := range others {
+		preds = append(preds, other.preds...)
+	}
+	return Cond{preds: preds}
+}
+
+/*
+Fragment
+
+@ dbType: Database type the columns are escaped for
+@ Return: Conditions joined with AND as a Fragment with "?" placeholders, and error from any condition.
+Global scopes are not applied, since the fragment belongs to no table
+*/
+func (c Cond) Fragment(dbType DBType) (Fragment, error) {
+	return BuildSelect(dbType, "cond").WithoutScopes().WhereCond(c).WhereFragment()
+}
+
+/*
+WhereCond
+
+@ c: Conditions declared with Cond
+@ Return: *QueryBuilder with every condition of c added
+*/
+func (qb *QueryBuilder) WhereCond(c Cond) *QueryBuilder {
+	qb = qb.mutable()
+	for _, pred := range c.preds {
+		if qb.err != nil {
+			return qb
+		}
+		qb = pred(qb)
+	}
+	return qb
+}
@@ -0,0 +1,172 @@
+package gqbd
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// debugPrefix marks DebugSQL output so that it is not mistaken for a query to run.
+const debugPrefix = "/* gqbd DebugSQL: not for execution */ "
+
+var (
+	_ fmt.Stringer   = (*QueryBuilder)(nil)
+	_ slog.LogValuer = (*QueryBuilder)(nil)
+)
+
+/*
+DebugSQL
+
+@ Return: Query with the bound arguments written inline as SQL literals, for logs and for pasting into a
+database console. It is NOT safe to execute: the output starts with a comment saying so, and values are only
+quoted for reading. Build errors are returned inside a comment
+*/
+func (qb *QueryBuilder) DebugSQL() string {
+	var spans []argSpan
+	debug := *qb
+	debug.argSpans = &spans
+	query, args, err := debug.Build()
+	if err != nil {
+		return "/* gqbd DebugSQL: " + err.Error() + " */"
+	}
+	// Placeholders are replaced where the writer put them, so "?" and "$1" inside literals are left alone.
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.arg < 0 || s.arg >= len(args) || s.start < last {
+			continue
+		}
+		sb.WriteString(query[last:s.start])
+		sb.WriteString(debugLiteral(qb.dbType, args[s.arg]))
+		last = s.end
+	}
+	sb.WriteString(query[last:])
+	return debugPrefix + sb.String()
+}
+
+/*
+debugLiteral
+
+@ dbType: Database type
+@ arg: Bound argument
+@ Return: Argument written as a SQL literal of the dialect
+*/
+func debugLiteral(dbType DBType, arg interface{}) string {
+	arg = plainArg(arg)
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "/* " + err.Error() + " */"
+		}
+		arg = v
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		switch dbType {
+		case PostgreSQL, CockroachDB:
+			return `'\x` + hex.EncodeToString(v) + "'"
+		case MSSQL:
+			return "0x" + hex.EncodeToString(v)
+		default:
+			return "X'" + hex.EncodeToString(v) + "'"
+		}
+	case bool:
+		if dbType == MSSQL || dbType == Oracle {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		return strings.ToUpper(strconv.FormatBool(v))
+	case time.Time:
+		switch dbType {
+		case PostgreSQL, CockroachDB:
+			return "'" + v.Format("2006-01-02 15:04:05.999999Z07:00") + "'"
+		case Oracle:
+			return "TIMESTAMP '" + v.Format("2006-01-02 15:04:05.999999") + "'"
+		default:
+			return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+		}
+	}
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return debugLiteral(dbType, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(arg)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL"
+		}
+		return debugLiteral(dbType, rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if dbType == PostgreSQL || dbType == CockroachDB {
+			items := make([]string, rv.Len())
+			for i := range items {
+				items[i] = debugLiteral(dbType, rv.Index(i).Interface())
+			}
+			return "ARRAY[" + strings.Join(items, ", ") + "]"
+		}
+	}
+	return debugLiteral(dbType, fmt.Sprint(arg))
+}
+
+/*
+String
+
+@ Return: Built query and number of arguments, e.g. `SELECT "id" FROM "users" WHERE id = $1 (1 arg)`, or the build
+error. Argument values are not shown; use DebugSQL for those. Hooks are not run
+*/
+func (qb *QueryBuilder) String() string {
+	query, args, err := qb.quietBuild()
+	if err != nil {
+		return fmt.Sprintf("gqbd.QueryBuilder(%s %s: %v)", qb.op, qb.tableName, err)
+	}
+	if len(args) == 1 {
+		return query + " (1 arg)"
+	}
+	return fmt.Sprintf("%s (%d args)", query, len(args))
+}
+
+/*
+LogValue
+
+@ Return: slog group with the statement kind, table, query name, built query and argument count, or the build error.
+Argument values are left out, since they may hold personal data
+*/
+func (qb *QueryBuilder) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("kind", qb.op),
+		slog.String("table", qb.tableName),
+	}
+	if qb.name != "" {
+		attrs = append(attrs, slog.String("name", qb.name))
+	}
+	query, args, err := qb.quietBuild()
+	if err != nil {
+		return slog.GroupValue(append(attrs, slog.String("error", err.Error()))...)
+	}
+	return slog.GroupValue(append(attrs, slog.String("query", query), slog.Int("args", len(args)))...)
+}
+
+/*
+quietBuild
+
+@ Return: Same as Build, without running the builder's hooks
+*/
+func (qb *QueryBuilder) quietBuild() (string, []interface{}, error) {
+	quiet := *qb
+	quiet.hooks = nil
+	return quiet.Build()
+}
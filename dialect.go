@@ -0,0 +1,523 @@
+package gqbd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Dialect describes the SQL syntax of a database. Dialects are registered for PostgreSQL, MariaDB,
+// Mysql, SQLite, MSSQL, Oracle, CockroachDB and ClickHouse; RegisterDialect adds others. Execution helpers such as hints,
+// SerializeBy and Explain only support the built-in dialects.
+type Dialect interface {
+	// Quote quotes a single, non-empty identifier.
+	Quote(name string) (string, error)
+	// Placeholder returns the n-th (1-based) bind placeholder, e.g. "$1" or "?".
+	Placeholder(n int) string
+	// Limit returns the clause written after SELECT (e.g. TOP) and the clause written at the end
+	// of the query, using "?" for the values bound in the fragments' Args.
+	Limit(spec LimitSpec) (head, tail Fragment)
+	// Upsert returns the clause appended to an INSERT to handle conflicting rows.
+	Upsert(spec Upsert) (string, error)
+	// Returning returns the clause written before VALUES (e.g. OUTPUT) and after the rows (e.g. RETURNING).
+	Returning(clause string) (head, tail string, err error)
+	// TableAlias returns the alias clause of a derived table, e.g. " AS t".
+	TableAlias(alias string) string
+	// Collation returns the COLLATE argument for a collation name.
+	Collation(name string) (string, error)
+	// Features reports optional syntax support.
+	Features() DialectFeatures
+}
+
+// LimitSpec describes the rows to return.
+type LimitSpec struct {
+	Limit    int  // Maximum number of rows, 0 for no limit
+	Offset   int  // Rows to skip, 0 for none
+	Ordered  bool // Whether the query has an ORDER BY
+	Compound bool // Whether the query is a UNION, where nothing can be written after the first SELECT
+}
+
+// Upsert describes the conflict handling of an INSERT, with identifiers already quoted.
+type Upsert struct {
+	Columns         []string // Inserted columns
+	ConflictColumns []string // Conflict target columns, may be empty
+	Constraint      string   // Conflict target constraint, may be empty
+	Update          []string // Columns overwritten with the proposed row
+	DoNothing       bool     // Skip conflicting rows instead of updating them
+}
+
+// DialectFeatures reports optional syntax supported by a Dialect.
+type DialectFeatures struct {
+	AggregateFilter    bool // COUNT(...) FILTER (WHERE ...)
+	RowValues          bool // (a, b) > (x, y)
+	GroupingSets       bool // GROUP BY ROLLUP (...), CUBE (...) and GROUPING SETS (...)
+	WithRollup         bool // GROUP BY ... WITH ROLLUP
+	ConflictConstraint bool // Upsert conflict target given by constraint name
+	ParenthesizedUnion bool // (SELECT ... LIMIT n) UNION (SELECT ...)
+	NullsOrdering      bool // ORDER BY ... NULLS FIRST / NULLS LAST
+	TupleIn            bool // (a, b) IN ((x, y), ...)
+	ValuesTable        bool // (VALUES (x, y), ...) AS t(a, b)
+	DeleteUsing        bool // DELETE FROM t USING u WHERE ...
+	DeleteJoin         bool // DELETE t FROM t JOIN u ON ...
+	JSONB              bool // jsonb @>, jsonb_path_exists and jsonb_path_query_first
+	JSONFunctions      bool // JSON_CONTAINS, JSON_CONTAINS_PATH and JSON_EXTRACT
+	TextSearch         bool // to_tsvector, plainto_tsquery and ts_rank full-text search
+	MatchAgainst       bool // MATCH (...) AGAINST (...) full-text search
+	MaxParams          int  // Bind parameters allowed in one statement, 0 for no limit
+}
+
+// registeredDialect is a Dialect with its placeholder numbering worked out once.
+type registeredDialect struct {
+	Dialect
+	prefix   string         // Prefix of numbered placeholders, empty if placeholders are not numbered
+	native   *regexp.Regexp // Matches numbered placeholders, nil if placeholders are not numbered
+	fixed    string         // Placeholder of dialects that do not number them, e.g. "?"
+	numbered bool           // Placeholder(n) is prefix followed by n, so it can be written without calling the dialect
+}
+
+/*
+writePlaceholder
+
+@ sb: Builder receiving the placeholder
+@ n: 1-based index of the placeholder
+@ Return: None. Writes the placeholder without allocating for the built-in dialects
+*/
+func (d *registeredDialect) writePlaceholder(sb *strings.Builder, n int) {
+	switch {
+	case d.numbered:
+		var buf [20]byte
+		sb.WriteString(d.prefix)
+		sb.Write(strconv.AppendInt(buf[:0], int64(n), 10))
+	case d.native == nil:
+		sb.WriteString(d.fixed)
+	default:
+		sb.WriteString(d.Placeholder(n))
+	}
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[DBType]*registeredDialect{}
+)
+
+func init() {
+	RegisterDialect(string(PostgreSQL), postgresDialect{})
+	RegisterDialect(string(MariaDB), mysqlDialect{})
+	RegisterDialect(string(Mysql), mysqlDialect{})
+	RegisterDialect(string(SQLite), sqliteDialect{})
+	RegisterDialect(string(MSSQL), mssqlDialect{})
+	RegisterDialect(string(Oracle), oracleDialect{})
+	RegisterDialect(string(CockroachDB), cockroachDialect{})
+	RegisterDialect(string(ClickHouse), clickhouseDialect{})
+}
+
+/*
+RegisterDialect
+
+@ name: Database type name, used as DBType(name) with the builders
+@ d: Dialect of the database; replaces any dialect registered under the same name
+@ Return: None
+*/
+func RegisterDialect(name string, d Dialect) {
+	rd := &registeredDialect{Dialect: d}
+	if first := d.Placeholder(1); first != d.Placeholder(2) {
+		rd.prefix = strings.TrimSuffix(first, "1")
+		rd.native = regexp.MustCompile(regexp.QuoteMeta(rd.prefix) + `(\d+)`)
+		rd.numbered = d.Placeholder(12) == rd.prefix+"12"
+	} else {
+		rd.fixed = first
+	}
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[DBType(name)] = rd
+}
+
+/*
+Dialects
+
+@ Return: Database types with a registered dialect, sorted by name
+*/
+func Dialects() []DBType {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	dbTypes := make([]DBType, 0, len(dialects))
+	for dbType := range dialects {
+		dbTypes = append(dbTypes, dbType)
+	}
+	slices.Sort(dbTypes)
+	return dbTypes
+}
+
+/*
+dialectFor
+
+@ dbType: Database type
+@ Return: Registered dialect, and error if none is registered
+*/
+func dialectFor(dbType DBType) (*registeredDialect, error) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[dbType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported db type: %v", dbType)
+	}
+	return d, nil
+}
+
+// fallbackDialect renders builders of unregistered db types, which already fail when quoting identifiers.
+var fallbackDialect = &registeredDialect{Dialect: mysqlDialect{}}
+
+/*
+dialectOf
+
+@ dbType: Database type
+@ Return: Registered dialect, or a "?" placeholder dialect if none is registered
+*/
+func dialectOf(dbType DBType) *registeredDialect {
+	if d, err := dialectFor(dbType); err == nil {
+		return d
+	}
+	return fallbackDialect
+}
+
+/*
+limitFragment
+
+@ limit: Maximum number of rows, 0 for no limit
+@ offset: Rows to skip, 0 for none
+@ Return: LIMIT ? OFFSET ? fragment shared by the dialects that use it
+*/
+func limitFragment(limit, offset int) Fragment {
+	var f Fragment
+	if limit > 0 {
+		f.SQL += " LIMIT ?"
+		f.Args = append(f.Args, limit)
+	}
+	if offset > 0 {
+		f.SQL += " OFFSET ?"
+		f.Args = append(f.Args, offset)
+	}
+	return f
+}
+
+/*
+onConflict
+
+@ spec: Conflict handling
+@ requireTarget: Whether DO UPDATE needs a conflict target
+@ Return: ON CONFLICT clause shared by PostgreSQL and SQLite, and error if any
+*/
+func onConflict(spec Upsert, requireTarget bool) (string, error) {
+	sql := " ON CONFLICT"
+	switch {
+	case spec.Constraint != "":
+		sql += " ON CONSTRAINT " + spec.Constraint
+	case len(spec.ConflictColumns) > 0:
+		sql += " (" + strings.Join(spec.ConflictColumns, ", ") + ")"
+	case !spec.DoNothing && requireTarget:
+		return "", fmt.Errorf("DoUpdate() requires OnConflict() or OnConflictConstraint() on PostgreSQL")
+	}
+	if spec.DoNothing {
+		return sql + " DO NOTHING", nil
+	}
+	sets := make([]string, len(spec.Update))
+	for i, col := range spec.Update {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", col, col)
+	}
+	return sql + " DO UPDATE SET " + strings.Join(sets, ", "), nil
+}
+
+// postgresDialect is the PostgreSQL dialect.
+type postgresDialect struct{}
+
+func (postgresDialect) Quote(name string) (string, error) {
+	if len(name) > 63 {
+		return "", fmt.Errorf("identifier %q is longer than 63 bytes and would be truncated", name)
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (postgresDialect) Limit(spec LimitSpec) (head, tail Fragment) {
+	return Fragment{}, limitFragment(spec.Limit, spec.Offset)
+}
+
+func (postgresDialect) Upsert(spec Upsert) (string, error) { return onConflict(spec, true) }
+
+func (postgresDialect) Returning(clause string) (head, tail string, err error) {
+	return "", " RETURNING " + clause, nil
+}
+
+func (postgresDialect) TableAlias(alias string) string { return " AS " + alias }
+
+func (d postgresDialect) Collation(name string) (string, error) { return d.Quote(name) }
+
+func (postgresDialect) Features() DialectFeatures {
+	return DialectFeatures{AggregateFilter: true, RowValues: true, GroupingSets: true, ConflictConstraint: true, ParenthesizedUnion: true, NullsOrdering: true, TupleIn: true, ValuesTable: true, DeleteUsing: true, JSONB: true, TextSearch: true, MaxParams: 65535}
+}
+
+// cockroachDialect is the CockroachDB dialect: PostgreSQL syntax without the 63-byte identifier limit
+// or constraint conflict targets.
+type cockroachDialect struct {
+	postgresDialect
+}
+
+func (cockroachDialect) Quote(name string) (string, error) {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+func (d cockroachDialect) Collation(name string) (string, error) { return d.Quote(name) }
+
+func (cockroachDialect) Features() DialectFeatures {
+	return DialectFeatures{AggregateFilter: true, RowValues: true, ParenthesizedUnion: true, NullsOrdering: true, TupleIn: true, ValuesTable: true, DeleteUsing: true, JSONB: true, TextSearch: true, MaxParams: 65535}
+}
+
+// mysqlDialect is the MariaDB and Mysql dialect.
+type mysqlDialect struct{}
+
+func (mysqlDialect) Quote(name string) (string, error) {
+	if utf8.RuneCountInString(name) > 64 {
+		return "", fmt.Errorf("identifier %q is longer than 64 characters", name)
+	}
+	if strings.HasSuffix(name, " ") {
+		return "", fmt.Errorf("identifier %q ends with a space", name)
+	}
+	for _, r := range name {
+		if r > 0xFFFF {
+			return "", fmt.Errorf("identifier %q contains a character outside the Basic Multilingual Plane", name)
+		}
+	}
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`", nil
+}
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) Limit(spec LimitSpec) (head, tail Fragment) {
+	return Fragment{}, limitFragment(spec.Limit, spec.Offset)
+}
+
+func (mysqlDialect) Upsert(spec Upsert) (string, error) {
+	update := spec.Update
+	if spec.DoNothing {
+		update = spec.Columns[:1]
+	}
+	sets := make([]string, len(update))
+	for i, col := range update {
+		if spec.DoNothing {
+			sets[i] = fmt.Sprintf("%s = %s", col, col)
+		} else {
+			sets[i] = fmt.Sprintf("%s = VALUES(%s)", col, col)
+		}
+	}
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+}
+
+func (mysqlDialect) Returning(string) (head, tail string, err error) { return "", "", nil }
+
+func (mysqlDialect) TableAlias(alias string) string { return " AS " + alias }
+
+func (mysqlDialect) Collation(name string) (string, error) { return plainCollation(name) }
+
+func (mysqlDialect) Features() DialectFeatures {
+	return DialectFeatures{WithRollup: true, ParenthesizedUnion: true, TupleIn: true, DeleteJoin: true, JSONFunctions: true, MatchAgainst: true, MaxParams: 65535}
+}
+
+// sqliteDialect is the SQLite dialect.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Quote(name string) (string, error) {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (sqliteDialect) Limit(spec LimitSpec) (head, tail Fragment) {
+	tail = limitFragment(spec.Limit, spec.Offset)
+	if spec.Limit <= 0 && spec.Offset > 0 {
+		tail.SQL = " LIMIT -1" + tail.SQL // SQLite only accepts OFFSET after LIMIT
+	}
+	return Fragment{}, tail
+}
+
+func (sqliteDialect) Upsert(spec Upsert) (string, error) { return onConflict(spec, false) }
+
+func (sqliteDialect) Returning(clause string) (head, tail string, err error) {
+	return "", " RETURNING " + clause, nil
+}
+
+func (sqliteDialect) TableAlias(alias string) string { return " AS " + alias }
+
+func (sqliteDialect) Collation(name string) (string, error) { return plainCollation(name) }
+
+func (sqliteDialect) Features() DialectFeatures {
+	return DialectFeatures{AggregateFilter: true, RowValues: true, NullsOrdering: true, TupleIn: true, MaxParams: 32766}
+}
+
+// clickhouseIdentifierEscaper escapes backslashes and backticks inside a ClickHouse quoted identifier.
+var clickhouseIdentifierEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+// clickhouseDialect is the ClickHouse dialect.
+type clickhouseDialect struct{}
+
+func (clickhouseDialect) Quote(name string) (string, error) {
+	return "`" + clickhouseIdentifierEscaper.Replace(name) + "`", nil
+}
+
+func (clickhouseDialect) Placeholder(int) string { return "?" }
+
+func (clickhouseDialect) Limit(spec LimitSpec) (head, tail Fragment) {
+	return Fragment{}, limitFragment(spec.Limit, spec.Offset)
+}
+
+func (clickhouseDialect) Upsert(Upsert) (string, error) {
+	return "", fmt.Errorf("OnConflict() is not supported for db type: %v", ClickHouse)
+}
+
+func (clickhouseDialect) Returning(string) (head, tail string, err error) {
+	return "", "", fmt.Errorf("Returning() is not supported for db type: %v", ClickHouse)
+}
+
+func (clickhouseDialect) TableAlias(alias string) string { return " AS " + alias }
+
+func (clickhouseDialect) Collation(name string) (string, error) { return plainCollation(name) }
+
+func (clickhouseDialect) Features() DialectFeatures {
+	return DialectFeatures{RowValues: true, GroupingSets: true, WithRollup: true, ParenthesizedUnion: true, NullsOrdering: true, TupleIn: true}
+}
+
+/*
+offsetFetch
+
+@ spec: Rows to return
+@ requireOrder: Whether OFFSET must follow an ORDER BY
+@ Return: OFFSET ... ROWS FETCH NEXT ... ROWS ONLY fragment shared by MSSQL and Oracle
+*/
+func offsetFetch(spec LimitSpec, requireOrder bool) Fragment {
+	var f Fragment
+	if spec.Limit <= 0 && spec.Offset <= 0 {
+		return f
+	}
+	if requireOrder && !spec.Ordered {
+		f.SQL += " ORDER BY (SELECT NULL)"
+	}
+	if spec.Offset > 0 || requireOrder {
+		f.SQL += " OFFSET ? ROWS"
+		f.Args = append(f.Args, max(spec.Offset, 0))
+	}
+	if spec.Limit > 0 {
+		if len(f.Args) > 0 {
+			f.SQL += " FETCH NEXT ? ROWS ONLY"
+		} else {
+			f.SQL += " FETCH FIRST ? ROWS ONLY"
+		}
+		f.Args = append(f.Args, spec.Limit)
+	}
+	return f
+}
+
+// mssqlDialect is the SQL Server dialect.
+type mssqlDialect struct{}
+
+func (mssqlDialect) Quote(name string) (string, error) {
+	if utf8.RuneCountInString(name) > 128 {
+		return "", fmt.Errorf("identifier %q is longer than 128 characters", name)
+	}
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]", nil
+}
+
+func (mssqlDialect) Placeholder(n int) string { return "@p" + strconv.Itoa(n) }
+
+func (mssqlDialect) Limit(spec LimitSpec) (head, tail Fragment) {
+	if spec.Limit > 0 && spec.Offset <= 0 && !spec.Compound {
+		return Fragment{SQL: "TOP (?) ", Args: []interface{}{spec.Limit}}, Fragment{}
+	}
+	return Fragment{}, offsetFetch(spec, true)
+}
+
+func (mssqlDialect) Upsert(Upsert) (string, error) {
+	return "", fmt.Errorf("OnConflict() is not supported for db type: %v", MSSQL)
+}
+
+// outputColumnRegexp matches a plain column name in a Returning clause.
+var outputColumnRegexp = regexp.MustCompile(`^\w+$`)
+
+/*
+Returning
+
+@ clause: Clause passed to Returning, e.g. "id, created_at" or "*"
+@ Return: OUTPUT list referring to the INSERTED row, and error if any.
+Items that are not plain column names are kept as written
+*/
+func (d mssqlDialect) Returning(clause string) (head, tail string, err error) {
+	items := strings.Split(clause, ",")
+	for i, item := range items {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "*":
+			items[i] = "INSERTED.*"
+		case outputColumnRegexp.MatchString(item):
+			safeCol, err := d.Quote(item)
+			if err != nil {
+				return "", "", err
+			}
+			items[i] = "INSERTED." + safeCol
+		default:
+			items[i] = item
+		}
+	}
+	return "OUTPUT " + strings.Join(items, ", ") + " ", "", nil
+}
+
+func (mssqlDialect) TableAlias(alias string) string { return " AS " + alias }
+
+func (mssqlDialect) Collation(name string) (string, error) { return plainCollation(name) }
+
+func (mssqlDialect) Features() DialectFeatures {
+	return DialectFeatures{GroupingSets: true, ValuesTable: true, DeleteJoin: true, MaxParams: 2100}
+}
+
+// oracleSimpleNameRegexp matches names Oracle would accept unquoted.
+var oracleSimpleNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*$`)
+
+// oracleDialect is the Oracle dialect.
+type oracleDialect struct{}
+
+func (oracleDialect) Quote(name string) (string, error) {
+	if len(name) > 128 {
+		return "", fmt.Errorf("identifier %q is longer than 128 bytes", name)
+	}
+	if strings.Contains(name, `"`) {
+		return "", fmt.Errorf("identifier %q contains a double quote", name)
+	}
+	// Unquoted names are stored in upper case, so plain names are folded to match them.
+	if oracleSimpleNameRegexp.MatchString(name) {
+		name = strings.ToUpper(name)
+	}
+	return `"` + name + `"`, nil
+}
+
+func (oracleDialect) Placeholder(n int) string { return ":" + strconv.Itoa(n) }
+
+func (oracleDialect) Limit(spec LimitSpec) (head, tail Fragment) {
+	return Fragment{}, offsetFetch(spec, false)
+}
+
+func (oracleDialect) Upsert(Upsert) (string, error) {
+	return "", fmt.Errorf("OnConflict() is not supported for db type: %v", Oracle)
+}
+
+func (oracleDialect) Returning(string) (head, tail string, err error) { return "", "", nil }
+
+// TableAlias omits AS, which Oracle does not accept before table aliases.
+func (oracleDialect) TableAlias(alias string) string { return " " + alias }
+
+func (oracleDialect) Collation(name string) (string, error) { return plainCollation(name) }
+
+func (oracleDialect) Features() DialectFeatures {
+	return DialectFeatures{GroupingSets: true, NullsOrdering: true, TupleIn: true, MaxParams: 65535}
+}
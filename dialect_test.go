@@ -0,0 +1,91 @@
+package gqbd_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+// testDialect is a minimal third-party dialect with "$n" placeholders and FIRST/SKIP paging.
+type testDialect struct{}
+
+func (testDialect) Quote(name string) (string, error) {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`, nil
+}
+
+func (testDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (testDialect) Limit(spec gqbd.LimitSpec) (head, tail gqbd.Fragment) {
+	if spec.Limit > 0 {
+		head.SQL += "FIRST ? "
+		head.Args = append(head.Args, spec.Limit)
+	}
+	if spec.Offset > 0 {
+		head.SQL += "SKIP ? "
+		head.Args = append(head.Args, spec.Offset)
+	}
+	return head, tail
+}
+
+func (testDialect) Upsert(gqbd.Upsert) (string, error) {
+	return "", fmt.Errorf("upsert is not supported")
+}
+
+func (testDialect) Returning(clause string) (head, tail string, err error) {
+	return "", " RETURNING " + clause, nil
+}
+
+func (testDialect) TableAlias(alias string) string { return " AS " + alias }
+
+func (testDialect) Collation(name string) (string, error) { return name, nil }
+
+func (testDialect) Features() gqbd.DialectFeatures { return gqbd.DialectFeatures{} }
+
+/*
+RegisterDialect
+
+@ Return: Builders of a registered dialect use its quoting, placeholder numbering, paging and feature set
+*/
+func TestRegisterDialect(t *testing.T) {
+	gqbd.RegisterDialect("testdb", testDialect{})
+	testDB := gqbd.DBType("testdb")
+
+	query, args, err := gqbd.BuildSelect(testDB, "users", "id", "name").
+		Where("status = ?", "active").
+		WhereRaw("age > $1", 18).
+		OrderBy("name", "ASC", nil).
+		Paginate(2, 10).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT FIRST $1 SKIP $2 "id", "name" FROM "users" WHERE status = $3 AND age > $4 ORDER BY "name" ASC`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{10, 10, "active", 18}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildInsert(testDB, "users").
+		Values(map[string]interface{}{"name": "Alice"}).
+		Returning("id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = `INSERT INTO "users" ("name") VALUES ($1) RETURNING id`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	if _, _, err := gqbd.BuildSelect(testDB, "sales", "region").GroupByCube("region").Build(); err == nil {
+		t.Error("expected error for GroupByCube without grouping sets support")
+	}
+	if _, err := gqbd.QuoteIdentifier("unregistered", "id"); err == nil {
+		t.Error("expected error for an unregistered db type")
+	}
+}
@@ -0,0 +1,393 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Executor is the subset of *sql.DB, *sql.Tx and *sql.Conn used to run built queries. Only the Context
+// variants are used, so cancellation and deadlines of the caller's context reach the database.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+/*
+Columns
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ Return: Column names and types of the result set without fetching any rows, and error if any
+*/
+func (qb *QueryBuilder) Columns(ctx context.Context, db Executor) ([]*sql.ColumnType, error) {
+	if qb.op != "SELECT" {
+		return nil, fmt.Errorf("Columns() can only be used with SELECT operation")
+	}
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	probe := "SELECT * FROM (" + query + ")" + tableAlias(qb.dbType, "gqbd_columns") + " WHERE 1 = 0"
+	var columns []*sql.ColumnType
+	err = qb.execute(ctx, db, execConfig{}, probe, args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+		rows, err := ex.QueryContext(ctx, query, args...)
+		if err != nil {
+			return -1, err
+		}
+		defer rows.Close()
+		columns, err = rows.ColumnTypes()
+		return -1, err
+	})
+	return columns, err
+}
+
+// ExecOption customizes how a builder is executed.
+type ExecOption func(*execConfig)
+
+type execConfig struct {
+	lockKey  string
+	watchdog *Watchdog
+	metrics  QueryMetrics
+	hooks    []Hook
+}
+
+/*
+SerializeBy
+
+@ key: Lock name; executions sharing a key run one at a time
+@ Return: ExecOption holding an advisory lock (PostgreSQL) or GET_LOCK (MariaDB/Mysql) around the statement
+*/
+func SerializeBy(key string) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.lockKey = key
+	}
+}
+
+/*
+Exec
+
+@ ctx: Context for the statement
+@ db: Database handle to run the statement against
+@ opts: Execution options
+@ Return: Result of the statement and error if any
+*/
+func (qb *QueryBuilder) Exec(ctx context.Context, db Executor, opts ...ExecOption) (sql.Result, error) {
+	cfg := execConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := qb.beforeBuild(cfg); err != nil {
+		return nil, err
+	}
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.lockKey != "" && qb.op == "SELECT" {
+		return nil, fmt.Errorf("SerializeBy() can only be used with write operations")
+	}
+	var result sql.Result
+	err = qb.execute(ctx, db, cfg, query, args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+		var err error
+		result, err = ex.ExecContext(ctx, query, args...)
+		if err != nil {
+			return -1, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			rows = -1
+		}
+		return rows, qb.checkVersion(result)
+	})
+	return result, err
+}
+
+/*
+DryRun
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ Return: Number of rows the UPDATE or DELETE would affect, counted with a SELECT COUNT(*) over the same WHERE, and error if any
+*/
+func (qb *QueryBuilder) DryRun(ctx context.Context, db Executor) (int64, error) {
+	if qb.op != "UPDATE" && qb.op != "DELETE" {
+		return 0, fmt.Errorf("DryRun() can only be used with UPDATE or DELETE operation")
+	}
+	if _, _, err := qb.Build(); err != nil {
+		return 0, err
+	}
+	scoped := qb.withScopes()
+	conditions := scoped.conditions
+	if qb.op == "DELETE" && qb.softDelete != "" {
+		conditions = scoped.softDeleteConditions()
+	}
+	count := QueryBuilder{op: "SELECT", dbType: qb.dbType, table: qb.table, joins: scoped.joins, conditions: conditions}
+	count.columns = []clause{{sql: "COUNT(*)"}}
+	w := newQueryWriter(qb.dbType)
+	count.writeSelect(w)
+	var n int64
+	// Hooks see the count as a SELECT of the builder's table.
+	probe := *qb
+	probe.op = "SELECT"
+	err := probe.execute(ctx, db, execConfig{}, w.String(), w.args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+		return -1, ex.QueryRowContext(ctx, query, w.args...).Scan(&n)
+	})
+	return n, err
+}
+
+/*
+ChunkByID
+
+@ ctx: Context for the queries
+@ db: Database handle to run the queries against
+@ column: Unique, ordered column to page by, usually the primary key; it must be in the result set
+@ size: Rows per batch
+@ fn: Function called with each non-empty batch, rows keyed by result column name; an error stops the iteration
+@ Return: Error from a query or from fn. Each batch is read with "WHERE column > last ORDER BY column LIMIT size",
+so rows are neither skipped nor repeated while fn modifies the table
+*/
+func (qb *QueryBuilder) ChunkByID(ctx context.Context, db Executor, column string, size int, fn func(rows []map[string]interface{}) error) error {
+	// The result column of "u.id" is "id".
+	key := column[strings.LastIndex(column, ".")+1:]
+	var batch []map[string]interface{}
+	return qb.chunk(ctx, db, "ChunkByID", column, size, func(rows *sql.Rows) (int, error) {
+		var err error
+		batch, err = scanMaps(rows)
+		return len(batch), err
+	}, func() (interface{}, error) {
+		last, ok := batch[len(batch)-1][key]
+		if !ok || last == nil {
+			return nil, fmt.Errorf("ChunkByID() column %q is missing from the result", column)
+		}
+		return last, fn(batch)
+	})
+}
+
+/*
+ChunkBy
+
+@ ctx: Context for the queries
+@ db: Database handle to run the queries against
+@ qb: SELECT builder without ORDER BY, LIMIT or OFFSET
+@ column: Unique, ordered column to page by, usually the primary key; T must have a field tagged with it
+@ size: Rows per batch
+@ fn: Function called with each non-empty batch scanned into T; an error stops the iteration
+@ Return: Error from a query, from scanning or from fn. Batches are read with keyset pagination as in ChunkByID
+*/
+func ChunkBy[T any](ctx context.Context, db Executor, qb *QueryBuilder, column string, size int, fn func(batch []T) error) error {
+	key := column[strings.LastIndex(column, ".")+1:]
+	var batch []T
+	return qb.chunk(ctx, db, "ChunkBy", column, size, func(rows *sql.Rows) (int, error) {
+		batch = nil
+		err := ScanAll(rows, &batch)
+		return len(batch), err
+	}, func() (interface{}, error) {
+		last := reflect.Indirect(reflect.ValueOf(batch[len(batch)-1]))
+		for _, f := range structFields(last.Type()) {
+			if f.column == key {
+				return last.FieldByIndex(f.index).Interface(), fn(batch)
+			}
+		}
+		return nil, fmt.Errorf("ChunkBy() column %q is not a field of %T", column, batch[0])
+	})
+}
+
+/*
+chunk
+
+@ ctx: Context for the queries
+@ db: Database handle to run the queries against
+@ method: Calling method, for error messages
+@ column: Column to page by
+@ size: Rows per batch
+@ scan: Function reading a batch from the rows, returning its length
+@ emit: Function handing a non-empty batch to the caller, returning the batch's last column value
+@ Return: Error from a query, scan or emit
+*/
+func (qb *QueryBuilder) chunk(ctx context.Context, db Executor, method, column string, size int, scan func(rows *sql.Rows) (int, error), emit func() (interface{}, error)) error {
+	if qb.op != "SELECT" {
+		return fmt.Errorf("%s() can only be used with SELECT operation", method)
+	}
+	if size < 1 {
+		return fmt.Errorf("%s() size must be positive, got %d", method, size)
+	}
+	if len(qb.orderBy) > 0 || qb.limit > 0 || qb.offset > 0 {
+		return fmt.Errorf("%s() sets its own ORDER BY and LIMIT", method)
+	}
+	if qb.maxLimit > 0 && size > qb.maxLimit {
+		return fmt.Errorf("%s() size %d exceeds the builder's max limit %d", method, size, qb.maxLimit)
+	}
+	var last interface{}
+	for {
+		batch := qb.Clone()
+		if last == nil {
+			batch = batch.OrderBy(column, "ASC", nil)
+		} else {
+			batch = batch.SeekAfter([]string{column}, []interface{}{last}, "ASC")
+		}
+		query, args, err := batch.Limit(size).Build()
+		if err != nil {
+			return err
+		}
+		var n int
+		err = batch.execute(ctx, db, execConfig{}, query, args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+			rows, err := ex.QueryContext(ctx, query, args...)
+			if err != nil {
+				return -1, err
+			}
+			n, err = scan(rows)
+			return -1, err
+		})
+		if err != nil || n == 0 {
+			return err
+		}
+		// The batch is handed over after the query, outside any transaction opened for hints.
+		if last, err = emit(); err != nil {
+			return err
+		}
+		if n < size {
+			return nil
+		}
+	}
+}
+
+/*
+scanMaps
+
+@ rows: Result rows; they are closed before scanMaps returns
+@ Return: Each row keyed by column name, and error if any
+*/
+func scanMaps(rows *sql.Rows) ([]map[string]interface{}, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			row[col] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// connPinner is implemented by *sql.DB; statements that need session state run on a single pinned connection.
+type connPinner interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+/*
+withExecutor
+
+@ ctx: Context for the statements
+@ db: Database handle
+@ dbType: Database type
+@ cfg: Execution options
+@ query: Built query
+@ fn: Function running the query, possibly rewritten to carry hints
+@ Return: Error from setting up the session or from fn
+*/
+func withExecutor(ctx context.Context, db Executor, dbType DBType, cfg execConfig, query string, fn func(Executor, string) error) (err error) {
+	if cfg.watchdog != nil {
+		defer cfg.watchdog.watch(ctx, query)()
+	}
+	hints := HintsFromContext(ctx)
+	if cfg.lockKey == "" && len(hints) == 0 {
+		return fn(db, query)
+	}
+	if cfg.lockKey != "" {
+		if pinner, ok := db.(connPinner); ok {
+			conn, err := pinner.Conn(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			db = conn
+		}
+		unlock, err := acquireLock(ctx, db, dbType, cfg.lockKey)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if unlockErr := unlock(); err == nil {
+				err = unlockErr
+			}
+		}()
+	}
+	if len(hints) == 0 {
+		return fn(db, query)
+	}
+	switch dbType {
+	case PostgreSQL, CockroachDB:
+		return withLocalSettings(ctx, db, hints, func(ex Executor) error {
+			return fn(ex, query)
+		})
+	case MariaDB:
+		prefix, err := setStatementPrefix(hints)
+		if err != nil {
+			return err
+		}
+		return fn(db, prefix+query)
+	case Mysql:
+		hinted, err := setVarHint(hints, query)
+		if err != nil {
+			return err
+		}
+		return fn(db, hinted)
+	default:
+		return fmt.Errorf("hints are not supported for db type: %v", dbType)
+	}
+}
+
+/*
+acquireLock
+
+@ ctx: Context for acquiring the lock
+@ db: Executor bound to a single connection
+@ dbType: Database type
+@ key: Lock name
+@ Return: Function releasing the lock, and error if the lock could not be acquired
+*/
+func acquireLock(ctx context.Context, db Executor, dbType DBType, key string) (func() error, error) {
+	// Release even if ctx was cancelled, otherwise the lock stays held by the pooled connection.
+	releaseCtx := context.WithoutCancel(ctx)
+	switch dbType {
+	case PostgreSQL:
+		if _, err := db.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", key); err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := db.ExecContext(releaseCtx, "SELECT pg_advisory_unlock(hashtext($1))", key)
+			return err
+		}, nil
+	case MariaDB, Mysql:
+		var acquired sql.NullInt64
+		if err := db.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", key).Scan(&acquired); err != nil {
+			return nil, err
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return nil, fmt.Errorf("could not acquire lock: %s", key)
+		}
+		return func() error {
+			_, err := db.ExecContext(releaseCtx, "SELECT RELEASE_LOCK(?)", key)
+			return err
+		}, nil
+	default:
+		return nil, fmt.Errorf("SerializeBy() is not supported for db type: %v", dbType)
+	}
+}
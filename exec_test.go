@@ -0,0 +1,1327 @@
+package gqbd_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+Columns
+
+@ Return: Result set column names fetched with a LIMIT 0 wrapper query
+*/
+func TestColumns(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{columns: []string{"id", "email"}}, nil
+	})
+	defer db.Close()
+
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email").Where("active = ?", true)
+	columns, err := qb.Columns(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(columns) != 2 || columns[0].Name() != "id" || columns[1].Name() != "email" {
+		t.Errorf("expected columns id, email, got %v", columns)
+	}
+	expectedQuery := "SELECT * FROM (SELECT \"id\", \"email\" FROM \"users\" WHERE active = $1) AS gqbd_columns WHERE 1 = 0"
+	if got := conn.Queries()[0]; got != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, got)
+	}
+
+	if _, err := gqbd.BuildDelete(gqbd.PostgreSQL, "users").Columns(context.Background(), db); err == nil {
+		t.Errorf("expected error for non-SELECT builder")
+	}
+}
+
+/*
+Exec with SerializeBy
+
+@ Return: Statement wrapped by lock acquisition and release on the same connection
+*/
+func TestExecSerializeBy(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		if strings.HasPrefix(query, "SELECT GET_LOCK") {
+			return fakeResult{columns: []string{"GET_LOCK"}, rows: [][]driver.Value{{int64(1)}}}, nil
+		}
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+
+	qb := gqbd.BuildUpdate(gqbd.MariaDB, "stock").
+		Set(map[string]interface{}{"qty": 3}).
+		Where("sku = ?", "A-1")
+	result, err := qb.Exec(context.Background(), db, gqbd.SerializeBy("stock:A-1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n != 1 {
+		t.Errorf("expected 1 affected row, got %d", n)
+	}
+	expectedQueries := []string{
+		"SELECT GET_LOCK(?, -1)",
+		"UPDATE `stock` SET `qty` = ? WHERE sku = ?",
+		"SELECT RELEASE_LOCK(?)",
+	}
+	if !reflect.DeepEqual(conn.Queries(), expectedQueries) {
+		t.Errorf("expected queries %v, got %v", expectedQueries, conn.Queries())
+	}
+
+	if _, err := gqbd.BuildSelect(gqbd.MariaDB, "stock").Exec(context.Background(), db, gqbd.SerializeBy("x")); err == nil {
+		t.Errorf("expected error for SerializeBy on SELECT")
+	}
+}
+
+/*
+Exec with context hints
+
+@ Return: Hints applied with SET LOCAL in a transaction (PostgreSQL), SET STATEMENT (MariaDB) or SET_VAR (Mysql)
+*/
+func TestExecHints(t *testing.T) {
+	db, conn := newFakeDB(nil)
+	defer db.Close()
+
+	ctx := gqbd.WithHint(context.Background(), "max_parallel_workers_per_gather=4")
+	ctx = gqbd.WithHint(ctx, "work_mem=64MB")
+	_, err := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").Where("expired = ?", true).Exec(ctx, db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQueries := []string{
+		"BEGIN",
+		"SET LOCAL max_parallel_workers_per_gather = '4'",
+		"SET LOCAL work_mem = '64MB'",
+		"DELETE FROM \"sessions\" WHERE expired = $1",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(conn.Queries(), expectedQueries) {
+		t.Errorf("expected queries %v, got %v", expectedQueries, conn.Queries())
+	}
+
+	mdb, mconn := newFakeDB(nil)
+	defer mdb.Close()
+	ctx = gqbd.WithHint(context.Background(), "max_statement_time=5", "optimizer_switch=index_merge_off")
+	if _, err := gqbd.BuildDelete(gqbd.MariaDB, "sessions").Exec(ctx, mdb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SET STATEMENT max_statement_time=5, optimizer_switch='index_merge_off' FOR DELETE FROM `sessions`"
+	if got := mconn.Queries()[0]; got != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, got)
+	}
+
+	ctx = gqbd.WithHint(context.Background(), "max_execution_time=1000", "optimizer_switch=index_merge=off")
+	if _, err := gqbd.BuildDelete(gqbd.Mysql, "sessions").Exec(ctx, mdb); err == nil {
+		t.Errorf("expected error for malformed hint")
+	}
+	ctx = gqbd.WithHint(context.Background(), "max_execution_time=1000", "sort_buffer_size=16M")
+	rows, err := gqbd.BuildSelect(gqbd.Mysql, "sessions", "id").Where("expired = ?", true).RunWith(mdb).Query(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows.Close()
+	expectedQuery = "SELECT /*+ SET_VAR(max_execution_time=1000) SET_VAR(sort_buffer_size='16M') */ `id` FROM `sessions` WHERE expired = ?"
+	if queries := mconn.Queries(); queries[len(queries)-1] != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, queries[len(queries)-1])
+	}
+
+	ctx = gqbd.WithHint(context.Background(), "work_mem='1GB'; DROP TABLE x")
+	if _, err := gqbd.BuildDelete(gqbd.MariaDB, "sessions").Exec(ctx, mdb); err == nil {
+		t.Errorf("expected error for malformed hint")
+	}
+}
+
+/*
+Exec with WithWatchdog
+
+@ Return: Leak reports for a statement that ignores context cancellation
+*/
+func TestExecWatchdog(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		time.Sleep(100 * time.Millisecond) // a driver that does not honor cancellation
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+
+	reports := make(chan gqbd.LeakReport, 2)
+	watchdog := gqbd.NewWatchdog(20*time.Millisecond, func(r gqbd.LeakReport) { reports <- r })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	qb := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").Where("expires_at < ?", "2024-01-01")
+	if _, err := qb.Exec(ctx, db, gqbd.WithWatchdog(watchdog)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, finished := range []bool{false, true} {
+		select {
+		case r := <-reports:
+			if r.Finished != finished {
+				t.Errorf("expected Finished=%v, got %+v", finished, r)
+			}
+			if r.Query != "DELETE FROM \"sessions\" WHERE expires_at < $1" {
+				t.Errorf("unexpected query in report: %s", r.Query)
+			}
+			if !strings.Contains(r.Caller, "exec_test.go") {
+				t.Errorf("expected caller in exec_test.go, got %s", r.Caller)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for leak report")
+		}
+	}
+	if stats := watchdog.Stats(); stats != (gqbd.WatchdogStats{Cancelled: 1, Leaked: 1, Running: 0}) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+/*
+DryRun
+
+@ Return: Row count of a DELETE computed with SELECT COUNT(*) over the same WHERE
+*/
+func TestDryRun(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(42)}}}, nil
+	})
+	defer db.Close()
+
+	qb := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").
+		Where("expires_at < ?", "2024-01-01").
+		WhereEq("revoked", true)
+	n, err := qb.DryRun(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 42 {
+		t.Errorf("expected 42 rows, got %d", n)
+	}
+	expectedQueries := []string{"SELECT COUNT(*) FROM \"sessions\" WHERE expires_at < $1 AND \"revoked\" = $2"}
+	if !reflect.DeepEqual(conn.Queries(), expectedQueries) {
+		t.Errorf("expected queries %v, got %v", expectedQueries, conn.Queries())
+	}
+
+	soft := gqbd.BuildDelete(gqbd.PostgreSQL, "users").Where("org_id = ?", 9).SoftDelete("deleted_at")
+	if _, err := soft.DryRun(context.Background(), db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT COUNT(*) FROM \"users\" WHERE org_id = $1 AND \"deleted_at\" IS NULL"
+	if queries := conn.Queries(); queries[len(queries)-1] != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, queries[len(queries)-1])
+	}
+
+	if _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "sessions").DryRun(context.Background(), db); err == nil {
+		t.Error("expected error for DryRun on SELECT")
+	}
+}
+
+/*
+WithSession
+
+@ Return: Every statement of the session on one connection, while other callers get another connection
+*/
+func TestWithSession(t *testing.T) {
+	db, conn := newFakeDB(nil)
+	defer db.Close()
+	ctx := context.Background()
+
+	err := gqbd.WithSession(ctx, db, func(s *gqbd.Session) error {
+		create := gqbd.BuildInsert(gqbd.PostgreSQL, "tmp_ids").Values(map[string]interface{}{"id": 1})
+		if err := s.Run(ctx, create); err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+			return err
+		}
+		_, err := gqbd.BuildDelete(gqbd.PostgreSQL, "tmp_ids").Exec(ctx, s)
+		s.Discard()
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := conn.ConnIDs()
+	if len(ids) != 3 || ids[0] != ids[2] || ids[0] == ids[1] {
+		t.Errorf("expected session statements on one connection and others on another, got %v", ids)
+	}
+	if _, err := db.ExecContext(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := conn.ConnIDs()[3]; last == ids[0] {
+		t.Errorf("expected discarded connection %d not to be reused", ids[0])
+	}
+}
+
+/*
+ScanAll with DedupeBy
+
+@ Return: One parent per key with the joined child rows appended; an unmatched LEFT JOIN row adds no child
+*/
+func TestScanAllDedupeBy(t *testing.T) {
+	type order struct {
+		ID    int64  `db:"order_id"`
+		Total string `db:"total"`
+	}
+	type user struct {
+		ID     int64   `db:"id"`
+		Name   string  `db:"name"`
+		Orders []order `db:"orders"`
+	}
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{
+			columns: []string{"id", "name", "order_id", "total"},
+			rows: [][]driver.Value{
+				{int64(1), "Alice", int64(10), "9.99"},
+				{int64(2), "Bob", nil, nil},
+				{int64(1), "Alice", int64(11), "5.00"},
+			},
+		}, nil
+	})
+	defer db.Close()
+
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "users.id", "users.name", "orders.total").
+		SelectRaw(gqbd.Raw("orders.id AS order_id")).
+		LeftJoin("orders", "orders.user_id = users.id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err := db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var users []user
+	if err := gqbd.ScanAll(rows, &users, gqbd.DedupeBy("id", "orders")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []user{
+		{ID: 1, Name: "Alice", Orders: []order{{ID: 10, Total: "9.99"}, {ID: 11, Total: "5.00"}}},
+		{ID: 2, Name: "Bob"},
+	}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("expected %+v, got %+v", expected, users)
+	}
+
+	rows, err = db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var flat []*user
+	if err := gqbd.ScanAll(rows, &flat); err == nil {
+		t.Error("expected error for child columns without DedupeBy")
+	}
+}
+
+/*
+ChunkByID
+
+@ Return: Batches read with a keyset condition on the last seen id until a short batch
+*/
+func TestChunkByID(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		after := int64(0)
+		if len(args) == 3 {
+			after = args[1].(int64)
+		}
+		result := fakeResult{columns: []string{"id", "email"}}
+		for id := after + 1; id <= 5 && id <= after+2; id++ {
+			result.rows = append(result.rows, []driver.Value{id, "user@example.com"})
+		}
+		return result, nil
+	})
+	defer db.Close()
+
+	var batches [][]int64
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email").Where("active = ?", true)
+	err := qb.ChunkByID(context.Background(), db, "id", 2, func(rows []map[string]interface{}) error {
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row["id"].(int64))
+		}
+		batches = append(batches, ids)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(batches, [][]int64{{1, 2}, {3, 4}, {5}}) {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+	expectedQueries := []string{
+		"SELECT \"id\", \"email\" FROM \"users\" WHERE active = $1 ORDER BY \"id\" ASC LIMIT $2",
+		"SELECT \"id\", \"email\" FROM \"users\" WHERE active = $1 AND \"id\" > $2 ORDER BY \"id\" ASC LIMIT $3",
+		"SELECT \"id\", \"email\" FROM \"users\" WHERE active = $1 AND \"id\" > $2 ORDER BY \"id\" ASC LIMIT $3",
+	}
+	if !reflect.DeepEqual(conn.Queries(), expectedQueries) {
+		t.Errorf("expected queries %v, got %v", expectedQueries, conn.Queries())
+	}
+
+	capped := gqbd.BuildSelectWith(gqbd.PostgreSQL, "users", []string{"id"}, gqbd.WithMaxLimit(1))
+	if err := capped.ChunkByID(context.Background(), db, "id", 2, nil); err == nil || !strings.Contains(err.Error(), "exceeds the builder's max limit 1") {
+		t.Errorf("expected max limit error, got %v", err)
+	}
+
+	if err := qb.Limit(10).ChunkByID(context.Background(), db, "id", 2, nil); err == nil {
+		t.Error("expected error for ChunkByID with LIMIT")
+	}
+}
+
+/*
+ExecIdempotent
+
+@ Return: Insert skipped on a duplicate key, with the stored row read back for both attempts
+*/
+func TestExecIdempotent(t *testing.T) {
+	inserted := false
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		if strings.HasPrefix(query, "INSERT") {
+			if inserted {
+				return fakeResult{affected: 0}, nil
+			}
+			inserted = true
+			return fakeResult{affected: 1}, nil
+		}
+		return fakeResult{columns: []string{"id", "status"}, rows: [][]driver.Value{{int64(7), "pending"}}}, nil
+	})
+	defer db.Close()
+
+	qb := gqbd.BuildInsert(gqbd.PostgreSQL, "payments").
+		Values(map[string]interface{}{"amount": 100}).
+		IdempotencyKey("request_id", "req-1").
+		Returning("id, status")
+	for i, expected := range []bool{true, false} {
+		var id int64
+		var status string
+		created, err := qb.ExecIdempotent(context.Background(), db, &id, &status)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if created != expected || id != 7 || status != "pending" {
+			t.Errorf("attempt %d: expected created=%v id=7 status=pending, got %v %d %s", i+1, expected, created, id, status)
+		}
+	}
+	expectedQueries := []string{
+		"INSERT INTO \"payments\" (\"amount\", \"request_id\") VALUES ($1, $2) ON CONFLICT (\"request_id\") DO NOTHING",
+		"SELECT id, status FROM \"payments\" WHERE \"request_id\" = $1",
+	}
+	if !reflect.DeepEqual(conn.Queries()[:2], expectedQueries) {
+		t.Errorf("expected queries %v, got %v", expectedQueries, conn.Queries())
+	}
+
+	var id int64
+	var status string
+	if _, err := gqbd.BuildInsertWith(gqbd.PostgreSQL, "payments", gqbd.WithSchema("billing")).
+		Values(map[string]interface{}{"amount": 100}).
+		IdempotencyKey("request_id", "req-2").
+		Returning("id, status").
+		ExecIdempotent(context.Background(), db, &id, &status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queries := conn.Queries()
+	if expectedQuery := "SELECT id, status FROM \"billing\".\"payments\" WHERE \"request_id\" = $1"; queries[len(queries)-1] != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, queries[len(queries)-1])
+	}
+
+	if _, err := gqbd.BuildInsert(gqbd.PostgreSQL, "payments").
+		Values(map[string]interface{}{"amount": 100}).
+		ExecIdempotent(context.Background(), db); err == nil {
+		t.Error("expected error without IdempotencyKey")
+	}
+}
+
+/*
+Exec with WithMetrics
+
+@ Return: Executions counted per statement kind and table, with failures counted as errors
+*/
+func TestExecMetrics(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		if strings.HasPrefix(query, "DELETE") {
+			return fakeResult{}, errors.New("permission denied")
+		}
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+
+	counters := gqbd.NewTableCounters()
+	ctx := context.Background()
+	update := gqbd.BuildUpdate(gqbd.PostgreSQL, "orders").Set(map[string]interface{}{"status": "paid"}).Where("id = ?", 1)
+	for i := 0; i < 2; i++ {
+		if _, err := update.Exec(ctx, db, gqbd.WithMetrics(counters)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").Exec(ctx, db, gqbd.WithMetrics(counters)); err == nil {
+		t.Fatal("expected error from DELETE")
+	}
+
+	snapshot := counters.Snapshot()
+	if got := snapshot[gqbd.TableKey{Kind: "UPDATE", Table: "orders"}]; got.Queries != 2 || got.Errors != 0 {
+		t.Errorf("unexpected UPDATE orders count: %+v", got)
+	}
+	if got := snapshot[gqbd.TableKey{Kind: "DELETE", Table: "sessions"}]; got.Queries != 1 || got.Errors != 1 {
+		t.Errorf("unexpected DELETE sessions count: %+v", got)
+	}
+	if len(snapshot) != 2 {
+		t.Errorf("expected 2 series, got %v", snapshot)
+	}
+}
+
+/*
+ExecBatches
+
+@ Return: One statement per chunk, with the affected rows summed
+*/
+func TestExecBatches(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{affected: int64(len(args) / 3)}, nil
+	})
+	defer db.Close()
+
+	rows := make([]map[string]interface{}, 1000)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"a": i, "b": i, "c": i}
+	}
+	n, err := gqbd.BuildInsert(gqbd.MSSQL, "items").InsertBatch(rows).ExecBatches(context.Background(), db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1000 {
+		t.Errorf("expected 1000 affected rows, got %d", n)
+	}
+	if got := len(conn.Queries()); got != 2 {
+		t.Errorf("expected 2 statements within the 2100 parameter limit, got %d", got)
+	}
+}
+
+/*
+Exec with WithVersion
+
+@ Return: ErrVersionConflict when the versioned UPDATE affects no row
+*/
+func TestExecVersionConflict(t *testing.T) {
+	var affected int64
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{affected: affected}, nil
+	})
+	defer db.Close()
+
+	update := func() error {
+		_, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+			Set(map[string]interface{}{"name": "Alice"}).
+			Where("id = ?", 7).
+			WithVersion("version", 3).
+			Exec(context.Background(), db)
+		return err
+	}
+	affected = 1
+	if err := update(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	affected = 0
+	if err := update(); !errors.Is(err, gqbd.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict, got %v", err)
+	}
+}
+
+/*
+RunWith
+
+@ Return: Query, QueryRow and Exec running the built query and args against the handle
+*/
+func TestRunWith(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		if strings.HasPrefix(query, "UPDATE") {
+			return fakeResult{affected: 2}, nil
+		}
+		return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}, {int64(2)}}}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+
+	rows, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("active = ?", true).RunWith(db).Query(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE active = $1"
+	if got := conn.Queries()[0]; got != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, got)
+	}
+
+	var id int64
+	if err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").RunWith(db).QueryRow(ctx).Scan(&id); err != nil || id != 1 {
+		t.Errorf("expected id 1, got %d, %v", id, err)
+	}
+
+	result, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		Set(map[string]interface{}{"active": false}).
+		Where("id IN (?, ?)", 1, 2).
+		RunWith(db).
+		Exec(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n != 2 {
+		t.Errorf("expected 2 affected rows, got %d", n)
+	}
+
+	if err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("id = ?").RunWith(db).QueryRow(ctx).Scan(&id); err == nil {
+		t.Errorf("expected build error from Scan")
+	}
+}
+
+/*
+Context cancellation
+
+@ Return: Every execution entry point stops with the context's error instead of running the statement
+*/
+func TestExecContextCanceled(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}, affected: 1}, nil
+	})
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	selectUsers := func() *gqbd.QueryBuilder {
+		return gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id")
+	}
+	insertUser := gqbd.BuildInsert(gqbd.PostgreSQL, "users").Values(map[string]interface{}{"email": "a@example.com"})
+	runs := map[string]func() error{
+		"Exec": func() error {
+			_, err := insertUser.Exec(ctx, db)
+			return err
+		},
+		"ExecBatches": func() error {
+			_, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+				InsertBatch([]map[string]interface{}{{"email": "a@example.com"}}).
+				ExecBatches(ctx, db)
+			return err
+		},
+		"Columns": func() error {
+			_, err := selectUsers().Columns(ctx, db)
+			return err
+		},
+		"ChunkByID": func() error {
+			return selectUsers().ChunkByID(ctx, db, "id", 10, func([]map[string]interface{}) error { return nil })
+		},
+		"RunWith.Query": func() error {
+			_, err := selectUsers().RunWith(db).Query(ctx)
+			return err
+		},
+		"RunWith.QueryRow": func() error {
+			var id int64
+			return selectUsers().RunWith(db).QueryRow(ctx).Scan(&id)
+		},
+	}
+	for name, run := range runs {
+		if err := run(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+	if queries := conn.Queries(); len(queries) != 0 {
+		t.Errorf("expected no statements to reach the driver, got %v", queries)
+	}
+}
+
+/*
+All and One
+
+@ Return: Rows scanned into tagged structs; One limits the query to one row and reports sql.ErrNoRows
+*/
+func TestAllOne(t *testing.T) {
+	type user struct {
+		ID    int64  `db:"id"`
+		Email string `db:"email"`
+	}
+	empty := false
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		result := fakeResult{columns: []string{"id", "email"}}
+		if !empty {
+			result.rows = [][]driver.Value{{int64(1), "a@example.com"}, {int64(2), "b@example.com"}}
+		}
+		return result, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", gqbd.ColumnsOf[user]()...)
+
+	users, err := gqbd.All[*user](ctx, db, qb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 2 || *users[1] != (user{ID: 2, Email: "b@example.com"}) {
+		t.Errorf("unexpected users: %+v", users)
+	}
+
+	first, err := gqbd.One[user](ctx, db, qb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != (user{ID: 1, Email: "a@example.com"}) {
+		t.Errorf("unexpected user: %+v", first)
+	}
+	expectedQuery := "SELECT \"id\", \"email\" FROM \"users\" LIMIT $1"
+	if got := conn.Queries()[1]; got != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, got)
+	}
+
+	empty = true
+	if _, err := gqbd.One[user](ctx, db, qb); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+/*
+Pluck, CountExec, SumExec and ExistsExec
+
+@ Return: Single column and scalar results read through rewritten queries
+*/
+func TestScalarHelpers(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		switch {
+		case strings.Contains(query, "COUNT(*)"):
+			return fakeResult{columns: []string{"count"}, rows: [][]driver.Value{{int64(3)}}}, nil
+		case strings.Contains(query, "SUM("):
+			return fakeResult{columns: []string{"sum"}, rows: [][]driver.Value{{"12.5"}}}, nil
+		case strings.Contains(query, "EXISTS"):
+			return fakeResult{columns: []string{"exists"}, rows: [][]driver.Value{{int64(1)}}}, nil
+		}
+		return fakeResult{columns: []string{"email"}, rows: [][]driver.Value{{"a@example.com"}, {"b@example.com"}}}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id", "total").Where("status = ?", "paid").OrderBy("id", "DESC", nil)
+
+	emails, err := gqbd.Pluck[string](ctx, db, qb, "email")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(emails, []string{"a@example.com", "b@example.com"}) {
+		t.Errorf("unexpected emails: %v", emails)
+	}
+	if n, err := gqbd.CountExec(ctx, db, qb); err != nil || n != 3 {
+		t.Errorf("expected count 3, got %d, %v", n, err)
+	}
+	if sum, err := gqbd.SumExec(ctx, db, qb, "total"); err != nil || sum != 12.5 {
+		t.Errorf("expected sum 12.5, got %v, %v", sum, err)
+	}
+	if exists, err := gqbd.ExistsExec(ctx, db, qb); err != nil || !exists {
+		t.Errorf("expected exists, got %v, %v", exists, err)
+	}
+
+	expected := []string{
+		"SELECT \"email\" FROM \"orders\" WHERE status = $1 ORDER BY \"id\" DESC",
+		"SELECT COUNT(*) FROM \"orders\" WHERE status = $1",
+		"SELECT SUM(\"total\") FROM \"orders\" WHERE status = $1",
+		"SELECT CASE WHEN EXISTS (SELECT \"id\", \"total\" FROM \"orders\" WHERE status = $1) THEN 1 ELSE 0 END",
+	}
+	if queries := conn.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("expected queries:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(queries, "\n"))
+	}
+
+	if _, err := gqbd.SumExec(ctx, db, qb.Clone().GroupBy("status"), "total"); err == nil {
+		t.Errorf("expected error for grouped query")
+	}
+}
+
+/*
+Iter
+
+@ Return: Rows scanned lazily into structs, stopping when the loop breaks
+*/
+func TestIter(t *testing.T) {
+	type user struct {
+		ID    int64  `db:"id"`
+		Email string `db:"email"`
+	}
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{
+			columns: []string{"id", "email"},
+			rows:    [][]driver.Value{{int64(1), "a@example.com"}, {int64(2), "b@example.com"}, {int64(3), "c@example.com"}},
+		}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email")
+
+	var ids []int64
+	for u, err := range gqbd.Iter[*user](ctx, db, qb) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if u.ID == 3 {
+			break
+		}
+		ids = append(ids, u.ID)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+
+	type partial struct {
+		ID int64 `db:"id"`
+	}
+	var errs int
+	for _, err := range gqbd.Iter[partial](ctx, db, qb) {
+		if err == nil {
+			t.Fatalf("expected error for column without destination field")
+		}
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("expected one error, got %d", errs)
+	}
+}
+
+/*
+ChunkBy
+
+@ Return: Batches scanned into structs, paged by the key field of the last row
+*/
+func TestChunkBy(t *testing.T) {
+	type user struct {
+		ID    int64  `db:"id"`
+		Email string `db:"email"`
+	}
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		after := int64(0)
+		if len(args) == 2 {
+			after = args[0].(int64)
+		}
+		result := fakeResult{columns: []string{"id", "email"}}
+		for id := after + 1; id <= 3 && id <= after+2; id++ {
+			result.rows = append(result.rows, []driver.Value{id, "user@example.com"})
+		}
+		return result, nil
+	})
+	defer db.Close()
+
+	var batches [][]int64
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email")
+	err := gqbd.ChunkBy(context.Background(), db, qb, "id", 2, func(batch []user) error {
+		var ids []int64
+		for _, u := range batch {
+			ids = append(ids, u.ID)
+		}
+		batches = append(batches, ids)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(batches, [][]int64{{1, 2}, {3}}) {
+		t.Errorf("unexpected batches: %v", batches)
+	}
+	expectedQuery := "SELECT \"id\", \"email\" FROM \"users\" WHERE \"id\" > $1 ORDER BY \"id\" ASC LIMIT $2"
+	if queries := conn.Queries(); len(queries) != 2 || queries[1] != expectedQuery {
+		t.Errorf("unexpected queries: %v", queries)
+	}
+
+	err = gqbd.ChunkBy(context.Background(), db, qb, "email_id", 2, func(batch []user) error { return nil })
+	if err == nil {
+		t.Error("expected error for a key column without a field")
+	}
+}
+
+/*
+WithTx savepoints
+
+@ Return: SAVEPOINT, ROLLBACK TO and RELEASE in each dialect's syntax inside one committed transaction, and a rollback when fn panics
+*/
+func TestWithTxSavePoint(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+
+	err := gqbd.WithTx(ctx, db, gqbd.PostgreSQL, nil, func(tx *gqbd.Tx) error {
+		if err := tx.SavePoint(ctx, "before_items"); err != nil {
+			return err
+		}
+		insert := gqbd.BuildInsert(gqbd.PostgreSQL, "items").Values(map[string]interface{}{"sku": "a-1"})
+		if _, err := tx.Run(insert).Exec(ctx); err != nil {
+			return err
+		}
+		if err := tx.RollbackTo(ctx, "before_items"); err != nil {
+			return err
+		}
+		return tx.ReleaseSavePoint(ctx, "before_items")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"BEGIN",
+		"SAVEPOINT \"before_items\"",
+		"INSERT INTO \"items\" (\"sku\") VALUES ($1)",
+		"ROLLBACK TO SAVEPOINT \"before_items\"",
+		"RELEASE SAVEPOINT \"before_items\"",
+		"COMMIT",
+	}
+	if queries := conn.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("expected queries:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(queries, "\n"))
+	}
+
+	mdb, mconn := newFakeDB(nil)
+	defer mdb.Close()
+	failed := errors.New("validation failed")
+	err = gqbd.WithTx(ctx, mdb, gqbd.MSSQL, nil, func(tx *gqbd.Tx) error {
+		if err := tx.SavePoint(ctx, "sp1"); err != nil {
+			return err
+		}
+		if err := tx.RollbackTo(ctx, "sp1"); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("expected error from fn, got %v", err)
+	}
+	expected = []string{"BEGIN", "SAVE TRANSACTION [sp1]", "ROLLBACK TRANSACTION [sp1]", "ROLLBACK"}
+	if queries := mconn.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("expected queries:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(queries, "\n"))
+	}
+
+	pdb, pconn := newFakeDB(nil)
+	defer pdb.Close()
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("expected the panic to propagate, got %v", p)
+			}
+		}()
+		_ = gqbd.WithTx(ctx, pdb, gqbd.PostgreSQL, nil, func(tx *gqbd.Tx) error {
+			panic("boom")
+		})
+	}()
+	expected = []string{"BEGIN", "ROLLBACK"}
+	if queries := pconn.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("expected queries:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(queries, "\n"))
+	}
+}
+
+/*
+RunBatch
+
+@ Return: Failing statements undone through a savepoint and reported under SkipFailed, the first failure
+returned under AbortAll, and a savepoint of its own for each call
+*/
+func TestRunBatch(t *testing.T) {
+	duplicate := errors.New("duplicate key")
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		if len(args) > 0 && args[0] == "dup" {
+			return fakeResult{}, duplicate
+		}
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+
+	insert := func(sku string) *gqbd.QueryBuilder {
+		return gqbd.BuildInsert(gqbd.PostgreSQL, "items").Values(map[string]interface{}{"sku": sku})
+	}
+	var report *gqbd.BatchReport
+	err := gqbd.WithTx(ctx, db, gqbd.PostgreSQL, nil, func(tx *gqbd.Tx) error {
+		var err error
+		report, err = tx.RunBatch(ctx, gqbd.SkipFailed,
+			insert("a-1"),
+			insert("dup"),
+			gqbd.Batch{Query: "INSERT INTO \"items\" (\"sku\") VALUES ($1)", Args: []interface{}{"a-2"}},
+			gqbd.BuildInsert(gqbd.PostgreSQL, "items"))
+		if err != nil {
+			return err
+		}
+		_, err = tx.RunBatch(ctx, gqbd.AbortAll, insert("a-3"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Succeeded != 2 || report.RowsAffected != 2 || len(report.Failed) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Failed[0].Index != 1 || !errors.Is(report.Failed[0].Err, duplicate) || report.Failed[1].Index != 3 {
+		t.Errorf("unexpected failures: %+v", report.Failed)
+	}
+	if !errors.Is(report.Err(), duplicate) {
+		t.Errorf("expected report error to wrap the duplicate key error, got %v", report.Err())
+	}
+	expected := []string{
+		"BEGIN",
+		"SAVEPOINT \"gqbd_batch_1\"",
+		"INSERT INTO \"items\" (\"sku\") VALUES ($1)",
+		"RELEASE SAVEPOINT \"gqbd_batch_1\"",
+		"SAVEPOINT \"gqbd_batch_1\"",
+		"INSERT INTO \"items\" (\"sku\") VALUES ($1)",
+		"ROLLBACK TO SAVEPOINT \"gqbd_batch_1\"",
+		"SAVEPOINT \"gqbd_batch_1\"",
+		"INSERT INTO \"items\" (\"sku\") VALUES ($1)",
+		"RELEASE SAVEPOINT \"gqbd_batch_1\"",
+		"SAVEPOINT \"gqbd_batch_2\"",
+		"INSERT INTO \"items\" (\"sku\") VALUES ($1)",
+		"RELEASE SAVEPOINT \"gqbd_batch_2\"",
+		"COMMIT",
+	}
+	if queries := conn.Queries(); !reflect.DeepEqual(queries, expected) {
+		t.Errorf("expected queries:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(queries, "\n"))
+	}
+
+	err = gqbd.WithTx(ctx, db, gqbd.PostgreSQL, nil, func(tx *gqbd.Tx) error {
+		report, err := tx.RunBatch(ctx, gqbd.AbortAll, insert("dup"), insert("a-4"))
+		if report.Succeeded != 0 || len(report.Failed) != 1 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+		return err
+	})
+	if !errors.Is(err, duplicate) {
+		t.Errorf("expected duplicate key error, got %v", err)
+	}
+}
+
+/*
+Hooks
+
+@ Return: Builder and execution hooks called around Build and the statement, in order, with the built query
+*/
+func TestHooks(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+
+	var calls []string
+	hook := func(name string) gqbd.Hook {
+		return gqbd.HookFuncs{
+			OnBuild: func(qb *gqbd.QueryBuilder) error {
+				calls = append(calls, name+" build")
+				return nil
+			},
+			OnBeforeExec: func(ctx context.Context, e gqbd.HookEvent) context.Context {
+				calls = append(calls, name+" before "+e.Kind+" "+e.Table)
+				return ctx
+			},
+			OnAfterExec: func(ctx context.Context, e gqbd.HookEvent) {
+				calls = append(calls, fmt.Sprintf("%s after %s %v %v", name, e.Query, e.Args, e.Err))
+			},
+		}
+	}
+	_, err := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").
+		Where("expires_at < ?", "2024-01-01").
+		AddHook(hook("builder")).
+		Exec(ctx, db, gqbd.WithHook(hook("exec")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"exec build",
+		"builder build",
+		"builder before DELETE sessions",
+		"exec before DELETE sessions",
+		"exec after DELETE FROM \"sessions\" WHERE expires_at < $1 [2024-01-01] <nil>",
+		"builder after DELETE FROM \"sessions\" WHERE expires_at < $1 [2024-01-01] <nil>",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(calls, "\n"))
+	}
+
+	denied := errors.New("DELETE without WHERE")
+	guard := gqbd.HookFuncs{OnBuild: func(qb *gqbd.QueryBuilder) error { return denied }}
+	if _, err := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").RunWith(db, gqbd.WithHook(guard)).Exec(ctx); !errors.Is(err, denied) {
+		t.Errorf("expected error from BeforeBuild, got %v", err)
+	}
+}
+
+/*
+Hooks on helper queries
+
+@ Return: Hooks notified of the queries run by CountExec, SumExec, ExistsExec, ChunkByID and DryRun
+*/
+func TestHooksHelpers(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		if strings.Contains(query, "ORDER BY") {
+			return fakeResult{columns: []string{"id"}}, nil
+		}
+		return fakeResult{columns: []string{"n"}, rows: [][]driver.Value{{int64(1)}}}, nil
+	})
+	defer db.Close()
+	ctx := context.Background()
+
+	var kinds []string
+	hook := gqbd.HookFuncs{OnAfterExec: func(ctx context.Context, e gqbd.HookEvent) {
+		kinds = append(kinds, e.Kind+" "+strings.SplitN(e.Query, " ", 2)[0])
+	}}
+	users := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("active = ?", true).AddHook(hook)
+	if _, err := gqbd.CountExec(ctx, db, users); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := gqbd.SumExec(ctx, db, users, "score"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := gqbd.ExistsExec(ctx, db, users); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := users.ChunkByID(ctx, db, "id", 10, func([]map[string]interface{}) error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := gqbd.BuildDelete(gqbd.PostgreSQL, "users").Where("id = ?", 1).AddHook(hook).DryRun(ctx, db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"SELECT SELECT", "SELECT SELECT", "SELECT SELECT", "SELECT SELECT", "SELECT SELECT"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected hook events %v, got %v", expected, kinds)
+	}
+}
+
+/*
+LogHook
+
+@ Return: Slow executions logged at Warn level with the query and without the arguments
+*/
+func TestLogHook(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		time.Sleep(5 * time.Millisecond)
+		return fakeResult{affected: 1}, nil
+	})
+	defer db.Close()
+
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	_, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		Set(map[string]interface{}{"email": "secret@example.com"}).
+		Where("id = ?", 1).
+		Exec(context.Background(), db, gqbd.WithHook(gqbd.LogHook(logger, time.Millisecond)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="slow query"`) || !strings.Contains(out, "table=users") {
+		t.Errorf("expected slow query warning, got %s", out)
+	}
+	if strings.Contains(out, "secret@example.com") {
+		t.Errorf("expected arguments to be left out, got %s", out)
+	}
+}
+
+/*
+SanitizeQuery and HookEvent.Rows
+
+@ Return: Inlined literals replaced for traces, and affected rows reported to AfterExec
+*/
+func TestSanitizeQueryAndRows(t *testing.T) {
+	query := "SELECT \"col1\", [t2].x FROM users WHERE status = 'it''s' AND age > 21 AND id = $1 AND code = @p2 AND n = :n1 LIMIT 10"
+	expected := "SELECT \"col1\", [t2].x FROM users WHERE status = ? AND age > ? AND id = $1 AND code = @p2 AND n = :n1 LIMIT ?"
+	if got := gqbd.SanitizeQuery(query); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{affected: 4, columns: []string{"id"}}, nil
+	})
+	defer db.Close()
+	var rows []int64
+	hook := gqbd.WithHook(gqbd.HookFuncs{OnAfterExec: func(ctx context.Context, e gqbd.HookEvent) {
+		rows = append(rows, e.Rows)
+	}})
+	ctx := context.Background()
+	if _, err := gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").Where("user_id = ?", 1).Exec(ctx, db, hook); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := gqbd.BuildSelect(gqbd.PostgreSQL, "sessions", "id").RunWith(db, hook).Query(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Close()
+	if !reflect.DeepEqual(rows, []int64{4, -1}) {
+		t.Errorf("expected rows [4 -1], got %v", rows)
+	}
+}
+
+/*
+Named
+
+@ Return: Query name passed to hooks with the execution
+*/
+func TestNamedQueryHook(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{columns: []string{"id"}}, nil
+	})
+	defer db.Close()
+
+	var names []string
+	hook := gqbd.HookFuncs{OnAfterExec: func(ctx context.Context, e gqbd.HookEvent) {
+		names = append(names, e.Name)
+	}}
+	base := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").AddHook(hook).Freeze()
+	for _, qb := range []*gqbd.QueryBuilder{base.Named("list_users"), base} {
+		rows, err := qb.RunWith(db).Query(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rows.Close()
+	}
+	if !reflect.DeepEqual(names, []string{"list_users", ""}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
+
+/*
+Fingerprint
+
+@ Return: Queries differing in values, placeholder style, whitespace or list lengths sharing one fingerprint
+*/
+func TestFingerprint(t *testing.T) {
+	expected := "SELECT \"id\" FROM \"users\" WHERE status = ? AND id IN (...) AND created_at::date > ?"
+	queries := []string{
+		"SELECT \"id\" FROM \"users\" WHERE status = $1 AND id IN ($2, $3, $4) AND created_at::date > $5",
+		"SELECT \"id\"\n  FROM \"users\"\n WHERE status = 'active' AND id IN (?) AND created_at::date > '2024-01-01'",
+		"SELECT \"id\" FROM \"users\" WHERE status = @status AND id IN (@p1, @p2) AND created_at::date > :since",
+	}
+	for _, q := range queries {
+		if got := gqbd.Fingerprint(q); got != expected {
+			t.Errorf("expected fingerprint:\n%s\ngot:\n%s", expected, got)
+		}
+	}
+
+	one := gqbd.BuildInsert(gqbd.PostgreSQL, "tags").InsertColumns("name", "slug").ValuesRow("a", "a")
+	two := one.Clone().ValuesRow("b", "b")
+	q1, _, _ := one.Build()
+	q2, _, _ := two.Build()
+	if f1, f2 := gqbd.Fingerprint(q1), gqbd.Fingerprint(q2); f1 != f2 || f1 != "INSERT INTO \"tags\" (\"name\", \"slug\") VALUES (...)" {
+		t.Errorf("expected equal VALUES fingerprints, got %q and %q", f1, f2)
+	}
+}
+
+/*
+StmtCache
+
+@ Return: Statements prepared once per query, the least recently used one evicted, and hit counts reported
+*/
+func TestStmtCache(t *testing.T) {
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}, affected: 1}, nil
+	})
+	defer db.Close()
+	cache, err := gqbd.NewStmtCache(db, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cache.Close()
+	ctx := context.Background()
+
+	byID := func(id int) *gqbd.QueryBuilder {
+		return gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("id = ?", id)
+	}
+	byEmail := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("email = ?", "a@example.com")
+	touch := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").Set(map[string]interface{}{"seen": true}).Where("id = ?", 1)
+
+	var id int64
+	for i := 1; i <= 3; i++ {
+		if err := byID(i).RunWith(cache).QueryRow(ctx).Scan(&id); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := gqbd.All[struct {
+		ID int64 `db:"id"`
+	}](ctx, cache, byEmail); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := touch.Exec(ctx, cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := byID(4).RunWith(cache).QueryRow(ctx).Scan(&id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"SELECT \"id\" FROM \"users\" WHERE id = $1",
+		"SELECT \"id\" FROM \"users\" WHERE email = $1",
+		"UPDATE \"users\" SET \"seen\" = $1 WHERE id = $2",
+		"SELECT \"id\" FROM \"users\" WHERE id = $1",
+	}
+	if prepared := conn.Prepared(); !reflect.DeepEqual(prepared, expected) {
+		t.Errorf("expected prepared:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(prepared, "\n"))
+	}
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Evictions != 2 || stats.Size != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if rate := stats.HitRate(); rate != 2.0/6 {
+		t.Errorf("unexpected hit rate: %v", rate)
+	}
+
+	if _, err := gqbd.NewStmtCache(db, 0); err == nil {
+		t.Error("expected error for empty cache")
+	}
+}
+
+/*
+StmtCache under concurrent eviction
+
+@ Return: No "statement is closed" errors when callers keep evicting each other's statements from a cache of one
+*/
+func TestStmtCacheConcurrentEviction(t *testing.T) {
+	db, _ := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		return fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}, affected: 1}, nil
+	})
+	defer db.Close()
+	cache, err := gqbd.NewStmtCache(db, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cache.Close()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				table := fmt.Sprintf("t%d", (g+i)%4)
+				qb := gqbd.BuildSelect(gqbd.PostgreSQL, table, "id").Where("id = ?", i)
+				var id int64
+				if err := qb.RunWith(cache).QueryRow(ctx).Scan(&id); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := gqbd.BuildDelete(gqbd.PostgreSQL, table).Where("id = ?", i).Exec(ctx, cache); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if stats := cache.Stats(); stats.Size != 1 || stats.Evictions == 0 {
+		t.Errorf("expected evictions with one cached statement, got %+v", stats)
+	}
+}
@@ -0,0 +1,226 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Plan is a summary of the execution plan reported by EXPLAIN.
+type Plan struct {
+	Cost    float64  // Estimated total cost (PostgreSQL only)
+	Rows    float64  // Estimated rows examined
+	Indexes []string // Indexes used by the plan
+	Scans   []string // Access paths, e.g. "Seq Scan on users" or "ALL on users"
+	Raw     string   // Raw EXPLAIN output
+}
+
+// PlanDiff reports the differences between two plans.
+type PlanDiff struct {
+	Before         *Plan
+	After          *Plan
+	AddedIndexes   []string
+	RemovedIndexes []string
+	AddedScans     []string
+	RemovedScans   []string
+	CostDelta      float64
+	RowsDelta      float64
+}
+
+/*
+Explain
+
+@ ctx: Context for the EXPLAIN query
+@ db: Database handle to run EXPLAIN against
+@ qb: SELECT builder to explain
+@ Return: Plan summary and error if any
+*/
+func Explain(ctx context.Context, db Executor, qb *QueryBuilder) (*Plan, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	switch qb.dbType {
+	case PostgreSQL:
+		return explainPostgres(ctx, db, query, args)
+	case MariaDB, Mysql:
+		return explainMariaDB(ctx, db, query, args)
+	default:
+		return nil, fmt.Errorf("explain is not supported for db type: %v", qb.dbType)
+	}
+}
+
+/*
+ExplainDiff
+
+@ ctx: Context for the EXPLAIN queries
+@ db: Database handle to run EXPLAIN against
+@ before: Builder with the old query shape
+@ after: Builder with the new query shape
+@ Return: Differences between both plans and error if any
+*/
+func ExplainDiff(ctx context.Context, db Executor, before, after *QueryBuilder) (*PlanDiff, error) {
+	if before.dbType != after.dbType {
+		return nil, fmt.Errorf("cannot compare plans across db types: %v and %v", before.dbType, after.dbType)
+	}
+	beforePlan, err := Explain(ctx, db, before)
+	if err != nil {
+		return nil, err
+	}
+	afterPlan, err := Explain(ctx, db, after)
+	if err != nil {
+		return nil, err
+	}
+	diff := &PlanDiff{
+		Before:    beforePlan,
+		After:     afterPlan,
+		CostDelta: afterPlan.Cost - beforePlan.Cost,
+		RowsDelta: afterPlan.Rows - beforePlan.Rows,
+	}
+	diff.AddedIndexes, diff.RemovedIndexes = diffStrings(beforePlan.Indexes, afterPlan.Indexes)
+	diff.AddedScans, diff.RemovedScans = diffStrings(beforePlan.Scans, afterPlan.Scans)
+	return diff, nil
+}
+
+/*
+String
+
+@ Return: Human readable report of the plan differences
+*/
+func (d *PlanDiff) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "cost: %.2f -> %.2f (%+.2f)\n", d.Before.Cost, d.After.Cost, d.CostDelta)
+	fmt.Fprintf(&sb, "rows: %.0f -> %.0f (%+.0f)\n", d.Before.Rows, d.After.Rows, d.RowsDelta)
+	for _, idx := range d.AddedIndexes {
+		fmt.Fprintf(&sb, "+ index %s\n", idx)
+	}
+	for _, idx := range d.RemovedIndexes {
+		fmt.Fprintf(&sb, "- index %s\n", idx)
+	}
+	for _, scan := range d.AddedScans {
+		fmt.Fprintf(&sb, "+ %s\n", scan)
+	}
+	for _, scan := range d.RemovedScans {
+		fmt.Fprintf(&sb, "- %s\n", scan)
+	}
+	return sb.String()
+}
+
+type pgPlanNode struct {
+	NodeType     string       `json:"Node Type"`
+	RelationName string       `json:"Relation Name"`
+	IndexName    string       `json:"Index Name"`
+	TotalCost    float64      `json:"Total Cost"`
+	PlanRows     float64      `json:"Plan Rows"`
+	Plans        []pgPlanNode `json:"Plans"`
+}
+
+func explainPostgres(ctx context.Context, db Executor, query string, args []interface{}) (*Plan, error) {
+	var raw string
+	if err := db.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+query, args...).Scan(&raw); err != nil {
+		return nil, err
+	}
+	var out []struct {
+		Plan pgPlanNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, fmt.Errorf("parse explain output: %w", err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty explain output")
+	}
+	plan := &Plan{Cost: out[0].Plan.TotalCost, Rows: out[0].Plan.PlanRows, Raw: raw}
+	var walk func(node pgPlanNode)
+	walk = func(node pgPlanNode) {
+		if node.IndexName != "" {
+			plan.Indexes = append(plan.Indexes, node.IndexName)
+		}
+		if node.RelationName != "" {
+			plan.Scans = append(plan.Scans, fmt.Sprintf("%s on %s", node.NodeType, node.RelationName))
+		}
+		for _, child := range node.Plans {
+			walk(child)
+		}
+	}
+	walk(out[0].Plan)
+	return plan, nil
+}
+
+func explainMariaDB(ctx context.Context, db Executor, query string, args []interface{}) (*Plan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	var raw strings.Builder
+	raw.WriteString(strings.Join(columns, "\t"))
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(columns))
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			row[strings.ToLower(col)] = values[i].String
+			cells[i] = values[i].String
+		}
+		raw.WriteString("\n" + strings.Join(cells, "\t"))
+		if row["key"] != "" {
+			plan.Indexes = append(plan.Indexes, row["key"])
+		}
+		if row["table"] != "" {
+			plan.Scans = append(plan.Scans, fmt.Sprintf("%s on %s", row["type"], row["table"]))
+		}
+		if n, err := strconv.ParseFloat(row["rows"], 64); err == nil {
+			plan.Rows += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	plan.Raw = raw.String()
+	return plan, nil
+}
+
+/*
+diffStrings
+
+@ before: Values of the old plan
+@ after: Values of the new plan
+@ Return: Sorted values only present in after, and sorted values only present in before
+*/
+func diffStrings(before, after []string) (added, removed []string) {
+	seen := make(map[string]int, len(before))
+	for _, v := range before {
+		seen[v]++
+	}
+	for _, v := range after {
+		if seen[v] > 0 {
+			seen[v]--
+			continue
+		}
+		added = append(added, v)
+	}
+	for v, n := range seen {
+		for ; n > 0; n-- {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
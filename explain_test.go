@@ -0,0 +1,51 @@
+package gqbd_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+ExplainDiff
+
+@ Return: Index and cost differences between two PostgreSQL plans
+*/
+func TestExplainDiffPostgreSQL(t *testing.T) {
+	seqScan := `[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "users", "Total Cost": 35.5, "Plan Rows": 10}}]`
+	indexScan := `[{"Plan": {"Node Type": "Index Scan", "Relation Name": "users", "Index Name": "users_email_idx", "Total Cost": 8.17, "Plan Rows": 1}}]`
+	db, conn := newFakeDB(func(query string, args []interface{}) (fakeResult, error) {
+		plan := seqScan
+		if strings.Contains(query, `"email"`) {
+			plan = indexScan
+		}
+		return fakeResult{columns: []string{"QUERY PLAN"}, rows: [][]driver.Value{{plan}}}, nil
+	})
+	defer db.Close()
+
+	before := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("lower(email) = ?", "a@b.c")
+	after := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email").Where("email = ?", "a@b.c")
+
+	diff, err := gqbd.ExplainDiff(context.Background(), db, before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Logf("Plan Diff :\n%s", diff)
+
+	if !strings.HasPrefix(conn.Queries()[0], "EXPLAIN (FORMAT JSON) SELECT") {
+		t.Errorf("expected EXPLAIN query, got %s", conn.Queries()[0])
+	}
+	if !reflect.DeepEqual(diff.AddedIndexes, []string{"users_email_idx"}) {
+		t.Errorf("expected added index users_email_idx, got %v", diff.AddedIndexes)
+	}
+	if !reflect.DeepEqual(diff.RemovedScans, []string{"Seq Scan on users"}) {
+		t.Errorf("expected removed seq scan, got %v", diff.RemovedScans)
+	}
+	if diff.CostDelta >= 0 {
+		t.Errorf("expected cost to decrease, got delta %v", diff.CostDelta)
+	}
+}
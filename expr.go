@@ -0,0 +1,159 @@
+package gqbd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RawExpr is SQL written verbatim, accepted only by the *Raw methods such as SelectRaw and OrderByRaw.
+// Being a distinct type, it cannot be smuggled in through a column name; never build one from user input.
+type RawExpr struct {
+	sql string
+}
+
+/*
+Raw
+
+@ sql: SQL expression to emit verbatim, e.g. "COALESCE(name, 'n/a')"
+@ Return: RawExpr for SelectRaw, GroupByRaw, OrderByRaw and AsOfSystemTimeRaw
+*/
+func Raw(sql string) RawExpr {
+	return RawExpr{sql: sql}
+}
+
+// Expression is a SQL fragment written with "?" placeholders together with its bound args.
+type Expression struct {
+	sql   string
+	args  []interface{}
+	alias string
+	err   error
+}
+
+/*
+Expr
+
+@ sql: SQL fragment with "?" placeholders
+@ args: Query parameters for the fragment
+@ Return: Expression usable with SelectExpr and OrderByExpression
+*/
+func Expr(sql string, args ...interface{}) Expression {
+	return Expression{sql: sql, args: args}
+}
+
+/*
+As
+
+@ alias: Output column name
+@ Return: Copy of the expression with an alias for the SELECT list
+*/
+func (e Expression) As(alias string) Expression {
+	e.alias = alias
+	return e
+}
+
+/*
+toClause
+
+@ dbType: Database type used to escape the alias
+@ withAlias: Whether to append the alias (only valid in the SELECT list)
+@ Return: Clause for the expression and error if any
+*/
+func (e Expression) toClause(dbType DBType, withAlias bool) (clause, error) {
+	if e.err != nil {
+		return clause{}, e.err
+	}
+	c := clause{sql: e.sql, args: e.args}
+	if withAlias && e.alias != "" {
+		safeAlias, err := EscapeIdentifier(dbType, e.alias)
+		if err != nil {
+			return clause{}, err
+		}
+		c.sql += " AS " + safeAlias
+	}
+	return c, nil
+}
+
+// CaseBuilder builds a CASE expression with parameterized WHEN conditions and results.
+type CaseBuilder struct {
+	whens   []clause
+	elseVal *clause
+}
+
+/*
+Case
+
+@ Return: Empty *CaseBuilder
+*/
+func Case() *CaseBuilder {
+	return &CaseBuilder{}
+}
+
+/*
+When
+
+@ condition: WHEN condition with placeholders
+@ result: Value returned when the condition matches; bound as a parameter unless it is an Expression
+@ args: Query parameters for the condition
+@ Return: *CaseBuilder with the WHEN branch added
+*/
+func (c *CaseBuilder) When(condition string, result interface{}, args ...interface{}) *CaseBuilder {
+	then := caseValue(result)
+	c.whens = append(c.whens, clause{
+		sql:  "WHEN " + condition + " THEN " + then.sql,
+		args: append(append([]interface{}{}, args...), then.args...),
+	})
+	return c
+}
+
+/*
+Else
+
+@ result: Value returned when no branch matches; bound as a parameter unless it is an Expression
+@ Return: *CaseBuilder with the ELSE branch set
+*/
+func (c *CaseBuilder) Else(result interface{}) *CaseBuilder {
+	v := caseValue(result)
+	c.elseVal = &v
+	return c
+}
+
+/*
+End
+
+@ Return: Expression for the CASE, usable in SELECT and ORDER BY
+*/
+func (c *CaseBuilder) End() Expression {
+	if len(c.whens) == 0 {
+		return Expression{err: fmt.Errorf("CASE expression requires at least one WHEN")}
+	}
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("CASE")
+	for _, w := range c.whens {
+		sb.WriteString(" " + w.sql)
+		args = append(args, w.args...)
+	}
+	if c.elseVal != nil {
+		sb.WriteString(" ELSE " + c.elseVal.sql)
+		args = append(args, c.elseVal.args...)
+	}
+	sb.WriteString(" END")
+	return Expression{sql: sb.String(), args: args}
+}
+
+/*
+As
+
+@ alias: Output column name
+@ Return: Expression for the CASE with an alias for the SELECT list
+*/
+func (c *CaseBuilder) As(alias string) Expression {
+	return c.End().As(alias)
+}
+
+func caseValue(v interface{}) clause {
+	if e, ok := v.(Expression); ok {
+		return clause{sql: e.sql, args: e.args}
+	}
+	return clause{sql: "?", args: []interface{}{v}}
+}
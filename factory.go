@@ -0,0 +1,216 @@
+package gqbd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Factory creates builders for one database type, sharing a schema registry, per-table insert defaults
+// and a write policy.
+type Factory struct {
+	dbType   DBType
+	registry *SchemaRegistry
+	mu       sync.RWMutex
+	defaults map[string]map[string]interface{}
+	policy   WritePolicy
+	stamps   *Timestamps
+	trashed  map[string]string // Soft-delete column per table
+}
+
+// WritePolicy lists columns that Insert and Update builders of a Factory may not write unless AllowWrite is called.
+type WritePolicy struct {
+	Protected []string            // Columns protected on every table, e.g. "id", "created_at", "tenant_id"
+	Tables    map[string][]string // Additional protected columns per table
+}
+
+/*
+NewFactory
+
+@ dbType: Database type of the builders
+@ Return: *Factory
+*/
+func NewFactory(dbType DBType) *Factory {
+	return &Factory{dbType: dbType, defaults: make(map[string]map[string]interface{})}
+}
+
+/*
+WithRegistry
+
+@ registry: Schema registry attached to every builder
+@ Return: *Factory for chaining
+*/
+func (f *Factory) WithRegistry(registry *SchemaRegistry) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.registry = registry
+	return f
+}
+
+/*
+SetDefaults
+
+@ table: Table name as passed to Insert
+@ defaults: Column values used when an INSERT does not set the column. Values may be Go values,
+an Expression such as Expr("gen_random_uuid()"), or a func() interface{} called for every row
+@ Return: *Factory for chaining
+*/
+func (f *Factory) SetDefaults(table string, defaults map[string]interface{}) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.defaults[table] = maps.Clone(defaults)
+	return f
+}
+
+/*
+WithWritePolicy
+
+@ policy: Columns Insert and Update builders may not write
+@ Return: *Factory for chaining
+*/
+func (f *Factory) WithWritePolicy(policy WritePolicy) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tables := make(map[string][]string, len(policy.Tables))
+	for table, columns := range policy.Tables {
+		tables[table] = slices.Clone(columns)
+	}
+	f.policy = WritePolicy{Protected: slices.Clone(policy.Protected), Tables: tables}
+	return f
+}
+
+/*
+WithTimestamps
+
+@ t: Timestamp columns and clock used by every Insert and Update builder
+@ Return: *Factory for chaining
+*/
+func (f *Factory) WithTimestamps(t Timestamps) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stamps = &t
+	return f
+}
+
+/*
+WithSoftDelete
+
+@ column: Timestamp column marking deleted rows, e.g. "deleted_at"
+@ tables: Tables whose Select and Delete builders use SoftDelete(column)
+@ Return: *Factory for chaining
+*/
+func (f *Factory) WithSoftDelete(column string, tables ...string) *Factory {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.trashed == nil {
+		f.trashed = make(map[string]string)
+	}
+	for _, table := range tables {
+		f.trashed[table] = column
+	}
+	return f
+}
+
+func (f *Factory) apply(qb *QueryBuilder) *QueryBuilder {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.registry != nil {
+		qb.registry = f.registry
+	}
+	if column, ok := f.trashed[qb.tableName]; ok && (qb.op == "SELECT" || qb.op == "DELETE") {
+		qb.SoftDelete(column)
+	}
+	if qb.op == "INSERT" || qb.op == "UPDATE" {
+		qb.timestamps = f.stamps
+		for _, col := range slices.Concat(f.policy.Protected, f.policy.Tables[qb.tableName]) {
+			if qb.protected == nil {
+				qb.protected = make(map[string]bool)
+			}
+			qb.protected[col] = true
+		}
+	}
+	return qb
+}
+
+/*
+AllowWrite
+
+@ columns: Protected columns this builder may write
+@ Return: *QueryBuilder exempt from the factory's write policy for the given columns
+*/
+func (qb *QueryBuilder) AllowWrite(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	for _, col := range columns {
+		delete(qb.protected, col)
+	}
+	return qb
+}
+
+/*
+checkProtected
+
+@ Return: Error if the INSERT or UPDATE writes a column protected by the factory's write policy.
+Columns filled from the factory's defaults are not checked
+*/
+func (qb *QueryBuilder) checkProtected() error {
+	if len(qb.protected) == 0 {
+		return nil
+	}
+	columns := slices.Concat(qb.insertCols, slices.Collect(maps.Keys(qb.data)), slices.Collect(maps.Keys(qb.adjustments)))
+	if qb.conflict != nil {
+		columns = append(columns, qb.conflict.update...)
+	}
+	slices.Sort(columns)
+	for _, col := range columns {
+		if qb.protected[col] {
+			return fmt.Errorf("column %s of table %s is protected; use AllowWrite to write it", col, qb.tableName)
+		}
+	}
+	return nil
+}
+
+/*
+Select
+
+@ table: Table name
+@ columns: Columns to select
+@ Return: SELECT *QueryBuilder
+*/
+func (f *Factory) Select(table string, columns ...string) *QueryBuilder {
+	return f.apply(BuildSelect(f.dbType, table, columns...))
+}
+
+/*
+Insert
+
+@ table: Table name
+@ Return: INSERT *QueryBuilder filling missing columns from the table's defaults
+*/
+func (f *Factory) Insert(table string) *QueryBuilder {
+	qb := f.apply(BuildInsert(f.dbType, table))
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	qb.defaults = maps.Clone(f.defaults[table])
+	return qb
+}
+
+/*
+Update
+
+@ table: Table name
+@ Return: UPDATE *QueryBuilder
+*/
+func (f *Factory) Update(table string) *QueryBuilder {
+	return f.apply(BuildUpdate(f.dbType, table))
+}
+
+/*
+Delete
+
+@ table: Table name
+@ Return: DELETE *QueryBuilder
+*/
+func (f *Factory) Delete(table string) *QueryBuilder {
+	return f.apply(BuildDelete(f.dbType, table))
+}
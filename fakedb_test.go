@@ -0,0 +1,203 @@
+package gqbd_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// fakeResult is the canned response returned by fakeConnector for a statement.
+type fakeResult struct {
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// fakeConnector is an in-memory database/sql connector recording every statement it receives.
+type fakeConnector struct {
+	mu       sync.Mutex
+	queries  []string
+	args     [][]interface{}
+	connIDs  []int // connection each statement ran on
+	connects int
+	prepared []string // queries prepared, in order
+	handler  func(query string, args []interface{}) (fakeResult, error)
+}
+
+/*
+newFakeDB
+
+@ handler: Function returning the canned response for each statement
+@ Return: *sql.DB backed by the fake connector, and the connector for inspection
+*/
+func newFakeDB(handler func(query string, args []interface{}) (fakeResult, error)) (*sql.DB, *fakeConnector) {
+	c := &fakeConnector{handler: handler}
+	return sql.OpenDB(c), c
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connects++
+	return &fakeConn{c: c, id: c.connects}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+func (c *fakeConnector) run(connID int, query string, named []driver.NamedValue) (fakeResult, error) {
+	args := make([]interface{}, len(named))
+	for i, nv := range named {
+		args[i] = nv.Value
+	}
+	c.mu.Lock()
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+	c.connIDs = append(c.connIDs, connID)
+	c.mu.Unlock()
+	if c.handler == nil {
+		return fakeResult{}, nil
+	}
+	return c.handler(query, args)
+}
+
+/*
+Queries
+
+@ Return: Copy of every statement received so far, in order
+*/
+func (c *fakeConnector) Queries() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.queries...)
+}
+
+/*
+ConnIDs
+
+@ Return: Connection each statement received so far ran on, numbered from 1 in connect order
+*/
+func (c *fakeConnector) ConnIDs() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.connIDs...)
+}
+
+/*
+Prepared
+
+@ Return: Copy of every query prepared so far, in order
+*/
+func (c *fakeConnector) Prepared() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.prepared...)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver: use newFakeDB")
+}
+
+type fakeConn struct {
+	c  *fakeConnector
+	id int
+}
+
+func (fc *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	fc.c.mu.Lock()
+	defer fc.c.mu.Unlock()
+	fc.c.prepared = append(fc.c.prepared, query)
+	return &fakeStmt{fc: fc, query: query}, nil
+}
+
+// fakeStmt runs its query through the connector like an unprepared statement.
+type fakeStmt struct {
+	fc    *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("fake driver: use ExecContext")
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("fake driver: use QueryContext")
+}
+
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.fc.ExecContext(ctx, s.query, args)
+}
+
+func (s *fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.fc.QueryContext(ctx, s.query, args)
+}
+
+func (fc *fakeConn) Close() error { return nil }
+
+func (fc *fakeConn) Begin() (driver.Tx, error) {
+	return fc.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (fc *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if _, err := fc.c.run(fc.id, "BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return &fakeTx{fc: fc}, nil
+}
+
+func (fc *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res, err := fc.c.run(fc.id, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: res.columns, rows: res.rows}, nil
+}
+
+func (fc *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := fc.c.run(fc.id, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(res.affected), nil
+}
+
+type fakeTx struct {
+	fc *fakeConn
+}
+
+func (tx *fakeTx) Commit() error {
+	_, err := tx.fc.c.run(tx.fc.id, "COMMIT", nil)
+	return err
+}
+
+func (tx *fakeTx) Rollback() error {
+	_, err := tx.fc.c.run(tx.fc.id, "ROLLBACK", nil)
+	return err
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
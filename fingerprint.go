@@ -0,0 +1,34 @@
+package gqbd
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// fingerprintDollar matches $n placeholders.
+	fingerprintDollar = regexp.MustCompile(`\$\d+`)
+	// fingerprintNamed matches @name and :name placeholders, but not :: casts.
+	fingerprintNamed = regexp.MustCompile(`(^|[^:@\w])[:@]\w+`)
+	// fingerprintList matches a parenthesized list of placeholders, e.g. an IN list or a VALUES row.
+	fingerprintList = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	// fingerprintLists matches repeated collapsed lists, e.g. the rows of a multi-row VALUES.
+	fingerprintLists = regexp.MustCompile(`\(\.\.\.\)(?:\s*,\s*\(\.\.\.\))+`)
+)
+
+/*
+Fingerprint
+
+@ query: Built or hand-written query
+@ Return: Shape of the query for grouping logs and metrics: literals and placeholders become "?", lists of
+placeholders such as IN lists and VALUES rows become "(...)" whatever their length, and whitespace is collapsed.
+Queries that differ only in values or list lengths share a fingerprint
+*/
+func Fingerprint(query string) string {
+	s := SanitizeQuery(query)
+	s = fingerprintDollar.ReplaceAllString(s, "?")
+	s = fingerprintNamed.ReplaceAllString(s, "${1}?")
+	s = strings.Join(strings.Fields(s), " ")
+	s = fingerprintList.ReplaceAllString(s, "(...)")
+	return fingerprintLists.ReplaceAllString(s, "(...)")
+}
@@ -0,0 +1,156 @@
+package gqbd
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// fixtureRow is a struct to load into a table.
+type fixtureRow struct {
+	table string
+	row   interface{}
+}
+
+// Fixtures builds the statements loading test data from structs and removing it afterwards,
+// using the same escaping and dialect as the code under test.
+type Fixtures struct {
+	dbType   DBType
+	registry *SchemaRegistry
+	opts     []StructOption
+	rows     []fixtureRow
+	err      error
+}
+
+/*
+NewFixtures
+
+@ dbType: Database type
+@ registry: Schema registry; every fixture table must be registered
+@ opts: Struct mapping options; zero values are skipped unless IncludeZeroValues is given
+@ Return: Empty *Fixtures
+*/
+func NewFixtures(dbType DBType, registry *SchemaRegistry, opts ...StructOption) *Fixtures {
+	return &Fixtures{dbType: dbType, registry: registry, opts: opts}
+}
+
+/*
+Add
+
+@ table: Registered table name
+@ rows: Structs or pointers to structs with `db:"column"` tags, one per row
+@ Return: *Fixtures for chaining; errors are reported by Inserts and Cleanup
+*/
+func (f *Fixtures) Add(table string, rows ...interface{}) *Fixtures {
+	if f.err != nil {
+		return f
+	}
+	if f.registry == nil {
+		f.err = fmt.Errorf("fixtures require a schema registry")
+		return f
+	}
+	if _, ok := f.registry.Table(table); !ok {
+		f.err = fmt.Errorf("fixture table %s is not registered", table)
+		return f
+	}
+	for _, row := range rows {
+		f.rows = append(f.rows, fixtureRow{table: table, row: row})
+	}
+	return f
+}
+
+/*
+Inserts
+
+@ Return: One INSERT builder per row in the order they were added, and error if any row cannot be mapped
+*/
+func (f *Fixtures) Inserts() ([]*QueryBuilder, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	inserts := make([]*QueryBuilder, 0, len(f.rows))
+	for _, r := range f.rows {
+		columns, values, err := structColumns(r.row, f.opts)
+		if err != nil {
+			return nil, err
+		}
+		data := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			data[col] = values[i]
+		}
+		inserts = append(inserts, BuildInsert(f.dbType, r.table).WithRegistry(f.registry).Values(data))
+	}
+	return inserts, nil
+}
+
+/*
+Cleanup
+
+@ Return: DELETE builders removing the added rows, tables in reverse order of first use so that
+rows referencing other fixtures go first, and error if any row cannot be mapped.
+Rows of tables with a PrimaryKey are deleted by key; other rows are matched on all their mapped columns
+*/
+func (f *Fixtures) Cleanup() ([]*QueryBuilder, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var tables []string
+	byTable := make(map[string][]interface{})
+	for _, r := range f.rows {
+		if _, ok := byTable[r.table]; !ok {
+			tables = append(tables, r.table)
+		}
+		byTable[r.table] = append(byTable[r.table], r.row)
+	}
+	var deletes []*QueryBuilder
+	for i := len(tables) - 1; i >= 0; i-- {
+		table := tables[i]
+		schema, _ := f.registry.Table(table)
+		if schema.PrimaryKey == "" {
+			for _, row := range byTable[table] {
+				if _, err := structValue(row); err != nil {
+					return nil, err
+				}
+				deletes = append(deletes, BuildDelete(f.dbType, table).WithRegistry(f.registry).WhereStruct(row, f.opts...))
+			}
+			continue
+		}
+		keys := make([]interface{}, 0, len(byTable[table]))
+		for _, row := range byTable[table] {
+			key, err := structColumnValue(row, schema.PrimaryKey)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		deletes = append(deletes, BuildDelete(f.dbType, table).WithRegistry(f.registry).WhereIn(schema.PrimaryKey, keys))
+	}
+	return deletes, nil
+}
+
+/*
+structColumnValue
+
+@ v: Struct or pointer to struct
+@ column: Column name of a tagged field
+@ Return: Field value, and error if the field is missing or zero
+*/
+func structColumnValue(v interface{}, column string) (interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range structFields(rv.Type()) {
+		if field.column != column {
+			continue
+		}
+		fv, err := rv.FieldByIndexErr(field.index)
+		if err != nil || fv.IsZero() {
+			return nil, fmt.Errorf("fixture for %s has no value for key column %s", rv.Type(), column)
+		}
+		if fv.Kind() == reflect.Pointer {
+			fv = fv.Elem()
+		}
+		return fv.Interface(), nil
+	}
+	return nil, fmt.Errorf("%s has no field for key column %s", rv.Type(), column)
+}
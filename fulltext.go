@@ -0,0 +1,84 @@
+package gqbd
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+fullTextMatch
+
+@ dbType: Database type
+@ columns: Columns searched together
+@ Return: Document and query SQL for the match, and error if any.
+PostgreSQL and CockroachDB yield to_tsvector(...) and plainto_tsquery(?); MariaDB/Mysql yields MATCH(...) and AGAINST (...)
+*/
+func fullTextMatch(dbType DBType, columns []string) (string, string, error) {
+	if len(columns) == 0 {
+		return "", "", fmt.Errorf("full-text search requires at least one column")
+	}
+	safeCols, err := escapeIdentifiers(dbType, columns)
+	if err != nil {
+		return "", "", err
+	}
+	features := dialectOf(dbType).Features()
+	switch {
+	case features.TextSearch:
+		doc := safeCols[0]
+		if len(safeCols) > 1 {
+			// concat_ws skips NULL columns, which || would turn the whole document into.
+			doc = "concat_ws(' ', " + strings.Join(safeCols, ", ") + ")"
+		}
+		return "to_tsvector(" + doc + ")", "plainto_tsquery(?)", nil
+	case features.MatchAgainst:
+		return "MATCH(" + strings.Join(safeCols, ", ") + ")", "AGAINST (? IN NATURAL LANGUAGE MODE)", nil
+	default:
+		return "", "", fmt.Errorf("full-text search is not supported for db type: %v", dbType)
+	}
+}
+
+/*
+WhereFullText
+
+@ columns: Columns searched together; MariaDB/Mysql need a FULLTEXT index over exactly these columns
+@ query: Search text in plain language, e.g. "quick brown fox"
+@ Return: *QueryBuilder with "to_tsvector(...) @@ plainto_tsquery(?)" (PostgreSQL, CockroachDB) or
+"MATCH(...) AGAINST (? IN NATURAL LANGUAGE MODE)" (MariaDB/Mysql) added
+*/
+func (qb *QueryBuilder) WhereFullText(columns []string, query string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	doc, tsQuery, err := fullTextMatch(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	sep := " "
+	if dialectOf(qb.dbType).Features().TextSearch {
+		sep = " @@ "
+	}
+	qb.conditions = append(qb.conditions, clause{sql: doc + sep + tsQuery, args: []interface{}{query}})
+	return qb
+}
+
+/*
+FullTextRank
+
+@ dbType: Database type
+@ columns: Columns searched together, as given to WhereFullText
+@ query: Search text
+@ Return: Expression for the relevance of each row, usable with SelectExpr and OrderByExpression;
+ts_rank(...) on PostgreSQL and CockroachDB and the MATCH ... AGAINST score on MariaDB/Mysql
+*/
+func FullTextRank(dbType DBType, columns []string, query string) Expression {
+	doc, tsQuery, err := fullTextMatch(dbType, columns)
+	if err != nil {
+		return Expression{err: err}
+	}
+	if dialectOf(dbType).Features().TextSearch {
+		return Expression{sql: "ts_rank(" + doc + ", " + tsQuery + ")", args: []interface{}{query}}
+	}
+	return Expression{sql: doc + " " + tsQuery, args: []interface{}{query}}
+}
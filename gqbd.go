@@ -2,46 +2,118 @@ package gqbd
 
 import (
 	"fmt"
-	"regexp"
+	"io"
+	"maps"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // DBType represents the type of database.
 type DBType string
 
 const (
-	PostgreSQL DBType = "postgres"
-	MariaDB    DBType = "mariadb"
-	Mysql      DBType = "mysql"
+	PostgreSQL  DBType = "postgres"
+	MariaDB     DBType = "mariadb"
+	Mysql       DBType = "mysql"
+	SQLite      DBType = "sqlite"
+	MSSQL       DBType = "mssql"
+	Oracle      DBType = "oracle"
+	CockroachDB DBType = "cockroachdb"
+	ClickHouse  DBType = "clickhouse"
 )
 
 // QueryBuilder is a flexible SQL query builder.
 type QueryBuilder struct {
-	op         string // "SELECT", "INSERT", "UPDATE", "DELETE"
-	dbType     DBType
-	table      string
-	columns    []string
-	joins      []string
-	conditions []string
-	groupBy    []string
-	having     []string
-	orderBy    string
-	limit      int
-	offset     int
-	args       []interface{}
-	distinct   bool
-	err        error
-	data       map[string]interface{} // for INSERT and UPDATE
-	returning  string                 // for INSERT, Postgres only
+	op               string // "SELECT", "INSERT", "UPDATE", "DELETE"
+	dbType           DBType
+	table            string
+	tableName        string // unescaped table name, used for registry lookups
+	columns          []clause
+	implicitStar     bool // columns is the default "*", replaced by the first added expression
+	joins            []joinClause
+	conditions       []clause
+	groupBy          []string
+	withRollup       bool // MariaDB/Mysql GROUP BY ... WITH ROLLUP
+	having           []clause
+	orderBy          []clause
+	orderByDef       string // fallback column for OrderBy, "id" when empty
+	limit            int
+	offset           int
+	distinct         bool
+	err              error
+	data             map[string]interface{} // for INSERT and UPDATE
+	insertCols       []string               // for INSERT with positional rows
+	rows             [][]interface{}        // for INSERT with positional rows
+	returning        string                 // for INSERT; RETURNING on Postgres and SQLite, OUTPUT on MSSQL
+	registry         *SchemaRegistry
+	fromSub          *clause                // subquery used as the FROM source, aliased as table
+	aliases          []string               // aliases generated for subqueries and aggregates
+	strict           bool                   // turn warnings into build errors
+	aggregates       map[string]clause      // aggregate expressions by alias, for SplitAggregateFilters
+	conflict         *conflictSpec          // for INSERT upserts
+	maxPerPage       int                    // upper bound for Paginate, 0 for DefaultMaxPerPage
+	page             *Page                  // effective page set by Paginate
+	collation        string                 // COLLATE applied to generated text comparisons and ORDER BY
+	frozen           bool                   // set by Freeze; mutating methods work on a copy
+	defaults         map[string]interface{} // for INSERT, values of columns left unset
+	asOfSystemTime   string                 // CockroachDB AS OF SYSTEM TIME argument, rendered
+	indexHint        string                 // CockroachDB index hint appended to the table, e.g. @{FORCE_INDEX=...}
+	returningNothing bool                   // CockroachDB RETURNING NOTHING
+	final            bool                   // ClickHouse FINAL
+	sample           string                 // ClickHouse SAMPLE argument, rendered
+	limitBy          *clause                // ClickHouse LIMIT n BY columns
+	namedOutput      bool                   // set on the copy built by BuildNamed
+	protected        map[string]bool        // columns INSERT and UPDATE may not write, set by Factory
+	strictArgs       bool                   // reject args database/sql cannot convert
+	argColumns       *[]string              // set on the copy built by ArgsTyped to collect the column of each arg
+	valuesFrom       bool                   // FROM source is a Values table
+	insertSelect     *clause                // SELECT source of InsertFromSelect
+	idempotency      *idempotencyKey        // Key set by IdempotencyKey
+	maxLimit         int                    // Largest LIMIT of a SELECT, set by WithMaxLimit
+	insertIgnore     bool                   // INSERT IGNORE, set by OrIgnore on MariaDB/Mysql
+	replace          bool                   // REPLACE INTO, or an ON CONFLICT emulation, set by Replace
+	timestamps       *Timestamps            // Automatic created_at/updated_at columns
+	softDelete       string                 // Soft-delete column set by SoftDelete
+	withTrashed      bool                   // Include soft-deleted rows in SELECT
+	versioned        bool                   // Exec reports ErrVersionConflict when no row matched, set by WithVersion
+	hooks            []Hook                 // Hooks added with AddHook
+	name             string                 // Query name for hooks, set by Named
+	shape            *shapeRef              // Cache and key set by CacheShape
+	argsOnly         bool                   // set on the copy built on a ShapeCache hit; only the args are collected
+	placeholderStart int                    // index of the first placeholder, set on the copy built by BuildWithOffset
+	scopesOff        map[string]bool        // global scopes disabled by WithoutScopes
+	noScopes         bool                   // all global scopes disabled by WithoutScopes
+	schema           string                 // schema set with WithSchema, empty for the default schema
+	argSpans         *[]argSpan             // set on the copy built by DebugSQL to collect where each placeholder was written
+	adjustments      map[string]Expression  // column = expression pairs from Increment, Decrement and WithVersion, kept apart from Set
+}
+
+// clause is a SQL fragment written with "?" placeholders and the args bound to them.
+// Placeholders are converted to the dialect's style when the query is built.
+type clause struct {
+	sql    string
+	args   []interface{}
+	native bool   // placeholders are already in the dialect's style; "$n" is relative to the clause
+	column string // unescaped column the args are compared with, empty if unknown
+	alias  string // escaped alias generated for an unaliased aggregate, written only in derived tables
 }
 
-var placeholderRegexp = regexp.MustCompile(`\$(\d+)`)
+// joinClause is a JOIN with its escaped table.
+type joinClause struct {
+	kind      string // "LEFT", "INNER" or "RIGHT"
+	table     string
+	safeTable string
+	on        string
+	args      []interface{} // Args of a Values source
+}
 
 /*
 BuildSelect
 
-@ dbType: Database type (PostgreSQL, MariaDB, Mysql)
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
 @ table: Table name
 @ columns: Columns to select
 @ Return: *QueryBuilder with SELECT operation
@@ -55,7 +127,7 @@ func BuildSelect(dbType DBType, table string, columns ...string) *QueryBuilder {
 /*
 BuildInsert
 
-@ dbType: Database type (PostgreSQL, MariaDB, Mysql)
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
 @ table: Table name
 @ Return: *QueryBuilder with INSERT operation
 */
@@ -68,7 +140,7 @@ func BuildInsert(dbType DBType, table string) *QueryBuilder {
 /*
 BuildUpdate
 
-@ dbType: Database type (PostgreSQL, MariaDB, Mysql)
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
 @ table: Table name
 @ Return: *QueryBuilder with UPDATE operation
 */
@@ -81,7 +153,7 @@ func BuildUpdate(dbType DBType, table string) *QueryBuilder {
 /*
 BuildDelete
 
-@ dbType: Database type (PostgreSQL, MariaDB, Mysql)
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
 @ table: Table name
 @ Return: *QueryBuilder with DELETE operation
 */
@@ -94,33 +166,48 @@ func BuildDelete(dbType DBType, table string) *QueryBuilder {
 /*
 NewQueryBuilder
 
-@ dbType: Database type (PostgreSQL, MariaDB, Mysql)
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
 @ table: Table name
 @ columns: Columns to select (variadic)
 @ Return: *QueryBuilder instance
 */
 func NewQueryBuilder(dbType DBType, table string, columns ...string) *QueryBuilder {
-	qb := &QueryBuilder{dbType: dbType}
+	qb := &QueryBuilder{}
+	qb.init(dbType, table, columns)
+	return qb
+}
+
+/*
+init
+
+@ dbType: Database type
+@ table: Table name
+@ columns: Columns to select, "*" when empty
+@ Return: None. Sets the table and columns of an empty builder, reusing the capacity of its columns slice
+*/
+func (qb *QueryBuilder) init(dbType DBType, table string, columns []string) {
+	qb.dbType = dbType
 	safeTable, err := EscapeIdentifier(dbType, table)
 	if err != nil {
 		qb.err = err
-		return qb
+		return
 	}
 	qb.table = safeTable
-	safeColumns := make([]string, len(columns))
-	for i, col := range columns {
+	qb.tableName = table
+	safeColumns := slices.Grow(qb.columns[:0], len(columns))
+	for _, col := range columns {
 		safeCol, err := EscapeIdentifier(dbType, col)
 		if err != nil {
 			qb.err = err
-			return qb
+			return
 		}
-		safeColumns[i] = safeCol
+		safeColumns = append(safeColumns, clause{sql: safeCol})
 	}
 	if len(safeColumns) == 0 {
-		safeColumns = []string{"*"}
+		safeColumns = append(safeColumns, clause{sql: "*"})
+		qb.implicitStar = true
 	}
 	qb.columns = safeColumns
-	return qb
 }
 
 /*
@@ -129,6 +216,7 @@ Distinct
 @ Return: *QueryBuilder with DISTINCT enabled
 */
 func (qb *QueryBuilder) Distinct() *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
@@ -136,23 +224,275 @@ func (qb *QueryBuilder) Distinct() *QueryBuilder {
 	return qb
 }
 
+// AggregateOption customizes an aggregate added with Aggregate.
+type AggregateOption func(*aggregateSpec)
+
+type aggregateSpec struct {
+	alias    string
+	distinct bool
+	filter   *clause
+}
+
+/*
+AggregateAs
+
+@ alias: Output column name for the aggregate
+@ Return: AggregateOption adding "AS alias"
+*/
+func AggregateAs(alias string) AggregateOption {
+	return func(spec *aggregateSpec) {
+		spec.alias = alias
+	}
+}
+
+/*
+AggregateDistinct
+
+@ Return: AggregateOption aggregating distinct values only, e.g. COUNT(DISTINCT col)
+*/
+func AggregateDistinct() AggregateOption {
+	return func(spec *aggregateSpec) {
+		spec.distinct = true
+	}
+}
+
+/*
+AggregateFilter
+
+@ condition: Condition with placeholders limiting the aggregated rows
+@ args: Query parameters for the condition
+@ Return: AggregateOption adding FILTER (WHERE ...) on PostgreSQL, emulated with CASE WHEN on MariaDB/Mysql
+*/
+func AggregateFilter(condition string, args ...interface{}) AggregateOption {
+	return func(spec *aggregateSpec) {
+		spec.filter = &clause{sql: condition, args: args}
+	}
+}
+
+// aggregateFunctions lists the aggregate functions accepted by Aggregate.
+var aggregateFunctions = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true,
+	"STDDEV": true, "STDDEV_POP": true, "STDDEV_SAMP": true,
+	"VARIANCE": true, "VAR_POP": true, "VAR_SAMP": true,
+	"BIT_AND": true, "BIT_OR": true, "BIT_XOR": true,
+	"BOOL_AND": true, "BOOL_OR": true, "EVERY": true,
+	"ARRAY_AGG": true, "STRING_AGG": true, "GROUP_CONCAT": true,
+	"JSON_AGG": true, "JSONB_AGG": true, "JSON_ARRAYAGG": true,
+}
+
 /*
 Aggregate
 
-@ function: Aggregate function (e.g., COUNT, SUM, AVG)
+@ function: Aggregate function (e.g., COUNT, SUM, AVG); unknown functions record an error
+@ column: Column name to aggregate
+@ opts: Alias, DISTINCT and FILTER options
+@ Return: *QueryBuilder with aggregate function added
+*/
+func (qb *QueryBuilder) Aggregate(function, column string, opts ...AggregateOption) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	name := strings.ToUpper(strings.TrimSpace(function))
+	if !aggregateFunctions[name] {
+		qb.err = fmt.Errorf("unsupported aggregate function: %s", function)
+		return qb
+	}
+	return qb.aggregate(name, column, opts)
+}
+
+/*
+RawAggregate
+
+@ function: Aggregate function emitted verbatim, without allowlist validation
 @ column: Column name to aggregate
+@ opts: Alias, DISTINCT and FILTER options
 @ Return: *QueryBuilder with aggregate function added
 */
-func (qb *QueryBuilder) Aggregate(function, column string) *QueryBuilder {
+func (qb *QueryBuilder) RawAggregate(function, column string, opts ...AggregateOption) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
+	return qb.aggregate(function, column, opts)
+}
+
+func (qb *QueryBuilder) aggregate(function, column string, opts []AggregateOption) *QueryBuilder {
 	safeCol, err := EscapeIdentifier(qb.dbType, column)
 	if err != nil {
 		qb.err = err
 		return qb
 	}
-	qb.columns = append(qb.columns, fmt.Sprintf("%s(%s)", function, safeCol))
+	spec := aggregateSpec{}
+	for _, opt := range opts {
+		opt(&spec)
+	}
+	var distinct string
+	if spec.distinct {
+		distinct = "DISTINCT "
+	}
+	var c clause
+	switch {
+	case spec.filter == nil:
+		c.sql = fmt.Sprintf("%s(%s%s)", function, distinct, safeCol)
+	case dialectOf(qb.dbType).Features().AggregateFilter:
+		c.sql = fmt.Sprintf("%s(%s%s) FILTER (WHERE %s)", function, distinct, safeCol, spec.filter.sql)
+		c.args = spec.filter.args
+	default:
+		value := safeCol
+		if value == "*" {
+			value = "1"
+		}
+		c.sql = fmt.Sprintf("%s(%sCASE WHEN %s THEN %s END)", function, distinct, spec.filter.sql, value)
+		c.args = spec.filter.args
+	}
+	generated := spec.alias == ""
+	if generated {
+		spec.alias = qb.generateAlias(function, column)
+	}
+	safeAlias, err := EscapeIdentifier(qb.dbType, spec.alias)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	if qb.aggregates == nil {
+		qb.aggregates = make(map[string]clause)
+	}
+	qb.aggregates[spec.alias] = c
+	if generated {
+		c.alias = safeAlias
+	} else {
+		c.sql += " AS " + safeAlias
+	}
+	qb.addColumn(c)
+	return qb
+}
+
+/*
+addColumn
+
+@ c: Column expression for the SELECT list
+@ Return: None. Replaces the default "*" when no columns were given to the constructor
+*/
+func (qb *QueryBuilder) addColumn(c clause) {
+	if qb.implicitStar {
+		qb.columns = nil
+		qb.implicitStar = false
+	}
+	qb.columns = append(qb.columns, c)
+}
+
+/*
+SelectAllowed
+
+@ requested: Field names from user input, e.g. a "fields" query parameter; empty selects every allowed field
+@ allowed: Map of field names to columns; fields whose column differs from the field name are aliased to it
+@ required: Columns always selected, e.g. the primary key
+@ Return: *QueryBuilder with the requested fields added to the SELECT list; unknown fields are dropped
+*/
+func (qb *QueryBuilder) SelectAllowed(requested []string, allowed map[string]string, required ...string) *QueryBuilder {
+	return qb.selectAllowed(requested, allowed, required, false)
+}
+
+/*
+SelectAllowedStrict
+
+@ requested: Field names from user input
+@ allowed: Map of field names to columns
+@ required: Columns always selected
+@ Return: *QueryBuilder with the requested fields added, or an error recorded if a field is not allowed
+*/
+func (qb *QueryBuilder) SelectAllowedStrict(requested []string, allowed map[string]string, required ...string) *QueryBuilder {
+	return qb.selectAllowed(requested, allowed, required, true)
+}
+
+func (qb *QueryBuilder) selectAllowed(requested []string, allowed map[string]string, required []string, strict bool) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if len(requested) == 0 {
+		requested = slices.Sorted(maps.Keys(allowed))
+	}
+	seen := map[string]bool{}
+	add := func(column, alias string) error {
+		if seen[column] {
+			return nil
+		}
+		seen[column] = true
+		safeCol, err := EscapeIdentifier(qb.dbType, column)
+		if err != nil {
+			return err
+		}
+		if alias != "" && alias != column[strings.LastIndex(column, ".")+1:] {
+			safeAlias, err := EscapeIdentifier(qb.dbType, alias)
+			if err != nil {
+				return err
+			}
+			safeCol += " AS " + safeAlias
+		}
+		qb.addColumn(clause{sql: safeCol})
+		return nil
+	}
+	for _, field := range requested {
+		column, ok := allowed[field]
+		if !ok {
+			if strict {
+				qb.err = fmt.Errorf("select field not allowed: %s", field)
+				return qb
+			}
+			continue
+		}
+		if err := add(column, field); err != nil {
+			qb.err = err
+			return qb
+		}
+	}
+	for _, column := range required {
+		if err := add(column, ""); err != nil {
+			qb.err = err
+			return qb
+		}
+	}
+	return qb
+}
+
+/*
+SelectRaw
+
+@ exprs: Raw expressions to add to the SELECT list, e.g. Raw("lower(name) AS name")
+@ Return: *QueryBuilder with expressions added verbatim
+*/
+func (qb *QueryBuilder) SelectRaw(exprs ...RawExpr) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	for _, expr := range exprs {
+		qb.addColumn(clause{sql: expr.sql})
+	}
+	return qb
+}
+
+/*
+SelectExpr
+
+@ exprs: Expressions to add to the SELECT list, e.g. built with Expr or Case
+@ Return: *QueryBuilder with expressions added
+*/
+func (qb *QueryBuilder) SelectExpr(exprs ...Expression) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	for _, expr := range exprs {
+		c, err := expr.toClause(qb.dbType, true)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		qb.addColumn(c)
+	}
 	return qb
 }
 
@@ -164,6 +504,7 @@ LeftJoin
 @ Return: *QueryBuilder with LEFT JOIN added
 */
 func (qb *QueryBuilder) LeftJoin(joinTable, onCondition string) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
@@ -172,7 +513,7 @@ func (qb *QueryBuilder) LeftJoin(joinTable, onCondition string) *QueryBuilder {
 		qb.err = err
 		return qb
 	}
-	qb.joins = append(qb.joins, fmt.Sprintf("LEFT JOIN %s ON %s", safeTable, onCondition))
+	qb.joins = append(qb.joins, joinClause{kind: "LEFT", table: joinTable, safeTable: safeTable, on: onCondition})
 	return qb
 }
 
@@ -184,6 +525,7 @@ InnerJoin
 @ Return: *QueryBuilder with INNER JOIN added
 */
 func (qb *QueryBuilder) InnerJoin(joinTable, onCondition string) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
@@ -192,7 +534,7 @@ func (qb *QueryBuilder) InnerJoin(joinTable, onCondition string) *QueryBuilder {
 		qb.err = err
 		return qb
 	}
-	qb.joins = append(qb.joins, fmt.Sprintf("INNER JOIN %s ON %s", safeTable, onCondition))
+	qb.joins = append(qb.joins, joinClause{kind: "INNER", table: joinTable, safeTable: safeTable, on: onCondition})
 	return qb
 }
 
@@ -204,6 +546,7 @@ RightJoin
 @ Return: *QueryBuilder with RIGHT JOIN added
 */
 func (qb *QueryBuilder) RightJoin(joinTable, onCondition string) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
@@ -212,24 +555,116 @@ func (qb *QueryBuilder) RightJoin(joinTable, onCondition string) *QueryBuilder {
 		qb.err = err
 		return qb
 	}
-	qb.joins = append(qb.joins, fmt.Sprintf("RIGHT JOIN %s ON %s", safeTable, onCondition))
+	qb.joins = append(qb.joins, joinClause{kind: "RIGHT", table: joinTable, safeTable: safeTable, on: onCondition})
 	return qb
 }
 
+/*
+Using
+
+@ table: Table joined to the DELETE target
+@ onCondition: Condition linking the table to the target
+@ Return: *QueryBuilder deleting only target rows with a match, written as DELETE ... USING (PostgreSQL, CockroachDB)
+or DELETE t FROM t INNER JOIN ... (MariaDB, Mysql, MSSQL)
+*/
+func (qb *QueryBuilder) Using(table, onCondition string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "DELETE" {
+		qb.err = fmt.Errorf("Using() can only be used with DELETE operation")
+		return qb
+	}
+	features := dialectOf(qb.dbType).Features()
+	if !features.DeleteUsing && !features.DeleteJoin {
+		qb.err = fmt.Errorf("Using() is not supported for db type: %v", qb.dbType)
+		return qb
+	}
+	return qb.InnerJoin(table, onCondition)
+}
+
 /*
 Where
 
-@ condition: Condition string with placeholders
-@ args: Query parameters
+@ condition: Condition string with "?" or :name placeholders
+@ args: Query parameters; NamedArg values or a single map[string]interface{} for :name placeholders
 @ Return: *QueryBuilder with WHERE clause added
 */
 func (qb *QueryBuilder) Where(condition string, args ...interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	condition, args, err := bindNamed(condition, args)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	if err := checkPlaceholderCount("Where", condition, args); err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.conditions = append(qb.conditions, clause{sql: condition, args: args})
+	return qb
+}
+
+/*
+WhereIf
+
+@ cond: Whether to add the condition
+@ condition: Condition string with placeholders
+@ args: Query parameters
+@ Return: *QueryBuilder with WHERE clause added when cond is true
+*/
+func (qb *QueryBuilder) WhereIf(cond bool, condition string, args ...interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if !cond {
+		return qb
+	}
+	return qb.Where(condition, args...)
+}
+
+/*
+WhereRaw
+
+@ condition: Condition written with the dialect's own placeholders ("$1", "$2", ... on PostgreSQL, "@p1", "@p2", ... on MSSQL,
+":1", ":2", ... on Oracle,
+"?" on MariaDB/Mysql and SQLite);
+"?" is never rewritten, so operators such as jsonb "?" and "?|" are kept as-is
+@ args: Query parameters; numbered placeholders refer to args[n-1] and are renumbered when the query is built
+@ Return: *QueryBuilder with the condition added
+*/
+func (qb *QueryBuilder) WhereRaw(condition string, args ...interface{}) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
-	updatedCondition := ReplacePlaceholders(qb.dbType, condition, len(qb.args)+1)
-	qb.conditions = append(qb.conditions, updatedCondition)
-	qb.args = append(qb.args, args...)
+	if re := dialectOf(qb.dbType).native; re != nil {
+		for _, m := range re.FindAllStringSubmatch(condition, -1) {
+			if n, _ := strconv.Atoi(m[1]); n < 1 || n > len(args) {
+				qb.err = fmt.Errorf("WhereRaw() placeholder %s has no matching argument", m[0])
+				return qb
+			}
+		}
+	}
+	qb.conditions = append(qb.conditions, clause{sql: condition, args: args, native: true})
+	return qb
+}
+
+/*
+If
+
+@ cond: Whether to apply fn
+@ fn: Function modifying the builder
+@ Return: *QueryBuilder, modified by fn when cond is true
+*/
+func (qb *QueryBuilder) If(cond bool, fn func(*QueryBuilder)) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil || !cond {
+		return qb
+	}
+	fn(qb)
 	return qb
 }
 
@@ -241,6 +676,7 @@ WhereIn
 @ Return: *QueryBuilder with IN clause added
 */
 func (qb *QueryBuilder) WhereIn(column string, values []interface{}) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
@@ -249,354 +685,1854 @@ func (qb *QueryBuilder) WhereIn(column string, values []interface{}) *QueryBuild
 		qb.err = err
 		return qb
 	}
-	placeholders := GeneratePlaceholders(qb.dbType, len(qb.args)+1, len(values))
-	qb.conditions = append(qb.conditions, fmt.Sprintf("%s IN (%s)", safeCol, placeholders))
-	qb.args = append(qb.args, values...)
+	qb.conditions = append(qb.conditions, clause{
+		sql:    fmt.Sprintf("%s IN (%s)", qb.collateIn(safeCol, values), strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")),
+		args:   values,
+		column: column,
+	})
 	return qb
 }
 
 /*
-WhereBetween
+WhereAny
 
-@ column: Column name for BETWEEN clause
-@ start: Start value
-@ end: End value
-@ Return: *QueryBuilder with BETWEEN clause added
+@ column: Column name compared with the array
+@ values: Slice bound as a single array parameter, e.g. []int64 or pq.Array(ids); pgx passes Go slices natively
+@ Return: *QueryBuilder with "column = ANY(?)" added, keeping one parameter however long the list is
 */
-func (qb *QueryBuilder) WhereBetween(column string, start, end interface{}) *QueryBuilder {
+func (qb *QueryBuilder) WhereAny(column string, values interface{}) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
+	if qb.dbType != PostgreSQL && qb.dbType != CockroachDB {
+		qb.err = fmt.Errorf("WhereAny() is not supported for db type: %v", qb.dbType)
+		return qb
+	}
 	safeCol, err := EscapeIdentifier(qb.dbType, column)
 	if err != nil {
 		qb.err = err
 		return qb
 	}
-	placeholders := GeneratePlaceholders(qb.dbType, len(qb.args)+1, 2)
-	qb.conditions = append(qb.conditions, fmt.Sprintf(" BETWEEN %s AND %s", safeCol, placeholders))
-	qb.args = append(qb.args, start, end)
+	qb.conditions = append(qb.conditions, clause{sql: safeCol + " = ANY(?)", args: []interface{}{values}, column: column})
 	return qb
 }
 
 /*
-GroupBy
+WhereTupleIn
 
-@ columns: Columns for GROUP BY clause
-@ Return: *QueryBuilder with GROUP BY clause added
+@ columns: Columns compared together, e.g. a composite key
+@ rows: Value rows, each with one value per column
+@ Return: *QueryBuilder with "(a, b) IN ((?, ?), ...)" added; expanded to
+"((a = ? AND b = ?) OR ...)" on databases without tuple IN
 */
-func (qb *QueryBuilder) GroupBy(columns ...string) *QueryBuilder {
+func (qb *QueryBuilder) WhereTupleIn(columns []string, rows [][]interface{}) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
-	for _, col := range columns {
-		safeCol, err := EscapeIdentifier(qb.dbType, col)
-		if err != nil {
-			qb.err = err
+	if len(columns) == 0 || len(rows) == 0 {
+		qb.err = fmt.Errorf("WhereTupleIn() requires at least one column and one row")
+		return qb
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	tupleIn := dialectOf(qb.dbType).Features().TupleIn
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	terms := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			qb.err = fmt.Errorf("WhereTupleIn() row %d has %d values, expected %d", i, len(row), len(columns))
 			return qb
 		}
-		qb.groupBy = append(qb.groupBy, safeCol)
+		args = append(args, row...)
+		if tupleIn {
+			terms[i] = tuple
+			continue
+		}
+		ands := make([]string, len(safeCols))
+		for j, safeCol := range safeCols {
+			ands[j] = safeCol + " = ?"
+		}
+		terms[i] = "(" + strings.Join(ands, " AND ") + ")"
 	}
+	sql := fmt.Sprintf("(%s) IN (%s)", strings.Join(safeCols, ", "), strings.Join(terms, ", "))
+	if !tupleIn {
+		sql = "(" + strings.Join(terms, " OR ") + ")"
+	}
+	qb.conditions = append(qb.conditions, clause{sql: sql, args: args})
 	return qb
 }
 
 /*
-Having
+WhereBetween
 
-@ condition: HAVING clause condition with placeholders
-@ args: Query parameters for HAVING clause
-@ Return: *QueryBuilder with HAVING clause added
+@ column: Column name for BETWEEN clause
+@ start: Start value
+@ end: End value
+@ Return: *QueryBuilder with BETWEEN clause added
 */
-func (qb *QueryBuilder) Having(condition string, args ...interface{}) *QueryBuilder {
+func (qb *QueryBuilder) WhereBetween(column string, start, end interface{}) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
-	updatedCondition := ReplacePlaceholders(qb.dbType, condition, len(qb.args)+1)
-	qb.having = append(qb.having, updatedCondition)
-	qb.args = append(qb.args, args...)
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.conditions = append(qb.conditions, clause{
+		sql:    fmt.Sprintf("%s BETWEEN ? AND ?", safeCol),
+		args:   []interface{}{start, end},
+		column: column,
+	})
 	return qb
 }
 
 /*
-OrderBy
+WhereDateRange
 
-@ column: Column name to order by
-@ direction: Order direction ("ASC" or "DESC")
-@ allowedColumns: Map of allowed columns for ordering
-@ Return: *QueryBuilder with ORDER BY clause added
+@ column: Column name holding a timestamp
+@ from: Inclusive start; the zero time leaves the range open at the start
+@ to: Exclusive end; the zero time leaves the range open at the end
+@ Return: *QueryBuilder with the half-open range column >= from AND column < to added
 */
-func (qb *QueryBuilder) OrderBy(column, direction string, allowedColumns map[string]bool) *QueryBuilder {
+func (qb *QueryBuilder) WhereDateRange(column string, from, to time.Time) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.err != nil {
 		return qb
 	}
-	direction = ValidateDirection(direction)
-	if allowedColumns != nil {
-		if _, ok := allowedColumns[column]; !ok {
-			column = "id"
-		}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		qb.err = fmt.Errorf("WhereDateRange() requires from to be before to, got %v and %v", from, to)
+		return qb
 	}
 	safeCol, err := EscapeIdentifier(qb.dbType, column)
 	if err != nil {
 		qb.err = err
 		return qb
 	}
-	qb.orderBy = fmt.Sprintf("%s %s", safeCol, direction)
+	if !from.IsZero() {
+		qb.conditions = append(qb.conditions, clause{sql: safeCol + " >= ?", args: []interface{}{from}, column: column})
+	}
+	if !to.IsZero() {
+		qb.conditions = append(qb.conditions, clause{sql: safeCol + " < ?", args: []interface{}{to}, column: column})
+	}
 	return qb
 }
 
 /*
-Limit
+whereCompare
 
-@ limit: Maximum number of rows to return
-@ Return: *QueryBuilder with LIMIT set
+@ column: Column name to compare
+@ op: Comparison operator
+@ value: Value bound as a parameter
+@ Return: *QueryBuilder with the comparison condition added
 */
-func (qb *QueryBuilder) Limit(limit int) *QueryBuilder {
+func (qb *QueryBuilder) whereCompare(column, op string, value interface{}) *QueryBuilder {
 	if qb.err != nil {
 		return qb
 	}
-	qb.limit = limit
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.conditions = append(qb.conditions, clause{sql: fmt.Sprintf("%s %s ?", qb.collate(safeCol, value), op), args: []interface{}{value}, column: column})
 	return qb
 }
 
 /*
-Offset
+WhereEq
 
-@ offset: Number of rows to skip
-@ Return: *QueryBuilder with OFFSET set
+@ column: Column name
+@ value: Value to compare against; nil matches with IS NULL
+@ Return: *QueryBuilder with column = value added
 */
-func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
-	if qb.err != nil {
-		return qb
+func (qb *QueryBuilder) WhereEq(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if value == nil {
+		return qb.whereNull(column, "IS NULL")
 	}
-	qb.offset = offset
-	return qb
+	return qb.whereCompare(column, "=", value)
 }
 
 /*
-Values
+WhereNotEq
 
-@ data: Map of column names to values for INSERT
-@ Return: *QueryBuilder with data set for INSERT
+@ column: Column name
+@ value: Value to compare against; nil matches with IS NOT NULL
+@ Return: *QueryBuilder with column <> value added
 */
-func (qb *QueryBuilder) Values(data map[string]interface{}) *QueryBuilder {
+func (qb *QueryBuilder) WhereNotEq(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if value == nil {
+		return qb.whereNull(column, "IS NOT NULL")
+	}
+	return qb.whereCompare(column, "<>", value)
+}
+
+/*
+WhereGt
+
+@ column: Column name
+@ value: Value to compare against
+@ Return: *QueryBuilder with column > value added
+*/
+func (qb *QueryBuilder) WhereGt(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	return qb.whereCompare(column, ">", value)
+}
+
+/*
+WhereGte
+
+@ column: Column name
+@ value: Value to compare against
+@ Return: *QueryBuilder with column >= value added
+*/
+func (qb *QueryBuilder) WhereGte(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	return qb.whereCompare(column, ">=", value)
+}
+
+/*
+WhereLt
+
+@ column: Column name
+@ value: Value to compare against
+@ Return: *QueryBuilder with column < value added
+*/
+func (qb *QueryBuilder) WhereLt(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	return qb.whereCompare(column, "<", value)
+}
+
+/*
+WhereLte
+
+@ column: Column name
+@ value: Value to compare against
+@ Return: *QueryBuilder with column <= value added
+*/
+func (qb *QueryBuilder) WhereLte(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	return qb.whereCompare(column, "<=", value)
+}
+
+/*
+WhereLike
+
+@ column: Column name
+@ pattern: LIKE pattern; use EscapeLike for user input
+@ Return: *QueryBuilder with column LIKE pattern added
+*/
+func (qb *QueryBuilder) WhereLike(column string, pattern string) *QueryBuilder {
+	qb = qb.mutable()
+	return qb.whereCompare(column, "LIKE", pattern)
+}
+
+/*
+whereNull
+
+@ column: Column name
+@ predicate: IS NULL or IS NOT NULL
+@ Return: *QueryBuilder with the null check added
+*/
+func (qb *QueryBuilder) whereNull(column, predicate string) *QueryBuilder {
+	if qb.err != nil {
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.conditions = append(qb.conditions, clause{sql: safeCol + " " + predicate})
+	return qb
+}
+
+/*
+WhereMap
+
+@ conditions: Map of column names to values; nil values match with IS NULL
+@ Return: *QueryBuilder with one equality condition per column, in sorted column order
+*/
+func (qb *QueryBuilder) WhereMap(conditions map[string]interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	for _, col := range sortedKeys(conditions) {
+		safeCol, err := EscapeIdentifier(qb.dbType, col)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		val := conditions[col]
+		if val == nil {
+			qb.conditions = append(qb.conditions, clause{sql: safeCol + " IS NULL"})
+			continue
+		}
+		qb.conditions = append(qb.conditions, clause{sql: qb.collate(safeCol, val) + " = ?", args: []interface{}{val}, column: col})
+	}
+	return qb
+}
+
+// Filter is a single column comparison, e.g. parsed from request parameters.
+type Filter struct {
+	Column string      // Column name or aggregate alias
+	Op     string      // Comparison operator: =, !=, <>, <, <=, >, >=, LIKE, NOT LIKE
+	Value  interface{} // Value bound as a parameter
+}
+
+// filterOperators lists the operators accepted in a Filter.
+var filterOperators = map[string]bool{
+	"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "NOT LIKE": true,
+}
+
+/*
+SplitAggregateFilters
+
+@ filters: Mixed filters on plain columns and aggregate aliases
+@ Return: *QueryBuilder with filters on aggregates added to HAVING and the rest to WHERE
+*/
+func (qb *QueryBuilder) SplitAggregateFilters(filters ...Filter) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	for _, f := range filters {
+		op := strings.ToUpper(strings.TrimSpace(f.Op))
+		if !filterOperators[op] {
+			qb.err = fmt.Errorf("unsupported filter operator: %s", f.Op)
+			return qb
+		}
+		if agg, ok := qb.aggregates[f.Column]; ok {
+			args := append(append([]interface{}{}, agg.args...), f.Value)
+			qb.having = append(qb.having, clause{sql: agg.sql + " " + op + " ?", args: args})
+			continue
+		}
+		safeCol, err := EscapeIdentifier(qb.dbType, f.Column)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		qb.conditions = append(qb.conditions, clause{sql: qb.collate(safeCol, f.Value) + " " + op + " ?", args: []interface{}{f.Value}, column: f.Column})
+	}
+	return qb
+}
+
+/*
+GroupBy
+
+@ columns: Columns for GROUP BY clause
+@ Return: *QueryBuilder with GROUP BY clause added
+*/
+func (qb *QueryBuilder) GroupBy(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	for _, col := range columns {
+		safeCol, err := EscapeIdentifier(qb.dbType, col)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		qb.groupBy = append(qb.groupBy, safeCol)
+	}
+	return qb
+}
+
+/*
+GroupByRaw
+
+@ exprs: Raw expressions for GROUP BY clause, e.g. Raw("date_trunc('day', created_at)")
+@ Return: *QueryBuilder with GROUP BY expressions added verbatim
+*/
+func (qb *QueryBuilder) GroupByRaw(exprs ...RawExpr) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	for _, expr := range exprs {
+		qb.groupBy = append(qb.groupBy, expr.sql)
+	}
+	return qb
+}
+
+/*
+GroupByRollup
+
+@ columns: Columns for ROLLUP grouping
+@ Return: *QueryBuilder with GROUP BY ROLLUP (PostgreSQL, MSSQL, Oracle) or GROUP BY ... WITH ROLLUP (MariaDB/Mysql) added
+*/
+func (qb *QueryBuilder) GroupByRollup(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	features := dialectOf(qb.dbType).Features()
+	switch {
+	case features.GroupingSets:
+		qb.groupBy = append(qb.groupBy, fmt.Sprintf("ROLLUP (%s)", strings.Join(safeCols, ", ")))
+	case features.WithRollup:
+		qb.groupBy = append(qb.groupBy, safeCols...)
+		qb.withRollup = true
+	default:
+		qb.err = fmt.Errorf("GroupByRollup() is not supported for db type: %v", qb.dbType)
+	}
+	return qb
+}
+
+/*
+GroupByCube
+
+@ columns: Columns for CUBE grouping
+@ Return: *QueryBuilder with GROUP BY CUBE added (PostgreSQL, MSSQL and Oracle only)
+*/
+func (qb *QueryBuilder) GroupByCube(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if !dialectOf(qb.dbType).Features().GroupingSets {
+		qb.err = fmt.Errorf("GroupByCube() is not supported for db type: %v", qb.dbType)
+		return qb
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.groupBy = append(qb.groupBy, fmt.Sprintf("CUBE (%s)", strings.Join(safeCols, ", ")))
+	return qb
+}
+
+/*
+GroupingSets
+
+@ sets: Column sets to group by; an empty set produces the grand total
+@ Return: *QueryBuilder with GROUP BY GROUPING SETS added (PostgreSQL, MSSQL and Oracle only)
+*/
+func (qb *QueryBuilder) GroupingSets(sets ...[]string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if !dialectOf(qb.dbType).Features().GroupingSets {
+		qb.err = fmt.Errorf("GroupingSets() is not supported for db type: %v", qb.dbType)
+		return qb
+	}
+	rendered := make([]string, len(sets))
+	for i, set := range sets {
+		safeCols, err := escapeIdentifiers(qb.dbType, set)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		rendered[i] = "(" + strings.Join(safeCols, ", ") + ")"
+	}
+	qb.groupBy = append(qb.groupBy, fmt.Sprintf("GROUPING SETS (%s)", strings.Join(rendered, ", ")))
+	return qb
+}
+
+/*
+Having
+
+@ condition: HAVING clause condition with "?" or :name placeholders
+@ args: Query parameters for HAVING clause; NamedArg values or a single map[string]interface{} for :name placeholders
+@ Return: *QueryBuilder with HAVING clause added
+*/
+func (qb *QueryBuilder) Having(condition string, args ...interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	condition, args, err := bindNamed(condition, args)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	if err := checkPlaceholderCount("Having", condition, args); err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.having = append(qb.having, clause{sql: condition, args: args})
+	return qb
+}
+
+/*
+OrderBy
+
+@ column: Column name to order by
+@ direction: Order direction ("ASC" or "DESC")
+@ allowedColumns: Map of allowed columns for ordering
+@ Return: *QueryBuilder with ORDER BY clause added
+*/
+func (qb *QueryBuilder) OrderBy(column, direction string, allowedColumns map[string]bool) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if allowedColumns != nil {
+		if _, ok := allowedColumns[column]; !ok {
+			column = qb.orderByDef
+			if column == "" {
+				column = "id"
+			}
+		}
+	}
+	return qb.setOrderBy(column, direction)
+}
+
+/*
+OrderByStrict
+
+@ column: Column name to order by
+@ direction: Order direction ("ASC" or "DESC")
+@ allowedColumns: Map of allowed columns for ordering
+@ Return: *QueryBuilder with ORDER BY clause added, or an error recorded if the column is not allowed
+*/
+func (qb *QueryBuilder) OrderByStrict(column, direction string, allowedColumns map[string]bool) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if allowedColumns != nil {
+		if _, ok := allowedColumns[column]; !ok {
+			qb.err = fmt.Errorf("order by column not allowed: %s", column)
+			return qb
+		}
+	}
+	return qb.setOrderBy(column, direction)
+}
+
+// SortField is one column of a stored sort preference.
+type SortField struct {
+	Column    string `json:"column"`
+	Direction string `json:"direction"`       // "ASC" or "DESC"
+	Nulls     string `json:"nulls,omitempty"` // "FIRST", "LAST" or "" for the database default
+}
+
+/*
+OrderBySpec
+
+@ fields: Sort fields, most significant first
+@ allowedColumns: Map of allowed columns; nil allows any column
+@ Return: *QueryBuilder with one ORDER BY term per field added; NULLS FIRST/LAST is emulated
+with an IS NULL sort key on databases without it
+*/
+func (qb *QueryBuilder) OrderBySpec(fields []SortField, allowedColumns map[string]bool) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	nullsOrdering := dialectOf(qb.dbType).Features().NullsOrdering
+	for _, f := range fields {
+		if allowedColumns != nil && !allowedColumns[f.Column] {
+			qb.err = fmt.Errorf("order by column not allowed: %s", f.Column)
+			return qb
+		}
+		nulls := strings.ToUpper(f.Nulls)
+		if nulls != "" && nulls != "FIRST" && nulls != "LAST" {
+			qb.err = fmt.Errorf("invalid nulls placement %q for column %s", f.Nulls, f.Column)
+			return qb
+		}
+		safeCol, err := EscapeIdentifier(qb.dbType, f.Column)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		term := qb.collateOrder(safeCol) + " " + ValidateDirection(f.Direction)
+		switch {
+		case nulls == "":
+		case nullsOrdering:
+			term += " NULLS " + nulls
+		case nulls == "FIRST":
+			term = fmt.Sprintf("CASE WHEN %s IS NULL THEN 0 ELSE 1 END, %s", safeCol, term)
+		default:
+			term = fmt.Sprintf("CASE WHEN %s IS NULL THEN 1 ELSE 0 END, %s", safeCol, term)
+		}
+		qb.orderBy = append(qb.orderBy, clause{sql: term})
+	}
+	return qb
+}
+
+/*
+OrderByExpression
+
+@ expr: Expression to order by, e.g. built with Case
+@ direction: Order direction ("ASC" or "DESC")
+@ Return: *QueryBuilder with ORDER BY expression added
+*/
+func (qb *QueryBuilder) OrderByExpression(expr Expression, direction string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	c, err := expr.toClause(qb.dbType, false)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	c.sql += " " + ValidateDirection(direction)
+	qb.orderBy = append(qb.orderBy, c)
+	return qb
+}
+
+/*
+OrderByRaw
+
+@ expr: Raw expression to order by, e.g. Raw("lower(name)")
+@ direction: Order direction ("ASC" or "DESC")
+@ Return: *QueryBuilder with ORDER BY expression added verbatim
+*/
+func (qb *QueryBuilder) OrderByRaw(expr RawExpr, direction string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.orderBy = append(qb.orderBy, clause{sql: expr.sql + " " + ValidateDirection(direction)})
+	return qb
+}
+
+/*
+DefaultOrderColumn
+
+@ column: Column OrderBy falls back to when the requested column is not allowed (default "id")
+@ Return: *QueryBuilder with the fallback order column set
+*/
+func (qb *QueryBuilder) DefaultOrderColumn(column string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.orderByDef = column
+	return qb
+}
+
+func (qb *QueryBuilder) setOrderBy(column, direction string) *QueryBuilder {
+	direction = ValidateDirection(direction)
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.orderBy = append(qb.orderBy, clause{sql: fmt.Sprintf("%s %s", qb.collateOrder(safeCol), direction)})
+	return qb
+}
+
+/*
+OrderByExpr
+
+@ expr: Sort expression with placeholders, e.g. "CASE WHEN status = ? THEN 0 ELSE 1 END"
+@ args: Query parameters for the expression
+@ Return: *QueryBuilder with ORDER BY expression added
+*/
+func (qb *QueryBuilder) OrderByExpr(expr string, args ...interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.orderBy = append(qb.orderBy, clause{sql: expr, args: args})
+	return qb
+}
+
+/*
+Limit
+
+@ limit: Maximum number of rows to return
+@ Return: *QueryBuilder with LIMIT set
+*/
+func (qb *QueryBuilder) Limit(limit int) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.limit = limit
+	return qb
+}
+
+/*
+Offset
+
+@ offset: Number of rows to skip
+@ Return: *QueryBuilder with OFFSET set
+*/
+func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.offset = offset
+	return qb
+}
+
+/*
+Values
+
+@ data: Map of column names to values for INSERT; Expression values are written as SQL
+@ Return: *QueryBuilder with data set for INSERT
+*/
+func (qb *QueryBuilder) Values(data map[string]interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("Values() can only be used with INSERT operation")
+		return qb
+	}
+	qb.data = data
+	return qb
+}
+
+/*
+Value
+
+@ column: Column to insert
+@ value: Value bound as a parameter, or an Expression such as Expr("now()") written as SQL
+@ Return: *QueryBuilder with the column added to the row set by Values
+*/
+func (qb *QueryBuilder) Value(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("Value() can only be used with INSERT operation")
+		return qb
+	}
+	qb.setData(column, value)
+	return qb
+}
+
+/*
+InsertColumns
+
+@ columns: Column names for rows added with ValuesRow
+@ Return: *QueryBuilder with insert columns set
+*/
+func (qb *QueryBuilder) InsertColumns(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("InsertColumns() can only be used with INSERT operation")
+		return qb
+	}
+	qb.insertCols = columns
+	return qb
+}
+
+/*
+ValuesRow
+
+@ values: Values for one row, in the order given to InsertColumns
+@ Return: *QueryBuilder with the row added
+*/
+func (qb *QueryBuilder) ValuesRow(values ...interface{}) *QueryBuilder {
+	qb = qb.mutable()
 	if qb.op != "INSERT" {
-		qb.err = fmt.Errorf("Values() can only be used with INSERT operation")
+		qb.err = fmt.Errorf("ValuesRow() can only be used with INSERT operation")
+		return qb
+	}
+	qb.rows = append(qb.rows, values)
+	return qb
+}
+
+/*
+Set
+
+@ data: Map of column names to values for UPDATE; Expression values are written as SQL
+@ Return: *QueryBuilder with data set for UPDATE
+*/
+func (qb *QueryBuilder) Set(data map[string]interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "UPDATE" {
+		qb.err = fmt.Errorf("Set() can only be used with UPDATE operation")
+		return qb
+	}
+	qb.data = data
+	return qb
+}
+
+/*
+SetValue
+
+@ column: Column to update
+@ value: Value bound as a parameter, or an Expression such as Expr("counter + ?", 1) written as SQL
+@ Return: *QueryBuilder with the column added to the data set by Set
+*/
+func (qb *QueryBuilder) SetValue(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "UPDATE" {
+		qb.err = fmt.Errorf("SetValue() can only be used with UPDATE operation")
+		return qb
+	}
+	qb.setData(column, value)
+	return qb
+}
+
+/*
+Increment
+
+@ column: Column to increase
+@ amount: Amount added, bound as a parameter
+@ Return: *QueryBuilder setting column = column + amount
+*/
+func (qb *QueryBuilder) Increment(column string, amount interface{}) *QueryBuilder {
+	return qb.adjust("Increment", column, "+", amount)
+}
+
+/*
+Decrement
+
+@ column: Column to decrease
+@ amount: Amount subtracted, bound as a parameter
+@ Return: *QueryBuilder setting column = column - amount
+*/
+func (qb *QueryBuilder) Decrement(column string, amount interface{}) *QueryBuilder {
+	return qb.adjust("Decrement", column, "-", amount)
+}
+
+func (qb *QueryBuilder) adjust(method, column, op string, amount interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "UPDATE" {
+		qb.err = fmt.Errorf("%s() can only be used with UPDATE operation", method)
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.setAdjustment(column, Expr(safeCol+" "+op+" ?", amount))
+	return qb
+}
+
+/*
+setAdjustment
+
+@ column: Column to write
+@ expr: Expression computing the new value from the old one
+@ Return: None. Adjustments are kept apart from the data so a later Set does not drop them
+*/
+func (qb *QueryBuilder) setAdjustment(column string, expr Expression) {
+	adjustments := make(map[string]Expression, len(qb.adjustments)+1)
+	maps.Copy(adjustments, qb.adjustments)
+	adjustments[column] = expr
+	qb.adjustments = adjustments
+}
+
+/*
+setData
+
+@ column: Column to write
+@ value: Value of the column
+@ Return: None. The data map is copied first, since Values and Set keep the caller's map
+*/
+func (qb *QueryBuilder) setData(column string, value interface{}) {
+	data := make(map[string]interface{}, len(qb.data)+1)
+	maps.Copy(data, qb.data)
+	data[column] = value
+	qb.data = data
+}
+
+/*
+Returning
+
+@ clause: RETURNING clause string (for PostgreSQL and SQLite); on MSSQL plain column names are
+turned into an OUTPUT INSERTED.column clause
+@ Return: *QueryBuilder with RETURNING clause set
+*/
+func (qb *QueryBuilder) Returning(clause string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("Returning() can only be used with INSERT operation")
+		return qb
+	}
+	qb.returning = clause
+	return qb
+}
+
+/*
+WithRegistry
+
+@ registry: Schema registry used to validate the query
+@ Return: *QueryBuilder with the registry attached
+*/
+func (qb *QueryBuilder) WithRegistry(registry *SchemaRegistry) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.registry = registry
+	return qb
+}
+
+/*
+Strict
+
+@ Return: *QueryBuilder that fails to build when it has warnings
+*/
+func (qb *QueryBuilder) Strict() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.strict = true
+	return qb
+}
+
+/*
+Clone
+
+@ Return: Independent copy of the builder; changes to either one do not affect the other.
+The schema registry is shared
+*/
+func (qb *QueryBuilder) Clone() *QueryBuilder {
+	c := *qb
+	c.columns = slices.Clone(qb.columns)
+	c.joins = slices.Clone(qb.joins)
+	c.conditions = slices.Clone(qb.conditions)
+	c.groupBy = slices.Clone(qb.groupBy)
+	c.having = slices.Clone(qb.having)
+	c.orderBy = slices.Clone(qb.orderBy)
+	c.data = maps.Clone(qb.data)
+	c.adjustments = maps.Clone(qb.adjustments)
+	c.insertCols = slices.Clone(qb.insertCols)
+	if qb.rows != nil {
+		c.rows = make([][]interface{}, len(qb.rows))
+		for i, row := range qb.rows {
+			c.rows[i] = slices.Clone(row)
+		}
+	}
+	if qb.fromSub != nil {
+		fromSub := *qb.fromSub
+		c.fromSub = &fromSub
+	}
+	if qb.insertSelect != nil {
+		insertSelect := *qb.insertSelect
+		c.insertSelect = &insertSelect
+	}
+	c.aliases = slices.Clone(qb.aliases)
+	c.aggregates = maps.Clone(qb.aggregates)
+	c.defaults = maps.Clone(qb.defaults)
+	c.protected = maps.Clone(qb.protected)
+	c.hooks = slices.Clone(qb.hooks)
+	c.scopesOff = maps.Clone(qb.scopesOff)
+	if qb.conflict != nil {
+		conflict := *qb.conflict
+		conflict.columns = slices.Clone(qb.conflict.columns)
+		conflict.update = slices.Clone(qb.conflict.update)
+		c.conflict = &conflict
+	}
+	if qb.page != nil {
+		page := *qb.page
+		c.page = &page
+	}
+	if qb.limitBy != nil {
+		limitBy := *qb.limitBy
+		c.limitBy = &limitBy
+	}
+	c.frozen = false
+	return &c
+}
+
+/*
+Freeze
+
+@ Return: The builder, made read-only. Later chained calls leave it untouched and return a modified copy,
+so a frozen base query can be shared between goroutines. Freeze itself must be called before sharing
+*/
+func (qb *QueryBuilder) Freeze() *QueryBuilder {
+	qb.frozen = true
+	return qb
+}
+
+/*
+mutable
+
+@ Return: The builder itself, or a copy of it if it is frozen
+*/
+func (qb *QueryBuilder) mutable() *QueryBuilder {
+	if !qb.frozen {
 		return qb
 	}
-	qb.data = data
-	return qb
+	return qb.Clone()
+}
+
+/*
+Warnings
+
+@ Return: Problems found in the query that do not prevent it from being built
+*/
+func (qb *QueryBuilder) Warnings() []string {
+	var warnings []string
+	if w := qb.checkPartitionFilter(); w != "" {
+		warnings = append(warnings, w)
+	}
+	if w := qb.checkConflictTarget(); w != "" {
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+// Sqlizer is implemented by builders that render to a query and its arguments, as in squirrel and other
+// libraries taking a built query, e.g. sqlx.
+type Sqlizer interface {
+	ToSql() (string, []interface{}, error)
+}
+
+var (
+	_ Sqlizer = (*QueryBuilder)(nil)
+	_ Sqlizer = (*UnionBuilder)(nil)
+	_ Sqlizer = Batch{}
+)
+
+/*
+ToSql
+
+@ Return: Same as Build, so *QueryBuilder satisfies Sqlizer
+*/
+func (qb *QueryBuilder) ToSql() (string, []interface{}, error) {
+	return qb.Build()
+}
+
+/*
+BuildTo
+
+@ w: Destination of the query text, e.g. a script being assembled or a log file
+@ Return: Arguments slice, and error from building or writing. Nothing is written if the build fails.
+Writers implementing io.StringWriter receive the query without another copy
+*/
+func (qb *QueryBuilder) BuildTo(w io.Writer) ([]interface{}, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, query); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+/*
+BuildWithOffset
+
+@ startIdx: Index of the first placeholder, e.g. N+1 when the query is appended to SQL already using $1..$N
+@ Return: Query string, arguments slice, and error if any. Dialects using "?" are unaffected
+*/
+func (qb *QueryBuilder) BuildWithOffset(startIdx int) (string, []interface{}, error) {
+	if startIdx < 1 {
+		return "", nil, fmt.Errorf("BuildWithOffset() requires a start index of at least 1, got %d", startIdx)
+	}
+	shifted := *qb
+	shifted.placeholderStart = startIdx
+	return shifted.Build()
+}
+
+/*
+Build
+
+@ Return: Final query string, arguments slice, and error if any
+*/
+func (qb *QueryBuilder) Build() (string, []interface{}, error) {
+	if qb.err != nil {
+		return "", nil, qb.err
+	}
+	if qb = qb.withScopes(); qb.err != nil {
+		return "", nil, qb.err
+	}
+	for _, h := range qb.hooks {
+		if err := h.BeforeBuild(qb); err != nil {
+			return "", nil, err
+		}
+	}
+	if qb.strict {
+		if warnings := qb.Warnings(); len(warnings) > 0 {
+			return "", nil, fmt.Errorf("strict mode: %s", strings.Join(warnings, "; "))
+		}
+	}
+	if err := qb.checkProtected(); err != nil {
+		return "", nil, err
+	}
+	if qb.shape != nil && !qb.namedOutput && qb.argColumns == nil && qb.argSpans == nil && qb.placeholderStart == 0 {
+		return qb.buildShape()
+	}
+	return qb.buildStatement()
+}
+
+/*
+buildStatement
+
+@ Return: Query string and args of the builder's operation
+*/
+func (qb *QueryBuilder) buildStatement() (string, []interface{}, error) {
+	var query string
+	var args []interface{}
+	var err error
+	switch qb.op {
+	case "SELECT":
+		query, args, err = qb.buildSelect()
+	case "INSERT":
+		query, args, err = qb.buildInsert()
+	case "UPDATE":
+		query, args, err = qb.buildUpdate()
+	case "DELETE":
+		query, args, err = qb.buildDelete()
+	default:
+		return "", nil, fmt.Errorf("unsupported operation: %s", qb.op)
+	}
+	if err == nil && qb.strictArgs {
+		err = checkArgs(args)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
+
+func (qb *QueryBuilder) buildSelect() (string, []interface{}, error) {
+	w := qb.newWriter()
+	qb.writeSelect(w)
+	return w.result()
+}
+
+func (qb *QueryBuilder) writeSelect(w *queryWriter) {
+	w.write("SELECT ")
+	if qb.distinct {
+		w.write("DISTINCT ")
+	}
+	limit := qb.limit
+	if qb.maxLimit > 0 && (limit == 0 || limit > qb.maxLimit) {
+		limit = qb.maxLimit
+	}
+	head, tail := w.dialect.Limit(LimitSpec{Limit: limit, Offset: qb.offset, Ordered: len(qb.orderBy) > 0})
+	w.writeFragment(head)
+	for i, c := range qb.columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.writeClause(c)
+		if w.derived && c.alias != "" {
+			w.write(" AS " + c.alias)
+		}
+	}
+	w.write(" FROM ")
+	if qb.fromSub != nil {
+		w.writeClause(*qb.fromSub)
+		w.write(tableAlias(qb.dbType, qb.table))
+	} else {
+		w.write(qb.table + qb.indexHint)
+	}
+	if qb.final {
+		w.write(" FINAL")
+	}
+	if qb.sample != "" {
+		w.write(" SAMPLE " + qb.sample)
+	}
+	qb.writeJoins(w)
+	if qb.asOfSystemTime != "" {
+		w.write(" AS OF SYSTEM TIME " + qb.asOfSystemTime)
+	}
+	conditions := qb.selectConditions()
+	if len(conditions) > 0 {
+		w.write(" WHERE ")
+		w.writeClauses(conditions, " AND ")
+	}
+	if len(qb.groupBy) > 0 {
+		w.write(" GROUP BY " + strings.Join(qb.groupBy, ", "))
+		if qb.withRollup {
+			w.write(" WITH ROLLUP")
+		}
+	}
+	if len(qb.having) > 0 {
+		w.write(" HAVING ")
+		w.writeClauses(qb.having, " AND ")
+	}
+	if len(qb.orderBy) > 0 {
+		w.write(" ORDER BY ")
+		w.writeClauses(qb.orderBy, ", ")
+	}
+	if qb.limitBy != nil {
+		w.write(" ")
+		w.writeClause(*qb.limitBy)
+	}
+	w.writeFragment(tail)
+}
+
+/*
+writeJoins
+
+@ w: Writer for the statement
+@ Return: None
+*/
+func (qb *QueryBuilder) writeJoins(w *queryWriter) {
+	for _, j := range qb.joins {
+		w.write(" ")
+		w.write(j.kind)
+		w.write(" JOIN ")
+		if len(j.args) > 0 {
+			w.writeClause(clause{sql: j.safeTable, args: j.args})
+		} else {
+			w.write(j.safeTable)
+		}
+		w.write(" ON ")
+		w.write(j.on)
+	}
+}
+
+func (qb *QueryBuilder) buildInsert() (string, []interface{}, error) {
+	var cols []string
+	var rows [][]interface{}
+	var err error
+	if qb.insertSelect != nil {
+		if qb.data != nil || qb.rows != nil {
+			return "", nil, fmt.Errorf("InsertFromSelect() cannot be combined with Values() or ValuesRow()")
+		}
+		cols = qb.insertCols
+	} else if cols, rows, err = qb.insertRows(); err != nil {
+		return "", nil, err
+	}
+	if err := qb.checkColumns(cols); err != nil {
+		return "", nil, err
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, cols)
+	if err != nil {
+		return "", nil, err
+	}
+	w := qb.newWriter()
+	var returningHead, returningTail string
+	if qb.returning != "" {
+		returningHead, returningTail, err = w.dialect.Returning(qb.returning)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	verb, err := qb.insertVerb()
+	if err != nil {
+		return "", nil, err
+	}
+	w.write(fmt.Sprintf("%s INTO %s (%s) ", verb, qb.table, strings.Join(safeCols, ", ")))
+	w.write(returningHead)
+	if qb.insertSelect != nil {
+		w.writeClause(*qb.insertSelect)
+	} else {
+		w.write("VALUES ")
+	}
+	for i, row := range rows {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write("(")
+		for j, val := range row {
+			if j > 0 {
+				w.write(", ")
+			}
+			if expr, ok := val.(Expression); ok {
+				c, err := expr.toClause(qb.dbType, false)
+				if err != nil {
+					return "", nil, err
+				}
+				w.writeClause(c)
+				continue
+			}
+			w.bindColumn(val, cols[j])
+		}
+		w.write(")")
+	}
+	if err := qb.writeConflict(w, cols); err != nil {
+		return "", nil, err
+	}
+	w.write(returningTail)
+	if err := qb.writeReturningNothing(w); err != nil {
+		return "", nil, err
+	}
+	return w.result()
+}
+
+/*
+insertRows
+
+@ Return: Insert columns and rows from either Values or InsertColumns/ValuesRow, completed with the table defaults,
+and error if they are missing or inconsistent
+*/
+func (qb *QueryBuilder) insertRows() ([]string, [][]interface{}, error) {
+	cols, rows, err := qb.explicitRows()
+	if err == nil && qb.idempotency != nil {
+		cols, rows, err = qb.withIdempotencyKey(cols, rows)
+	}
+	if err == nil && qb.timestamps != nil {
+		cols, rows = qb.withCreatedAt(cols, rows)
+	}
+	if err != nil || len(qb.defaults) == 0 {
+		return cols, rows, err
+	}
+	set := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		set[col] = true
+	}
+	var missing []string
+	for _, col := range sortedKeys(qb.defaults) {
+		if !set[col] {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) == 0 {
+		return cols, rows, nil
+	}
+	allCols := append(slices.Clone(cols), missing...)
+	allRows := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		allRows[i] = slices.Clone(row)
+		for _, col := range missing {
+			val := qb.defaults[col]
+			if fn, ok := val.(func() interface{}); ok {
+				val = fn()
+			}
+			allRows[i] = append(allRows[i], val)
+		}
+	}
+	return allCols, allRows, nil
 }
 
 /*
-Set
+explicitRows
 
-@ data: Map of column names to values for UPDATE
-@ Return: *QueryBuilder with data set for UPDATE
+@ Return: Insert columns and rows as set on the builder, and error if they are missing or inconsistent
 */
-func (qb *QueryBuilder) Set(data map[string]interface{}) *QueryBuilder {
-	if qb.op != "UPDATE" {
-		qb.err = fmt.Errorf("Set() can only be used with UPDATE operation")
-		return qb
+func (qb *QueryBuilder) explicitRows() ([]string, [][]interface{}, error) {
+	if qb.insertCols == nil && qb.rows == nil {
+		if qb.data == nil {
+			return nil, nil, fmt.Errorf("no data provided for INSERT")
+		}
+		cols := sortedKeys(qb.data)
+		row := make([]interface{}, len(cols))
+		for i, col := range cols {
+			row[i] = qb.data[col]
+		}
+		return cols, [][]interface{}{row}, nil
 	}
-	qb.data = data
-	return qb
+	if qb.data != nil {
+		return nil, nil, fmt.Errorf("Values() cannot be combined with InsertColumns() and ValuesRow()")
+	}
+	if len(qb.insertCols) == 0 {
+		return nil, nil, fmt.Errorf("no columns provided for INSERT")
+	}
+	if len(qb.rows) == 0 {
+		return nil, nil, fmt.Errorf("no data provided for INSERT")
+	}
+	for i, row := range qb.rows {
+		if len(row) != len(qb.insertCols) {
+			return nil, nil, fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(qb.insertCols))
+		}
+	}
+	return qb.insertCols, qb.rows, nil
 }
 
-/*
-Returning
+func (qb *QueryBuilder) buildUpdate() (string, []interface{}, error) {
+	if qb.data == nil && qb.adjustments == nil {
+		return "", nil, fmt.Errorf("no data provided for UPDATE")
+	}
+	data := qb.updateData()
+	w := qb.newWriter()
+	w.write("UPDATE " + qb.table + qb.indexHint + " SET ")
+	for i, col := range sortedKeys(data) {
+		safeCol, err := EscapeIdentifier(qb.dbType, col)
+		if err != nil {
+			return "", nil, err
+		}
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(safeCol + " = ")
+		if expr, ok := data[col].(Expression); ok {
+			c, err := expr.toClause(qb.dbType, false)
+			if err != nil {
+				return "", nil, err
+			}
+			w.writeClause(c)
+			continue
+		}
+		w.bindColumn(data[col], col)
+	}
+	if len(qb.conditions) > 0 {
+		w.write(" WHERE ")
+		w.writeClauses(qb.conditions, " AND ")
+	}
+	if err := qb.writeReturningNothing(w); err != nil {
+		return "", nil, err
+	}
+	return w.result()
+}
 
-@ clause: RETURNING clause string (for PostgreSQL)
-@ Return: *QueryBuilder with RETURNING clause set
-*/
-func (qb *QueryBuilder) Returning(clause string) *QueryBuilder {
-	if qb.op != "INSERT" {
-		qb.err = fmt.Errorf("Returning() can only be used with INSERT operation")
-		return qb
+func (qb *QueryBuilder) buildDelete() (string, []interface{}, error) {
+	if qb.softDelete != "" {
+		return qb.buildSoftDelete()
 	}
-	qb.returning = clause
-	return qb
+	w := qb.newWriter()
+	features := w.dialect.Features()
+	var ons []string
+	switch {
+	case len(qb.joins) == 0:
+		w.write("DELETE FROM " + qb.table + qb.indexHint)
+	case features.DeleteUsing:
+		tables := make([]string, len(qb.joins))
+		for i, j := range qb.joins {
+			if j.kind != "INNER" || len(j.args) > 0 {
+				return "", nil, fmt.Errorf("only Using() joins can be used with DELETE for db type: %v", qb.dbType)
+			}
+			tables[i] = j.safeTable
+			ons = append(ons, j.on)
+		}
+		w.write("DELETE FROM " + qb.table + qb.indexHint + " USING " + strings.Join(tables, ", "))
+	case features.DeleteJoin:
+		w.write("DELETE " + qb.table + " FROM " + qb.table)
+		qb.writeJoins(w)
+	default:
+		return "", nil, fmt.Errorf("joins are not supported with DELETE for db type: %v", qb.dbType)
+	}
+	if len(ons) > 0 || len(qb.conditions) > 0 {
+		w.write(" WHERE " + strings.Join(ons, " AND "))
+		if len(ons) > 0 && len(qb.conditions) > 0 {
+			w.write(" AND ")
+		}
+		w.writeClauses(qb.conditions, " AND ")
+	}
+	if err := qb.writeReturningNothing(w); err != nil {
+		return "", nil, err
+	}
+	return w.result()
+}
+
+// queryWriter accumulates query text and args, numbering placeholders in the order they are written.
+type queryWriter struct {
+	dbType     DBType
+	dialect    *registeredDialect
+	sb         strings.Builder
+	args       []interface{}
+	embed      bool                   // keep "?" placeholders, for queries embedded in another query
+	derived    bool                   // write generated aggregate aliases, for queries used as derived tables
+	named      bool                   // write named placeholders and sql.NamedArg args, for BuildNamed
+	names      map[string]interface{} // values bound per name in named mode
+	positional int                    // positional args named so far in named mode
+	cols       []string               // column of each positional arg, empty if unknown
+	colsOut    *[]string              // receives cols, for ArgsTyped
+	spans      *[]argSpan             // receives the placeholder of each arg as it is written, for DebugSQL
+	argsOnly   bool                   // collect the args without writing SQL, for ShapeCache hits
+	offset     int                    // placeholders already used by the surrounding query, for BuildWithOffset
+	err        error
+}
+
+// argSpan is where a placeholder was written in the query.
+type argSpan struct {
+	arg        int // index of the bound arg
+	start, end int // byte offsets of the placeholder
+}
+
+func newQueryWriter(dbType DBType) *queryWriter {
+	return &queryWriter{dbType: dbType, dialect: dialectOf(dbType)}
 }
 
 /*
-Build
+newWriter
 
-@ Return: Final query string, arguments slice, and error if any
+@ Return: Writer for the builder's statement, in named mode for BuildNamed
 */
-func (qb *QueryBuilder) Build() (string, []interface{}, error) {
-	if qb.err != nil {
-		return "", nil, qb.err
+func (qb *QueryBuilder) newWriter() *queryWriter {
+	w := newQueryWriter(qb.dbType)
+	w.named = qb.namedOutput
+	w.colsOut = qb.argColumns
+	w.spans = qb.argSpans
+	w.argsOnly = qb.argsOnly
+	if qb.placeholderStart > 1 {
+		w.offset = qb.placeholderStart - 1
 	}
-	switch qb.op {
-	case "SELECT":
-		return qb.buildSelect()
-	case "INSERT":
-		return qb.buildInsert()
-	case "UPDATE":
-		return qb.buildUpdate()
-	case "DELETE":
-		return qb.buildDelete()
-	default:
-		return "", nil, fmt.Errorf("unsupported operation: %s", qb.op)
+	size, args := qb.sizeHint()
+	if !qb.argsOnly {
+		w.sb.Grow(size)
 	}
+	w.args = make([]interface{}, 0, args)
+	w.cols = make([]string, 0, args)
+	return w
 }
 
-func (qb *QueryBuilder) buildSelect() (string, []interface{}, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString("SELECT ")
-	if qb.distinct {
-		queryBuilder.WriteString("DISTINCT ")
+/*
+sizeHint
+
+@ Return: Estimated length of the built query and number of args, used to size the writer's buffers once
+*/
+func (qb *QueryBuilder) sizeHint() (size, args int) {
+	size = 32 + len(qb.table) + len(qb.returning)
+	args = 2 // LIMIT and OFFSET
+	for _, clauses := range [][]clause{qb.columns, qb.conditions, qb.having, qb.orderBy} {
+		for _, c := range clauses {
+			size += len(c.sql) + 8 + 3*len(c.args)
+			args += len(c.args)
+		}
 	}
-	queryBuilder.WriteString(strings.Join(qb.columns, ", "))
-	queryBuilder.WriteString(" FROM ")
-	queryBuilder.WriteString(qb.table)
-	if len(qb.joins) > 0 {
-		queryBuilder.WriteString(" " + strings.Join(qb.joins, " "))
+	for _, j := range qb.joins {
+		size += len(j.kind) + len(j.safeTable) + len(j.on) + 12
+		args += len(j.args)
 	}
-	if len(qb.conditions) > 0 {
-		queryBuilder.WriteString(" WHERE " + strings.Join(qb.conditions, " AND "))
+	for _, col := range qb.groupBy {
+		size += len(col) + 2
 	}
-	if len(qb.groupBy) > 0 {
-		queryBuilder.WriteString(" GROUP BY " + strings.Join(qb.groupBy, ", "))
+	for _, src := range []*clause{qb.fromSub, qb.insertSelect} {
+		if src != nil {
+			size += len(src.sql) + 2
+			args += len(src.args)
+		}
 	}
-	if len(qb.having) > 0 {
-		queryBuilder.WriteString(" HAVING " + strings.Join(qb.having, " AND "))
+	size += 24 * (len(qb.data) + len(qb.adjustments) + len(qb.defaults))
+	args += len(qb.data) + len(qb.adjustments) + len(qb.defaults)
+	for _, row := range qb.rows {
+		size += 8 * len(row)
+		args += len(row)
 	}
-	if qb.orderBy != "" {
-		queryBuilder.WriteString(" ORDER BY " + qb.orderBy)
+	return size, args
+}
+
+func (w *queryWriter) write(s string) {
+	if !w.argsOnly {
+		w.sb.WriteString(s)
 	}
-	argIdx := len(qb.args) + 1
-	if qb.limit > 0 {
-		if qb.dbType == PostgreSQL {
-			queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIdx))
+}
+
+/*
+writeClause
+
+@ c: Clause with "?" placeholders
+@ Return: None. Placeholders are numbered after the args already written
+*/
+func (w *queryWriter) writeClause(c clause) {
+	switch {
+	case w.embed:
+		if c.native {
+			w.sb.WriteString(shiftPlaceholders(w.dbType, c.sql, len(w.args)))
 		} else {
-			queryBuilder.WriteString(" LIMIT ?")
+			w.sb.WriteString(c.sql)
 		}
-		qb.args = append(qb.args, qb.limit)
-		argIdx++
-	}
-	if qb.offset > 0 {
-		if qb.dbType == PostgreSQL {
-			queryBuilder.WriteString(fmt.Sprintf(" OFFSET $%d", argIdx))
-		} else {
-			queryBuilder.WriteString(" OFFSET ?")
+		w.args = append(w.args, c.args...)
+		return
+	case w.named && c.native:
+		if w.err == nil {
+			w.err = fmt.Errorf("WhereRaw() conditions cannot be used with BuildNamed()")
+		}
+		return
+	case w.named:
+		next := 0
+		for i := 0; i < len(c.sql); i++ {
+			switch {
+			case c.sql[i] != '?':
+				w.sb.WriteByte(c.sql[i])
+			case i+1 < len(c.sql) && c.sql[i+1] == '?':
+				w.sb.WriteByte('?')
+				i++
+			case next < len(c.args):
+				w.sb.WriteString(w.namedPlaceholder(c.args[next]))
+				next++
+			default:
+				w.sb.WriteByte('?')
+			}
 		}
-		qb.args = append(qb.args, qb.offset)
+		return
+	case w.argsOnly:
+	case c.native:
+		start := w.sb.Len()
+		w.sb.WriteString(shiftPlaceholders(w.dbType, c.sql, w.offset+len(w.args)))
+		if w.spans != nil {
+			w.markNative(start)
+		}
+	case w.spans != nil:
+		w.writeMarked(c.sql)
+	default:
+		writePlaceholders(&w.sb, w.dialect, c.sql, w.offset+len(w.args)+1)
+	}
+	for _, arg := range c.args {
+		w.addArg(plainArg(arg), c.column)
 	}
-	return queryBuilder.String(), qb.args, nil
 }
 
-func (qb *QueryBuilder) buildInsert() (string, []interface{}, error) {
-	if qb.data == nil {
-		return "", nil, fmt.Errorf("no data provided for INSERT")
-	}
-	var cols []string
-	var placeholders []string
-	var args []interface{}
-	idx := 1
-	for col, val := range qb.data {
-		safeCol, err := EscapeIdentifier(qb.dbType, col)
-		if err != nil {
-			return "", nil, err
+/*
+writeMarked
+
+@ sql: Clause with "?" placeholders
+@ Return: None. Writes the clause as writePlaceholders does, recording the span of each placeholder
+*/
+func (w *queryWriter) writeMarked(sql string) {
+	arg := len(w.args)
+	for {
+		i := strings.IndexByte(sql, '?')
+		if i < 0 {
+			w.sb.WriteString(sql)
+			return
 		}
-		cols = append(cols, safeCol)
-		if qb.dbType == PostgreSQL {
-			placeholders = append(placeholders, fmt.Sprintf("$%d", idx))
-		} else {
-			placeholders = append(placeholders, "?")
+		w.sb.WriteString(sql[:i])
+		if i+1 < len(sql) && sql[i+1] == '?' {
+			w.sb.WriteByte('?')
+			sql = sql[i+2:]
+			continue
 		}
-		args = append(args, val)
-		idx++
+		start := w.sb.Len()
+		w.dialect.writePlaceholder(&w.sb, w.offset+arg+1)
+		*w.spans = append(*w.spans, argSpan{arg: arg, start: start, end: w.sb.Len()})
+		arg++
+		sql = sql[i+1:]
 	}
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", qb.table, strings.Join(cols, ", "), strings.Join(placeholders, ", "))
-	if qb.dbType == PostgreSQL && qb.returning != "" {
-		query += " RETURNING " + qb.returning
-	}
-	return query, args, nil
 }
 
-func (qb *QueryBuilder) buildUpdate() (string, []interface{}, error) {
-	if qb.data == nil {
-		return "", nil, fmt.Errorf("no data provided for UPDATE")
+/*
+markNative
+
+@ start: Offset where a WhereRaw clause was written
+@ Return: None. Records the span of each native placeholder of the clause; with "?" dialects every "?" binds the
+next arg, as the driver sees it
+*/
+func (w *queryWriter) markNative(start int) {
+	written := w.sb.String()[start:]
+	if w.dialect.native == nil {
+		arg := len(w.args)
+		for i := 0; i < len(written); i++ {
+			if written[i] == '?' {
+				*w.spans = append(*w.spans, argSpan{arg: arg, start: start + i, end: start + i + 1})
+				arg++
+			}
+		}
+		return
 	}
-	var setClauses []string
-	var updateArgs []interface{}
-	idx := 1
-	for col, val := range qb.data {
-		safeCol, err := EscapeIdentifier(qb.dbType, col)
+	for _, loc := range w.dialect.native.FindAllStringIndex(written, -1) {
+		n, err := strconv.Atoi(written[loc[0]+len(w.dialect.prefix) : loc[1]])
 		if err != nil {
-			return "", nil, err
+			continue
 		}
-		var placeholder string
-		if qb.dbType == PostgreSQL {
-			placeholder = fmt.Sprintf("$%d", idx)
-		} else {
-			placeholder = "?"
+		*w.spans = append(*w.spans, argSpan{arg: n - 1 - w.offset, start: start + loc[0], end: start + loc[1]})
+	}
+}
+
+/*
+writeFragment
+
+@ f: Fragment with "?" placeholders, as returned by a Dialect
+@ Return: None. Placeholders are numbered after the args already written
+*/
+func (w *queryWriter) writeFragment(f Fragment) {
+	w.writeClause(clause{sql: f.SQL, args: f.Args})
+}
+
+func (w *queryWriter) writeClauses(clauses []clause, sep string) {
+	for i, c := range clauses {
+		if i > 0 {
+			w.write(sep)
 		}
-		setClauses = append(setClauses, fmt.Sprintf("%s = %s", safeCol, placeholder))
-		updateArgs = append(updateArgs, val)
-		idx++
+		w.writeClause(c)
 	}
-	query := fmt.Sprintf("UPDATE %s SET %s", qb.table, strings.Join(setClauses, ", "))
-	if len(qb.conditions) > 0 {
-		if qb.dbType == PostgreSQL {
-			shiftedConds := make([]string, len(qb.conditions))
-			for i, cond := range qb.conditions {
-				shiftedConds[i] = shiftPlaceholders(cond, len(qb.data))
-			}
-			query += " WHERE " + strings.Join(shiftedConds, " AND ")
-		} else {
-			query += " WHERE " + strings.Join(qb.conditions, " AND ")
+}
+
+/*
+bind
+
+@ arg: Value to bind
+@ Return: None. Writes a single placeholder and records the value
+*/
+func (w *queryWriter) bind(arg interface{}) {
+	w.bindColumn(arg, "")
+}
+
+/*
+bindColumn
+
+@ arg: Value to bind
+@ column: Unescaped column the value is written to, empty if unknown
+@ Return: None. Writes a single placeholder and records the value
+*/
+func (w *queryWriter) bindColumn(arg interface{}, column string) {
+	switch {
+	case w.embed:
+		w.sb.WriteString("?")
+		w.args = append(w.args, arg)
+	case w.named:
+		w.sb.WriteString(w.namedPlaceholder(arg))
+	case w.argsOnly:
+		w.addArg(plainArg(arg), column)
+	default:
+		start := w.sb.Len()
+		w.dialect.writePlaceholder(&w.sb, w.offset+len(w.args)+1)
+		if w.spans != nil {
+			*w.spans = append(*w.spans, argSpan{arg: len(w.args), start: start, end: w.sb.Len()})
 		}
-		updateArgs = append(updateArgs, qb.args...)
+		w.addArg(plainArg(arg), column)
 	}
-	return query, updateArgs, nil
 }
 
-func (qb *QueryBuilder) buildDelete() (string, []interface{}, error) {
-	var queryBuilder strings.Builder
-	queryBuilder.WriteString("DELETE FROM ")
-	queryBuilder.WriteString(qb.table)
-	if len(qb.conditions) > 0 {
-		queryBuilder.WriteString(" WHERE " + strings.Join(qb.conditions, " AND "))
+/*
+addArg
+
+@ arg: Value bound to the placeholder just written
+@ column: Unescaped column of the value, empty if unknown
+@ Return: None
+*/
+func (w *queryWriter) addArg(arg interface{}, column string) {
+	for len(w.cols) < len(w.args) {
+		w.cols = append(w.cols, "")
 	}
-	return queryBuilder.String(), qb.args, nil
+	w.args = append(w.args, arg)
+	w.cols = append(w.cols, column)
 }
 
 /*
-shiftPlaceholders
+result
 
-@ condition: Condition string with placeholders
-@ offset: Value to add to placeholder indices
-@ Return: Condition string with shifted placeholders
+@ Return: Query string, arguments slice, and error recorded while writing
 */
-func shiftPlaceholders(condition string, offset int) string {
-	return placeholderRegexp.ReplaceAllStringFunc(condition, func(match string) string {
-		numStr := match[1:]
-		num, err := strconv.Atoi(numStr)
-		if err != nil {
-			return match
+func (w *queryWriter) result() (string, []interface{}, error) {
+	if w.err != nil {
+		return "", nil, w.err
+	}
+	if w.colsOut != nil {
+		for len(w.cols) < len(w.args) {
+			w.cols = append(w.cols, "")
 		}
-		return fmt.Sprintf("$%d", num+offset)
-	})
+		*w.colsOut = w.cols
+	}
+	if len(w.args) == 0 {
+		return w.String(), nil, nil
+	}
+	return w.String(), w.args, nil
+}
+
+func (w *queryWriter) String() string {
+	return w.sb.String()
+}
+
+/*
+sortedKeys
+
+@ data: Map of column names to values
+@ Return: Column names in sorted order, so generated queries are deterministic
+*/
+func sortedKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 /*
 EscapeIdentifier
 
-@ dbType: Database type (PostgreSQL, MariaDB, Mysql)
-@ name: Identifier to escape
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
+@ name: Identifier to escape; dots separate qualified parts, e.g. "schema.table" or "table.*"
 @ Return: Escaped identifier and error if any
 */
 func EscapeIdentifier(dbType DBType, name string) (string, error) {
 	if name == "*" {
 		return name, nil
 	}
-	if dbType == PostgreSQL {
-		return fmt.Sprintf(`"%s"`, strings.ReplaceAll(name, `"`, `""`)), nil
+	return QuoteQualified(dbType, strings.Split(name, ".")...)
+}
+
+/*
+QuoteQualified
+
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
+@ parts: Parts of a qualified name, e.g. "schema", "table", "column"; only the last may be "*".
+Dots inside a part are kept as part of the name
+@ Return: Quoted parts joined with ".", and error if any
+*/
+func QuoteQualified(dbType DBType, parts ...string) (string, error) {
+	if len(parts) == 0 {
+		return "", fmt.Errorf("empty identifier")
+	}
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 && i > 0 {
+			quoted[i] = part
+			continue
+		}
+		q, err := QuoteIdentifier(dbType, part)
+		if err != nil {
+			return "", err
+		}
+		quoted[i] = q
+	}
+	return strings.Join(quoted, "."), nil
+}
+
+/*
+QuoteIdentifier
+
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
+@ name: Identifier to quote as a single name; dots are not treated as separators
+@ Return: Quoted identifier using the same rules as the builder, and error if the database
+would reject or silently truncate the name. Unlike EscapeIdentifier, "*" and Raw values are quoted like any other name.
+*/
+func QuoteIdentifier(dbType DBType, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty identifier")
+	}
+	if strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("identifier %q contains a NUL character", name)
 	}
-	if dbType == MariaDB || dbType == Mysql {
-		return fmt.Sprintf("`%s`", strings.ReplaceAll(name, "`", "``")), nil
+	d, err := dialectFor(dbType)
+	if err != nil {
+		return "", err
+	}
+	return d.Quote(name)
+}
+
+/*
+tableAlias
+
+@ dbType: Database type
+@ alias: Escaped or generated alias
+@ Return: Alias clause for a derived table, as written by the dialect
+*/
+func tableAlias(dbType DBType, alias string) string {
+	return dialectOf(dbType).TableAlias(alias)
+}
+
+// likeEscaper escapes LIKE wildcards with the default escape character "\".
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+/*
+EscapeLike
+
+@ s: User input to match literally inside a LIKE pattern
+@ Return: Input with "\", "%" and "_" escaped, to be bound as a parameter, e.g. Where("name LIKE ?", EscapeLike(s)+"%")
+*/
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+/*
+escapeIdentifiers
+
+@ dbType: Database type
+@ names: Identifiers to escape
+@ Return: Escaped identifiers and error if any
+*/
+func escapeIdentifiers(dbType DBType, names []string) ([]string, error) {
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		safeName, err := EscapeIdentifier(dbType, name)
+		if err != nil {
+			return nil, err
+		}
+		escaped[i] = safeName
 	}
-	return "", fmt.Errorf("unsupported db type: %v", dbType)
+	return escaped, nil
 }
 
 /*
@@ -613,29 +2549,166 @@ func ValidateDirection(direction string) string {
 	return direction
 }
 
+/*
+checkPlaceholderCount
+
+@ method: Name of the calling method, for the error message
+@ condition: Condition string with "?" placeholders
+@ args: Arguments given with the condition
+@ Return: Error if the number of placeholders and arguments differ
+*/
+func checkPlaceholderCount(method, condition string, args []interface{}) error {
+	if n := countPlaceholders(condition); n != len(args) {
+		return fmt.Errorf("%s() condition %q has %d placeholders but %d args", method, condition, n, len(args))
+	}
+	return nil
+}
+
 /*
 ReplacePlaceholders
 
 @ dbType: Database type
-@ condition: Condition string with placeholders
+@ condition: Condition string with placeholders; "??" is written as a literal "?", e.g. for the jsonb operators "??|" and "??&"
 @ startIdx: Starting index for placeholders
 @ Return: Condition string with replaced placeholders
 */
 func ReplacePlaceholders(dbType DBType, condition string, startIdx int) string {
-	if dbType == MariaDB {
-		return condition // MariaDB uses "?" directly
+	if !strings.Contains(condition, "?") {
+		return condition
 	}
 	var result strings.Builder
+	result.Grow(len(condition) + 4*strings.Count(condition, "?"))
+	writePlaceholders(&result, dialectOf(dbType), condition, startIdx)
+	return result.String()
+}
+
+/*
+writePlaceholders
+
+@ sb: Builder receiving the condition
+@ d: Dialect of the placeholders
+@ condition: Condition string with "?" placeholders
+@ startIdx: Index of the first placeholder
+@ Return: None. Writes the condition with numbered placeholders, unescaping "??"
+*/
+func writePlaceholders(sb *strings.Builder, d *registeredDialect, condition string, startIdx int) {
 	placeholderCount := startIdx
-	for _, char := range condition {
-		if char == '?' {
-			result.WriteString(fmt.Sprintf("$%d", placeholderCount))
-			placeholderCount++
-		} else {
-			result.WriteRune(char)
+	for {
+		i := strings.IndexByte(condition, '?')
+		if i < 0 {
+			sb.WriteString(condition)
+			return
 		}
+		sb.WriteString(condition[:i])
+		if i+1 < len(condition) && condition[i+1] == '?' {
+			sb.WriteByte('?')
+			condition = condition[i+2:]
+			continue
+		}
+		d.writePlaceholder(sb, placeholderCount) // MariaDB, Mysql and SQLite use "?" directly
+		placeholderCount++
+		condition = condition[i+1:]
 	}
-	return result.String()
+}
+
+/*
+groupConditions
+
+@ conditions: WHERE conditions joined with AND
+@ Return: Conditions with every one containing a top-level OR wrapped in parentheses, so conditions added
+by scopes and soft deletes still apply to each OR branch
+*/
+func groupConditions(conditions []clause) []clause {
+	var grouped []clause
+	for i, c := range conditions {
+		if !hasTopLevelOr(c.sql) {
+			continue
+		}
+		if grouped == nil {
+			grouped = slices.Clone(conditions)
+		}
+		grouped[i].sql = "(" + c.sql + ")"
+	}
+	if grouped == nil {
+		return conditions
+	}
+	return grouped
+}
+
+/*
+hasTopLevelOr
+
+@ sql: Condition
+@ Return: Whether the condition has an OR or || operator outside parentheses and quoted text
+*/
+func hasTopLevelOr(sql string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth > 0:
+		case ch == '|' && i+1 < len(sql) && sql[i+1] == '|':
+			return true
+		case (ch == 'O' || ch == 'o') && i+1 < len(sql) && (sql[i+1] == 'R' || sql[i+1] == 'r') &&
+			(i == 0 || !isWordByte(sql[i-1])) && (i+2 == len(sql) || !isWordByte(sql[i+2])):
+			return true
+		}
+	}
+	return false
+}
+
+func isWordByte(ch byte) bool {
+	return ch == '_' || ch == '.' || ch == '$' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+/*
+countPlaceholders
+
+@ condition: Condition string with placeholders
+@ Return: Number of "?" placeholders, not counting escaped "??"
+*/
+func countPlaceholders(condition string) int {
+	return strings.Count(condition, "?") - 2*strings.Count(condition, "??")
+}
+
+/*
+placeholderPrefix
+
+@ dbType: Database type
+@ Return: Prefix of numbered placeholders ("$", "@p" or ":"), empty for dialects using "?"
+*/
+func placeholderPrefix(dbType DBType) string {
+	return dialectOf(dbType).prefix
+}
+
+/*
+shiftPlaceholders
+
+@ dbType: Database type
+@ sql: SQL fragment with numbered placeholders starting from 1
+@ offset: Number of args written before the fragment
+@ Return: Fragment with each placeholder n renumbered to n+offset; unchanged for dialects using "?"
+*/
+func shiftPlaceholders(dbType DBType, sql string, offset int) string {
+	d := dialectOf(dbType)
+	if d.native == nil || offset == 0 {
+		return sql
+	}
+	return d.native.ReplaceAllStringFunc(sql, func(m string) string {
+		n, _ := strconv.Atoi(m[len(d.prefix):])
+		return d.Placeholder(n + offset)
+	})
 }
 
 /*
@@ -647,13 +2720,17 @@ GeneratePlaceholders
 @ Return: String of placeholders separated by comma
 */
 func GeneratePlaceholders(dbType DBType, startIdx, count int) string {
-	placeholders := make([]string, count)
+	if count <= 0 {
+		return ""
+	}
+	d := dialectOf(dbType)
+	var sb strings.Builder
+	sb.Grow(count * (len(d.prefix) + len(d.fixed) + 6))
 	for i := 0; i < count; i++ {
-		if dbType == PostgreSQL {
-			placeholders[i] = fmt.Sprintf("$%d", startIdx+i)
-		} else {
-			placeholders[i] = "?"
+		if i > 0 {
+			sb.WriteString(", ")
 		}
+		d.writePlaceholder(&sb, startIdx+i)
 	}
-	return strings.Join(placeholders, ", ")
+	return sb.String()
 }
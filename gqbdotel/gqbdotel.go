@@ -0,0 +1,126 @@
+// Package gqbdotel traces gqbd executions with OpenTelemetry. Add the hook to a builder with AddHook or to an
+// execution with gqbd.WithHook; every statement gets a client span carrying the sanitized query.
+package gqbdotel
+
+import (
+	"context"
+
+	"github.com/donghquinn/gqbd"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer of this package.
+const instrumentationName = "github.com/donghquinn/gqbd/gqbdotel"
+
+// Option customizes the tracing hook.
+type Option func(*hook)
+
+/*
+WithTracerProvider
+
+@ tp: Tracer provider to create spans with, instead of the global provider
+@ Return: Option for Hook
+*/
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(h *hook) {
+		h.provider = tp
+	}
+}
+
+/*
+WithStatement
+
+@ enabled: Whether to record db.statement; it is sanitized with gqbd.SanitizeQuery either way
+@ Return: Option for Hook
+*/
+func WithStatement(enabled bool) Option {
+	return func(h *hook) {
+		h.statement = enabled
+	}
+}
+
+type hook struct {
+	provider  trace.TracerProvider
+	tracer    trace.Tracer
+	statement bool
+}
+
+/*
+Hook
+
+@ opts: Tracing options
+@ Return: gqbd.Hook starting a span per executed statement with db.system, db.operation, db.sql.table,
+db.statement and, for writes, db.rows_affected. Failed executions set the span status to Error
+*/
+func Hook(opts ...Option) gqbd.Hook {
+	h := &hook{statement: true}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.provider == nil {
+		h.provider = otel.GetTracerProvider()
+	}
+	h.tracer = h.provider.Tracer(instrumentationName)
+	return h
+}
+
+func (h *hook) BeforeBuild(qb *gqbd.QueryBuilder) error {
+	return nil
+}
+
+func (h *hook) BeforeExec(ctx context.Context, e gqbd.HookEvent) context.Context {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", dbSystem(e.DBType)),
+		attribute.String("db.operation", e.Kind),
+		attribute.String("db.sql.table", e.Table),
+	}
+	if h.statement {
+		attrs = append(attrs, attribute.String("db.statement", gqbd.SanitizeQuery(e.Query)))
+	}
+	ctx, _ = h.tracer.Start(ctx, e.Kind+" "+e.Table, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx
+}
+
+func (h *hook) AfterExec(ctx context.Context, e gqbd.HookEvent) {
+	span := trace.SpanFromContext(ctx)
+	if e.Rows >= 0 {
+		span.SetAttributes(attribute.Int64("db.rows_affected", e.Rows))
+	}
+	if e.Err != nil {
+		span.RecordError(e.Err)
+		span.SetStatus(codes.Error, e.Err.Error())
+	}
+	span.End()
+}
+
+/*
+dbSystem
+
+@ dbType: Database type of the builder
+@ Return: OpenTelemetry db.system value
+*/
+func dbSystem(dbType gqbd.DBType) string {
+	switch dbType {
+	case gqbd.PostgreSQL:
+		return "postgresql"
+	case gqbd.MariaDB:
+		return "mariadb"
+	case gqbd.Mysql:
+		return "mysql"
+	case gqbd.MSSQL:
+		return "mssql"
+	case gqbd.Oracle:
+		return "oracle"
+	case gqbd.SQLite:
+		return "sqlite"
+	case gqbd.CockroachDB:
+		return "cockroachdb"
+	case gqbd.ClickHouse:
+		return "clickhouse"
+	default:
+		return "other_sql"
+	}
+}
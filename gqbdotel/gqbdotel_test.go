@@ -0,0 +1,81 @@
+package gqbdotel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/donghquinn/gqbd/gqbdotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+Hook
+
+@ Return: One client span per execution with the db attributes, and Error status for failed executions
+*/
+func TestHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	h := gqbdotel.Hook(gqbdotel.WithTracerProvider(tp))
+
+	event := gqbd.HookEvent{
+		DBType: gqbd.PostgreSQL,
+		Kind:   "UPDATE",
+		Table:  "users",
+		Query:  "UPDATE \"users\" SET \"name\" = 'ann' WHERE id = $1",
+		Rows:   3,
+	}
+	h.AfterExec(h.BeforeExec(context.Background(), event), event)
+
+	event.Err = errors.New("deadlock")
+	h.AfterExec(h.BeforeExec(context.Background(), event), event)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "UPDATE users" || span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("unexpected span %q of kind %v", span.Name(), span.SpanKind())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	expected := map[attribute.Key]string{
+		"db.system":    "postgresql",
+		"db.operation": "UPDATE",
+		"db.sql.table": "users",
+		"db.statement": gqbd.SanitizeQuery(event.Query),
+	}
+	for key, value := range expected {
+		if attrs[key].AsString() != value {
+			t.Errorf("expected %s = %q, got %q", key, value, attrs[key].AsString())
+		}
+	}
+	if attrs["db.rows_affected"].AsInt64() != 3 {
+		t.Errorf("expected db.rows_affected = 3, got %v", attrs["db.rows_affected"])
+	}
+	if span.Status().Code != codes.Unset {
+		t.Errorf("expected unset status, got %v", span.Status())
+	}
+	if status := spans[1].Status(); status.Code != codes.Error || status.Description != "deadlock" {
+		t.Errorf("expected Error status, got %v", status)
+	}
+
+	recorder = tracetest.NewSpanRecorder()
+	tp = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	h = gqbdotel.Hook(gqbdotel.WithTracerProvider(tp), gqbdotel.WithStatement(false))
+	h.AfterExec(h.BeforeExec(context.Background(), event), event)
+	for _, kv := range recorder.Ended()[0].Attributes() {
+		if kv.Key == "db.statement" {
+			t.Errorf("expected no db.statement with WithStatement(false)")
+		}
+	}
+}
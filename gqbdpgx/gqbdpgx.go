@@ -0,0 +1,109 @@
+// Package gqbdpgx runs gqbd builders on pgx (*pgxpool.Pool, *pgx.Conn or pgx.Tx) instead of database/sql.
+// Builders must use gqbd.PostgreSQL or gqbd.CockroachDB. Slices bound with WhereAny are sent as native
+// arrays, and rows are scanned with pgx's row mapping using `db` struct tags.
+package gqbdpgx
+
+import (
+	"context"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Querier is the subset of *pgxpool.Pool, *pgx.Conn and pgx.Tx used to run built queries.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+/*
+Exec
+
+@ ctx: Context for the statement
+@ db: pgx handle
+@ qb: Builder to run
+@ Return: Command tag of the statement and error if any
+*/
+func Exec(ctx context.Context, db Querier, qb *gqbd.QueryBuilder) (pgconn.CommandTag, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return db.Exec(ctx, query, args...)
+}
+
+/*
+Query
+
+@ ctx: Context for the query
+@ db: pgx handle
+@ qb: Builder to run
+@ Return: Result rows, which the caller must close, and error if any
+*/
+func Query(ctx context.Context, db Querier, qb *gqbd.QueryBuilder) (pgx.Rows, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	return db.Query(ctx, query, args...)
+}
+
+// errRow is a pgx.Row reporting a build error from Scan.
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
+
+/*
+QueryRow
+
+@ ctx: Context for the query
+@ db: pgx handle
+@ qb: Builder to run
+@ Return: First result row; errors from building or running the query are returned by Scan
+*/
+func QueryRow(ctx context.Context, db Querier, qb *gqbd.QueryBuilder) pgx.Row {
+	query, args, err := qb.Build()
+	if err != nil {
+		return errRow{err: err}
+	}
+	return db.QueryRow(ctx, query, args...)
+}
+
+/*
+All
+
+@ ctx: Context for the query
+@ db: pgx handle
+@ qb: SELECT builder, or a write with RETURNING
+@ Return: Every result row scanned into T by column name using `db` tags, and error if any
+*/
+func All[T any](ctx context.Context, db Querier, qb *gqbd.QueryBuilder) ([]T, error) {
+	rows, err := Query(ctx, db, qb)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByNameLax[T])
+}
+
+/*
+One
+
+@ ctx: Context for the query
+@ db: pgx handle
+@ qb: SELECT builder
+@ Return: First result row scanned into T, and pgx.ErrNoRows if there is none
+*/
+func One[T any](ctx context.Context, db Querier, qb *gqbd.QueryBuilder) (T, error) {
+	rows, err := Query(ctx, db, qb)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return pgx.CollectOneRow(rows, pgx.RowToStructByNameLax[T])
+}
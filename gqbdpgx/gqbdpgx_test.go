@@ -0,0 +1,139 @@
+package gqbdpgx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/donghquinn/gqbd/gqbdpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fakeQuerier records the statements it receives and answers queries with fixed rows.
+type fakeQuerier struct {
+	queries []string
+	args    [][]interface{}
+	columns []string
+	rows    [][]interface{}
+}
+
+func (q *fakeQuerier) record(sql string, args []interface{}) {
+	q.queries = append(q.queries, sql)
+	q.args = append(q.args, args)
+}
+
+func (q *fakeQuerier) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	q.record(sql, args)
+	return pgconn.NewCommandTag(fmt.Sprintf("DELETE %d", len(q.rows))), nil
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	q.record(sql, args)
+	return &fakeRows{columns: q.columns, rows: q.rows, pos: -1}, nil
+}
+
+func (q *fakeQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	rows, _ := q.Query(ctx, sql, args...)
+	return rows.(*fakeRows)
+}
+
+// fakeRows is a pgx.Rows over in-memory values.
+type fakeRows struct {
+	columns []string
+	rows    [][]interface{}
+	pos     int
+}
+
+func (r *fakeRows) Close()                         {}
+func (r *fakeRows) Err() error                     { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag  { return pgconn.NewCommandTag("SELECT") }
+func (r *fakeRows) RawValues() [][]byte            { return nil }
+func (r *fakeRows) Conn() *pgx.Conn                { return nil }
+func (r *fakeRows) Values() ([]interface{}, error) { return r.rows[r.pos], nil }
+func (r *fakeRows) Next() bool                     { r.pos++; return r.pos < len(r.rows) }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription {
+	fields := make([]pgconn.FieldDescription, len(r.columns))
+	for i, col := range r.columns {
+		fields[i].Name = col
+	}
+	return fields
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	if r.pos < 0 && !r.Next() {
+		return pgx.ErrNoRows
+	}
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(r.rows[r.pos][i]))
+	}
+	return nil
+}
+
+/*
+Exec and QueryRow
+
+@ Return: Built query and args passed to pgx, and build errors reported without running anything
+*/
+func TestExecQueryRow(t *testing.T) {
+	db := &fakeQuerier{rows: [][]interface{}{{int64(3)}}}
+	ctx := context.Background()
+
+	tag, err := gqbdpgx.Exec(ctx, db, gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").WhereAny("id", []int64{1, 2}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.RowsAffected() != 1 {
+		t.Errorf("expected 1 affected row, got %d", tag.RowsAffected())
+	}
+	expectedQuery := "DELETE FROM \"sessions\" WHERE \"id\" = ANY($1)"
+	if db.queries[0] != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, db.queries[0])
+	}
+	if !reflect.DeepEqual(db.args[0], []interface{}{[]int64{1, 2}}) {
+		t.Errorf("expected the slice to be bound as one array, got %v", db.args[0])
+	}
+
+	var n int64
+	if err := gqbdpgx.QueryRow(ctx, db, gqbd.BuildSelect(gqbd.PostgreSQL, "sessions", "id")).Scan(&n); err != nil || n != 3 {
+		t.Errorf("expected 3, got %d (%v)", n, err)
+	}
+
+	broken := gqbd.BuildSelect(gqbd.PostgreSQL, "sessions").Where("id = ?")
+	if err := gqbdpgx.QueryRow(ctx, db, broken).Scan(&n); err == nil {
+		t.Errorf("expected build error from Scan")
+	}
+	if len(db.queries) != 2 {
+		t.Errorf("expected the broken query not to run, got %v", db.queries)
+	}
+}
+
+/*
+All and One
+
+@ Return: Rows mapped to structs by `db` tags, and pgx.ErrNoRows when One finds nothing
+*/
+func TestAllOne(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	db := &fakeQuerier{columns: []string{"id", "name"}, rows: [][]interface{}{{int64(1), "ann"}, {int64(2), "bob"}}}
+	ctx := context.Background()
+
+	users, err := gqbdpgx.All[user](ctx, db, gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(users, []user{{1, "ann"}, {2, "bob"}}) {
+		t.Errorf("unexpected users: %v", users)
+	}
+
+	db.rows = nil
+	if _, err := gqbdpgx.One[user](ctx, db, gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name")); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected pgx.ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Package gqbdprom exports Prometheus metrics for gqbd executions: a duration histogram and an error counter,
+// labeled by the query name set with QueryBuilder.Named and the statement kind.
+package gqbdprom
+
+import (
+	"context"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Hook is a gqbd.Hook recording every execution it is attached to.
+type Hook struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+/*
+NewHook
+
+@ reg: Registerer for the collectors, e.g. prometheus.DefaultRegisterer
+@ buckets: Duration histogram buckets in seconds, nil for prometheus.DefBuckets
+@ Return: *Hook to attach with AddHook or gqbd.WithHook, and error if the collectors are already registered
+*/
+func NewHook(reg prometheus.Registerer, buckets []float64) (*Hook, error) {
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	h := &Hook{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gqbd_query_duration_seconds",
+			Help:    "Duration of queries run through gqbd.",
+			Buckets: buckets,
+		}, []string{"name", "kind"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gqbd_query_errors_total",
+			Help: "Queries run through gqbd that returned an error.",
+		}, []string{"name", "kind"}),
+	}
+	for _, c := range []prometheus.Collector{h.duration, h.errors} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func (h *Hook) BeforeBuild(qb *gqbd.QueryBuilder) error {
+	return nil
+}
+
+func (h *Hook) BeforeExec(ctx context.Context, e gqbd.HookEvent) context.Context {
+	return ctx
+}
+
+func (h *Hook) AfterExec(ctx context.Context, e gqbd.HookEvent) {
+	name := e.Name
+	if name == "" {
+		// Unnamed queries are grouped per table, which keeps the label set bounded.
+		name = e.Table
+	}
+	h.duration.WithLabelValues(name, e.Kind).Observe(e.Duration.Seconds())
+	if e.Err != nil {
+		h.errors.WithLabelValues(name, e.Kind).Inc()
+	}
+}
@@ -0,0 +1,60 @@
+package gqbdprom_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/donghquinn/gqbd/gqbdprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+/*
+NewHook
+
+@ Return: Durations observed per name and kind, errors counted, and duplicate registration rejected
+*/
+func TestHook(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h, err := gqbdprom.NewHook(reg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	events := []gqbd.HookEvent{
+		{Name: "list_users", Kind: "SELECT", Table: "users", Duration: time.Millisecond},
+		{Kind: "UPDATE", Table: "users", Duration: time.Millisecond},
+		{Kind: "UPDATE", Table: "users", Duration: time.Millisecond, Err: errors.New("deadlock")},
+	}
+	for _, e := range events {
+		h.AfterExec(h.BeforeExec(ctx, e), e)
+	}
+
+	if n := testutil.CollectAndCount(reg, "gqbd_query_duration_seconds"); n != 2 {
+		t.Errorf("expected 2 duration series, got %d", n)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "gqbd_query_errors_total" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, l := range mf.GetMetric()[0].GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		if labels["name"] != "users" || labels["kind"] != "UPDATE" || mf.GetMetric()[0].GetCounter().GetValue() != 1 {
+			t.Errorf("unexpected error counter: %v", mf)
+		}
+	}
+
+	if _, err := gqbdprom.NewHook(reg, nil); err == nil {
+		t.Errorf("expected error registering the collectors twice")
+	}
+}
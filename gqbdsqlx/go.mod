@@ -0,0 +1,10 @@
+module github.com/donghquinn/gqbd/gqbdsqlx
+
+go 1.24.1
+
+require (
+	github.com/donghquinn/gqbd v0.0.0
+	github.com/jmoiron/sqlx v1.4.0
+)
+
+replace github.com/donghquinn/gqbd => ../
@@ -0,0 +1,78 @@
+// Package gqbdsqlx runs gqbd builders with sqlx, scanning results into structs with sqlx's `db` tag mapping.
+// Any gqbd.Sqlizer works, so builders can be passed as they are; bind struct fields to :name placeholders
+// with gqbd.NamedStruct.
+package gqbdsqlx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+Get
+
+@ ctx: Context for the query
+@ db: *sqlx.DB, *sqlx.Tx or another sqlx.QueryerContext
+@ dest: Pointer to a struct or scalar receiving the first row
+@ qb: Builder to run
+@ Return: Error from building, running or scanning; sql.ErrNoRows if there is no row
+*/
+func Get(ctx context.Context, db sqlx.QueryerContext, dest interface{}, qb gqbd.Sqlizer) error {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return err
+	}
+	return sqlx.GetContext(ctx, db, dest, query, args...)
+}
+
+/*
+Select
+
+@ ctx: Context for the query
+@ db: *sqlx.DB, *sqlx.Tx or another sqlx.QueryerContext
+@ dest: Pointer to a slice receiving every row
+@ qb: Builder to run
+@ Return: Error from building, running or scanning
+*/
+func Select(ctx context.Context, db sqlx.QueryerContext, dest interface{}, qb gqbd.Sqlizer) error {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return err
+	}
+	return sqlx.SelectContext(ctx, db, dest, query, args...)
+}
+
+/*
+Exec
+
+@ ctx: Context for the statement
+@ db: *sqlx.DB, *sqlx.Tx or another sqlx.ExecerContext
+@ qb: Builder to run
+@ Return: Result of the statement and error if any
+*/
+func Exec(ctx context.Context, db sqlx.ExecerContext, qb gqbd.Sqlizer) (sql.Result, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+/*
+Queryx
+
+@ ctx: Context for the query
+@ db: *sqlx.DB, *sqlx.Tx or another sqlx.QueryerContext
+@ qb: Builder to run
+@ Return: *sqlx.Rows for StructScan or MapScan, which the caller must close, and error if any
+*/
+func Queryx(ctx context.Context, db sqlx.QueryerContext, qb gqbd.Sqlizer) (*sqlx.Rows, error) {
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryxContext(ctx, query, args...)
+}
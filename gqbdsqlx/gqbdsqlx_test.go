@@ -0,0 +1,124 @@
+package gqbdsqlx_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/donghquinn/gqbd/gqbdsqlx"
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeConn records the statements it receives and answers every query with the same rows.
+type fakeConn struct {
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.NamedValue
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c *fakeConn) record(query string, args []driver.NamedValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(len(c.rows)), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	return &fakeRows{columns: c.columns, rows: c.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeConnector struct{ conn *fakeConn }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+/*
+Get, Select and Exec
+
+@ Return: Built queries run through sqlx with rows scanned by `db` tags
+*/
+func TestGetSelectExec(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	conn := &fakeConn{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), "ann"}, {int64(2), "bob"}},
+	}
+	db := sqlx.NewDb(sql.OpenDB(fakeConnector{conn}), "postgres")
+	defer db.Close()
+	ctx := context.Background()
+
+	var users []user
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name").Where("active = ?", true)
+	if err := gqbdsqlx.Select(ctx, db, &users, qb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(users, []user{{1, "ann"}, {2, "bob"}}) {
+		t.Errorf("unexpected users: %v", users)
+	}
+	expectedQuery := "SELECT \"id\", \"name\" FROM \"users\" WHERE active = $1"
+	if conn.queries[0] != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, conn.queries[0])
+	}
+	if len(conn.args[0]) != 1 || conn.args[0][0].Value != true {
+		t.Errorf("unexpected args: %v", conn.args[0])
+	}
+
+	var first user
+	if err := gqbdsqlx.Get(ctx, db, &first, qb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != (user{1, "ann"}) {
+		t.Errorf("unexpected user: %v", first)
+	}
+
+	res, err := gqbdsqlx.Exec(ctx, db, gqbd.BuildDelete(gqbd.PostgreSQL, "users").Where("id = ?", 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Errorf("expected 2 affected rows, got %d", n)
+	}
+
+	if err := gqbdsqlx.Get(ctx, db, &first, gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("id = ?")); err == nil {
+		t.Errorf("expected build error")
+	}
+	if len(conn.queries) != 3 {
+		t.Errorf("expected the broken query not to run, got %v", conn.queries)
+	}
+}
@@ -0,0 +1,26 @@
+// Package gqbdtest provides helpers for testing code built on gqbd.
+package gqbdtest
+
+import (
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+RenderAll
+
+@ build: Function building the logical query for the given database type
+@ Return: SQL built for every registered dialect, keyed by database type.
+Dialects that reject the query map to "error: " followed by the build error, so unsupported features show up in snapshots
+*/
+func RenderAll(build func(dbType gqbd.DBType) *gqbd.QueryBuilder) map[gqbd.DBType]string {
+	rendered := make(map[gqbd.DBType]string)
+	for _, dbType := range gqbd.Dialects() {
+		query, _, err := build(dbType).Build()
+		if err != nil {
+			rendered[dbType] = "error: " + err.Error()
+			continue
+		}
+		rendered[dbType] = query
+	}
+	return rendered
+}
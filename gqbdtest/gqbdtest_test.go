@@ -0,0 +1,42 @@
+package gqbdtest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+	"github.com/donghquinn/gqbd/gqbdtest"
+)
+
+/*
+RenderAll
+
+@ Return: The same paged query rendered for every built-in dialect, with errors for unsupported features
+*/
+func TestRenderAll(t *testing.T) {
+	rendered := gqbdtest.RenderAll(func(dbType gqbd.DBType) *gqbd.QueryBuilder {
+		return gqbd.BuildSelect(dbType, "users", "id").
+			Where("status = ?", "active").
+			Limit(10)
+	})
+	expected := map[gqbd.DBType]string{
+		gqbd.ClickHouse:  "SELECT `id` FROM `users` WHERE status = ? LIMIT ?",
+		gqbd.CockroachDB: `SELECT "id" FROM "users" WHERE status = $1 LIMIT $2`,
+		gqbd.MariaDB:     "SELECT `id` FROM `users` WHERE status = ? LIMIT ?",
+		gqbd.MSSQL:       "SELECT TOP (@p1) [id] FROM [users] WHERE status = @p2",
+		gqbd.Mysql:       "SELECT `id` FROM `users` WHERE status = ? LIMIT ?",
+		gqbd.Oracle:      `SELECT "ID" FROM "USERS" WHERE status = :1 FETCH FIRST :2 ROWS ONLY`,
+		gqbd.PostgreSQL:  `SELECT "id" FROM "users" WHERE status = $1 LIMIT $2`,
+		gqbd.SQLite:      `SELECT "id" FROM "users" WHERE status = ? LIMIT ?`,
+	}
+	if !reflect.DeepEqual(rendered, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, rendered)
+	}
+
+	rendered = gqbdtest.RenderAll(func(dbType gqbd.DBType) *gqbd.QueryBuilder {
+		return gqbd.BuildSelect(dbType, "sales", "region").GroupByCube("region")
+	})
+	if got := rendered[gqbd.SQLite]; got != "error: GroupByCube() is not supported for db type: sqlite" {
+		t.Errorf("expected unsupported error for SQLite, got %s", got)
+	}
+}
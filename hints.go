@@ -0,0 +1,174 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type hintsKey struct{}
+
+var (
+	hintNameRegexp  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	hintValueRegexp = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+)
+
+/*
+WithHint
+
+@ ctx: Parent context
+@ hints: Settings in "name=value" form, e.g. "max_parallel_workers_per_gather=4"
+@ Return: Context carrying the hints, applied by Exec and Columns as SET LOCAL (PostgreSQL/CockroachDB),
+SET STATEMENT (MariaDB) or SET_VAR optimizer hints (Mysql)
+*/
+func WithHint(ctx context.Context, hints ...string) context.Context {
+	existing := HintsFromContext(ctx)
+	merged := make([]string, 0, len(existing)+len(hints))
+	merged = append(merged, existing...)
+	merged = append(merged, hints...)
+	return context.WithValue(ctx, hintsKey{}, merged)
+}
+
+/*
+HintsFromContext
+
+@ ctx: Context
+@ Return: Hints attached with WithHint
+*/
+func HintsFromContext(ctx context.Context) []string {
+	hints, _ := ctx.Value(hintsKey{}).([]string)
+	return hints
+}
+
+/*
+parseHint
+
+@ hint: Setting in "name=value" form
+@ Return: Validated name and value, and error if the hint is malformed
+*/
+func parseHint(hint string) (string, string, error) {
+	name, value, ok := strings.Cut(hint, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || !hintNameRegexp.MatchString(name) || !hintValueRegexp.MatchString(value) {
+		return "", "", fmt.Errorf("invalid hint: %q", hint)
+	}
+	return name, value, nil
+}
+
+// txBeginner is implemented by *sql.DB and *sql.Conn.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+/*
+withLocalSettings
+
+@ ctx: Context for the transaction
+@ db: Database handle; a transaction is started unless db already is one
+@ hints: Validated hints
+@ fn: Function running the statement inside the transaction
+@ Return: Error from the transaction or fn
+*/
+func withLocalSettings(ctx context.Context, db Executor, hints []string, fn func(Executor) error) (err error) {
+	if t, ok := db.(*Tx); ok {
+		db = t.Tx
+	}
+	tx, ownTx := db.(*sql.Tx)
+	if !ownTx {
+		beginner, ok := db.(txBeginner)
+		if !ok {
+			return fmt.Errorf("hints require a database handle that can begin transactions")
+		}
+		if tx, err = beginner.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+				return
+			}
+			err = tx.Commit()
+		}()
+	}
+	for _, hint := range hints {
+		name, value, err := parseHint(hint)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL %s = '%s'", name, value)); err != nil {
+			return err
+		}
+	}
+	return fn(tx)
+}
+
+/*
+setStatementPrefix
+
+@ hints: Hints to apply
+@ Return: "SET STATEMENT ... FOR " prefix for MariaDB, and error if a hint is malformed
+*/
+func setStatementPrefix(hints []string) (string, error) {
+	settings, err := hintSettings(hints)
+	if err != nil {
+		return "", err
+	}
+	return "SET STATEMENT " + strings.Join(settings, ", ") + " FOR ", nil
+}
+
+/*
+setVarHint
+
+@ hints: Hints to apply
+@ query: Statement starting with SELECT, INSERT, REPLACE, UPDATE or DELETE
+@ Return: Query with a SET_VAR optimizer hint comment after its first keyword, as Mysql expects,
+and error if a hint is malformed or the statement has no such keyword
+*/
+func setVarHint(hints []string, query string) (string, error) {
+	settings, err := hintSettings(hints)
+	if err != nil {
+		return "", err
+	}
+	keyword := len(query) - len(strings.TrimLeft(query, " \t\n"))
+	end := keyword + strings.IndexFunc(query[keyword:], func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < keyword {
+		end = len(query)
+	}
+	switch strings.ToUpper(query[keyword:end]) {
+	case "SELECT", "INSERT", "REPLACE", "UPDATE", "DELETE":
+	default:
+		return "", fmt.Errorf("hints are not supported for this statement on db type: %v", Mysql)
+	}
+	var hint strings.Builder
+	hint.WriteString(" /*+")
+	for _, setting := range settings {
+		hint.WriteString(" SET_VAR(" + setting + ")")
+	}
+	hint.WriteString(" */")
+	return query[:end] + hint.String() + query[end:], nil
+}
+
+/*
+hintSettings
+
+@ hints: Hints to apply
+@ Return: "name=value" settings with non-numeric values quoted, and error if a hint is malformed
+*/
+func hintSettings(hints []string) ([]string, error) {
+	settings := make([]string, len(hints))
+	for i, hint := range hints {
+		name, value, err := parseHint(hint)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			value = "'" + value + "'"
+		}
+		settings[i] = name + "=" + value
+	}
+	return settings, nil
+}
@@ -0,0 +1,233 @@
+package gqbd
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HookEvent describes a statement run through Exec or a Runner.
+type HookEvent struct {
+	DBType   DBType
+	Name     string        // Query name set with Named, empty if unnamed
+	Kind     string        // Statement kind: "SELECT", "INSERT", "UPDATE" or "DELETE"
+	Table    string        // Unescaped primary table
+	Query    string        // Built query, before hints are added
+	Args     []interface{} // Query arguments
+	Duration time.Duration // Time spent executing; set for AfterExec
+	Err      error         // Error returned by the execution; set for AfterExec
+	Rows     int64         // Rows affected by Exec; set for AfterExec, -1 when unknown such as for queries
+}
+
+// Hook observes builders as they are built and executed, e.g. for structured logging or slow-query warnings.
+// Hooks run in the order they were added; AfterExec runs in reverse order.
+type Hook interface {
+	// BeforeBuild is called by Build before rendering; an error aborts the build.
+	BeforeBuild(qb *QueryBuilder) error
+	// BeforeExec is called before the statement runs; the returned context is used for the execution.
+	BeforeExec(ctx context.Context, e HookEvent) context.Context
+	// AfterExec is called once the statement returned, with Duration and Err set.
+	AfterExec(ctx context.Context, e HookEvent)
+}
+
+// HookFuncs is a Hook built from optional functions; nil fields do nothing.
+type HookFuncs struct {
+	OnBuild      func(qb *QueryBuilder) error
+	OnBeforeExec func(ctx context.Context, e HookEvent) context.Context
+	OnAfterExec  func(ctx context.Context, e HookEvent)
+}
+
+func (h HookFuncs) BeforeBuild(qb *QueryBuilder) error {
+	if h.OnBuild == nil {
+		return nil
+	}
+	return h.OnBuild(qb)
+}
+
+func (h HookFuncs) BeforeExec(ctx context.Context, e HookEvent) context.Context {
+	if h.OnBeforeExec == nil {
+		return ctx
+	}
+	return h.OnBeforeExec(ctx, e)
+}
+
+func (h HookFuncs) AfterExec(ctx context.Context, e HookEvent) {
+	if h.OnAfterExec != nil {
+		h.OnAfterExec(ctx, e)
+	}
+}
+
+/*
+AddHook
+
+@ h: Hook to run for this builder and its copies
+@ Return: *QueryBuilder with the hook added
+*/
+func (qb *QueryBuilder) AddHook(h Hook) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.hooks = append(slices.Clone(qb.hooks), h)
+	return qb
+}
+
+/*
+Named
+
+@ name: Stable name of the query, e.g. "list_users", reported to hooks so metrics and logs can be grouped by it
+@ Return: *QueryBuilder with the name set
+*/
+func (qb *QueryBuilder) Named(name string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.name = name
+	return qb
+}
+
+/*
+WithHook
+
+@ h: Hook to run for this execution, after the builder's own hooks
+@ Return: ExecOption for Exec and RunWith
+*/
+func WithHook(h Hook) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.hooks = append(cfg.hooks, h)
+	}
+}
+
+/*
+LogHook
+
+@ logger: Logger receiving one record per execution
+@ slow: Executions taking at least this long are logged at Warn level; 0 disables the warning
+@ Return: Hook logging the query and duration at Debug level, and failed executions at Error level.
+Arguments are not logged, since they may hold personal data
+*/
+func LogHook(logger *slog.Logger, slow time.Duration) Hook {
+	return HookFuncs{
+		OnAfterExec: func(ctx context.Context, e HookEvent) {
+			level, msg := slog.LevelDebug, "query executed"
+			switch {
+			case e.Err != nil:
+				level, msg = slog.LevelError, "query failed"
+			case slow > 0 && e.Duration >= slow:
+				level, msg = slog.LevelWarn, "slow query"
+			}
+			attrs := []slog.Attr{
+				slog.String("kind", e.Kind),
+				slog.String("table", e.Table),
+				slog.String("query", e.Query),
+				slog.Duration("duration", e.Duration),
+			}
+			if e.Name != "" {
+				attrs = append(attrs, slog.String("name", e.Name))
+			}
+			if e.Err != nil {
+				attrs = append(attrs, slog.Any("error", e.Err))
+			}
+			logger.LogAttrs(ctx, level, msg, attrs...)
+		},
+	}
+}
+
+/*
+SanitizeQuery
+
+@ query: Built query
+@ Return: Query with string and numeric literals replaced by "?", for logs and traces. Placeholders, quoted
+identifiers and keywords are kept, so queries differing only in inlined values look the same
+*/
+func SanitizeQuery(query string) string {
+	var sb strings.Builder
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case ch == '\'':
+			j := i + 1
+			for ; j < len(query); j++ {
+				if query[j] != '\'' {
+					continue
+				}
+				if j+1 < len(query) && query[j+1] == '\'' {
+					j++
+					continue
+				}
+				break
+			}
+			sb.WriteByte('?')
+			i = j
+		case ch == '"' || ch == '`' || ch == '[':
+			closing := map[byte]byte{'"': '"', '`': '`', '[': ']'}[ch]
+			j := strings.IndexByte(query[i+1:], closing)
+			if j < 0 {
+				sb.WriteString(query[i:])
+				return sb.String()
+			}
+			sb.WriteString(query[i : i+j+2])
+			i += j + 1
+		case ch >= '0' && ch <= '9' && (i == 0 || !isNamePart(query[i-1]) && !strings.ContainsRune("$@:.", rune(query[i-1]))):
+			for i+1 < len(query) && (query[i+1] >= '0' && query[i+1] <= '9' || query[i+1] == '.') {
+				i++
+			}
+			sb.WriteByte('?')
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+/*
+execute
+
+@ ctx: Context for the statement
+@ db: Database handle
+@ cfg: Execution options
+@ query: Built query
+@ args: Query arguments
+@ fn: Function running the query with the context returned by the hooks, returning the affected rows or -1
+@ Return: Error from fn. Hooks and metrics are notified around the execution
+*/
+func (qb *QueryBuilder) execute(ctx context.Context, db Executor, cfg execConfig, query string, args []interface{}, fn func(ctx context.Context, ex Executor, query string) (int64, error)) error {
+	hooks := slices.Concat(qb.hooks, cfg.hooks)
+	event := HookEvent{DBType: qb.dbType, Name: qb.name, Kind: qb.op, Table: qb.tableName, Query: query, Args: args}
+	for _, h := range hooks {
+		ctx = h.BeforeExec(ctx, event)
+	}
+	start := time.Now()
+	rows := int64(-1)
+	err := withExecutor(ctx, db, qb.dbType, cfg, query, func(ex Executor, query string) error {
+		var err error
+		rows, err = fn(ctx, ex, query)
+		return err
+	})
+	event.Duration, event.Err, event.Rows = time.Since(start), err, rows
+	if cfg.metrics != nil {
+		cfg.metrics.ObserveQuery(QueryEvent{Kind: qb.op, Table: qb.tableName, Duration: event.Duration, Err: err})
+	}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterExec(ctx, event)
+	}
+	return err
+}
+
+/*
+beforeBuild
+
+@ cfg: Execution options
+@ Return: Error from the BeforeBuild of a hook added with WithHook
+*/
+func (qb *QueryBuilder) beforeBuild(cfg execConfig) error {
+	for _, h := range cfg.hooks {
+		if err := h.BeforeBuild(qb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
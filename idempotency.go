@@ -0,0 +1,102 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+)
+
+// idempotencyKey is the unique column and value identifying a retried insert.
+type idempotencyKey struct {
+	column string
+	key    interface{}
+}
+
+/*
+IdempotencyKey
+
+@ column: Column with a unique index holding the key
+@ key: Key of the request, e.g. the Idempotency-Key header of an API call
+@ Return: *QueryBuilder inserting the key with the row and skipping the insert with
+ON CONFLICT (column) DO NOTHING when the key already exists
+*/
+func (qb *QueryBuilder) IdempotencyKey(column string, key interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("IdempotencyKey() can only be used with INSERT operation")
+		return qb
+	}
+	if qb.insertSelect != nil {
+		qb.err = fmt.Errorf("IdempotencyKey() cannot be used with InsertFromSelect()")
+		return qb
+	}
+	qb.idempotency = &idempotencyKey{column: column, key: key}
+	return qb.OnConflict(column).DoNothing()
+}
+
+/*
+withIdempotencyKey
+
+@ cols: Insert columns
+@ rows: Insert rows
+@ Return: Columns and the single row with the idempotency key set, and error if there is more than one row
+*/
+func (qb *QueryBuilder) withIdempotencyKey(cols []string, rows [][]interface{}) ([]string, [][]interface{}, error) {
+	if len(rows) != 1 {
+		return nil, nil, fmt.Errorf("IdempotencyKey() requires a single row, got %d", len(rows))
+	}
+	row := slices.Clone(rows[0])
+	if i := slices.Index(cols, qb.idempotency.column); i >= 0 {
+		row[i] = qb.idempotency.key
+		return cols, [][]interface{}{row}, nil
+	}
+	return append(slices.Clone(cols), qb.idempotency.column), [][]interface{}{append(row, qb.idempotency.key)}, nil
+}
+
+/*
+ExecIdempotent
+
+@ ctx: Context for the statements
+@ db: Database handle to run the statements against
+@ dest: Scan destinations for the columns given to Returning
+@ Return: Whether this call inserted the row, and error if any. The row stored under the key is
+read back with a follow-up SELECT, so a retry receives the row of the first attempt
+*/
+func (qb *QueryBuilder) ExecIdempotent(ctx context.Context, db Executor, dest ...interface{}) (bool, error) {
+	if qb.idempotency == nil {
+		return false, fmt.Errorf("ExecIdempotent() requires IdempotencyKey()")
+	}
+	if qb.returning == "" {
+		return false, fmt.Errorf("ExecIdempotent() requires Returning() to select the stored row")
+	}
+	insert := qb.Clone()
+	insert.returning = ""
+	result, err := insert.Exec(ctx, db)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	query, args, err := BuildSelectWith(qb.dbType, qb.tableName, nil, WithSchema(qb.schema)).
+		SelectRaw(Raw(qb.returning)).
+		WhereEq(qb.idempotency.column, qb.idempotency.key).
+		Build()
+	if err != nil {
+		return false, err
+	}
+	readBack := *qb
+	readBack.op = "SELECT"
+	err = readBack.execute(ctx, db, execConfig{}, query, args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+		return -1, ex.QueryRowContext(ctx, query, args...).Scan(dest...)
+	})
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("row with idempotency key %v not found after insert", qb.idempotency.key)
+	}
+	return affected > 0, err
+}
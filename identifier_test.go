@@ -0,0 +1,104 @@
+package gqbd_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+// identifierCases are the identifier edge cases published in IDENTIFIERS.md.
+var identifierCases = []struct {
+	name  string
+	input string
+}{
+	{"plain", "users"},
+	{"mixed case", "UserId"},
+	{"qualified", "public.users"},
+	{"qualified star", "users.*"},
+	{"three parts", "db.users.id"},
+	{"space", "order items"},
+	{"unicode", "사용자"},
+	{"reserved word", "select"},
+	{"double quote", `we"ird`},
+	{"backtick", "we`ird"},
+	{"bracket", "we]ird"},
+	{"empty part", "users..id"},
+	{"trailing space", "name "},
+	{"outside BMP", "emoji😀"},
+	{"NUL", "a\x00b"},
+	{"64 characters", strings.Repeat("x", 64)},
+	{"65 characters", strings.Repeat("x", 65)},
+}
+
+/*
+EscapeIdentifier compatibility table
+
+@ Return: IDENTIFIERS.md matching the escaping of every edge case per dialect; run with -update to regenerate
+*/
+func TestIdentifierCompatibility(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("# Identifier compatibility\n\n")
+	sb.WriteString("Generated by `go test -run TestIdentifierCompatibility -update`. Do not edit.\n\n")
+	sb.WriteString("`EscapeIdentifier` splits names on `.`; use `QuoteIdentifier` or `QuoteQualified` for names that contain dots.\n")
+	sb.WriteString("Oracle folds names that would be valid unquoted to upper case, matching objects created without quotes; other names keep their case.\n\n")
+	sb.WriteString("| Case | Input | PostgreSQL | MariaDB / Mysql | SQLite | MSSQL | Oracle | CockroachDB | ClickHouse |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|---|\n")
+	for _, c := range identifierCases {
+		row := []string{c.name, cell(fmt.Sprintf("%q", c.input))}
+		for _, dbType := range []gqbd.DBType{gqbd.PostgreSQL, gqbd.MariaDB, gqbd.SQLite, gqbd.MSSQL, gqbd.Oracle, gqbd.CockroachDB, gqbd.ClickHouse} {
+			quoted, err := gqbd.EscapeIdentifier(dbType, c.input)
+			if err != nil {
+				row = append(row, "error")
+				continue
+			}
+			row = append(row, cell(quoted))
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	got := sb.String()
+
+	const golden = "IDENTIFIERS.md"
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(want) != got {
+		t.Errorf("%s is out of date, run go test -run TestIdentifierCompatibility -update\ngot:\n%s", golden, got)
+	}
+}
+
+// cellEscaper escapes text placed inside an HTML code element in a markdown table.
+var cellEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "|", "&#124;")
+
+func cell(s string) string {
+	return "<code>" + cellEscaper.Replace(s) + "</code>"
+}
+
+/*
+QuoteQualified
+
+@ Return: Each part quoted separately, dots inside a part kept
+*/
+func TestQuoteQualified(t *testing.T) {
+	quoted, err := gqbd.QuoteQualified(gqbd.PostgreSQL, "analytics", "events.v2", "*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quoted != `"analytics"."events.v2".*` {
+		t.Errorf("unexpected quoted name: %s", quoted)
+	}
+	if _, err := gqbd.QuoteQualified(gqbd.MariaDB, "users", ""); err == nil {
+		t.Error("expected error for empty part")
+	}
+}
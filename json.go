@@ -0,0 +1,105 @@
+package gqbd
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*
+WhereJSONContains
+
+@ column: JSON column
+@ value: Value the column must contain, marshalled to JSON, e.g. map[string]interface{}{"role": "admin"}
+@ Return: *QueryBuilder with "column @> ?::jsonb" (PostgreSQL, CockroachDB) or JSON_CONTAINS(column, ?) (MariaDB/Mysql) added
+*/
+func (qb *QueryBuilder) WhereJSONContains(column string, value interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	doc, err := json.Marshal(value)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	features := dialectOf(qb.dbType).Features()
+	switch {
+	case features.JSONB:
+		qb.conditions = append(qb.conditions, clause{sql: safeCol + " @> ?::jsonb", args: []interface{}{string(doc)}})
+	case features.JSONFunctions:
+		qb.conditions = append(qb.conditions, clause{sql: fmt.Sprintf("JSON_CONTAINS(%s, ?)", safeCol), args: []interface{}{string(doc)}})
+	default:
+		qb.err = fmt.Errorf("WhereJSONContains() is not supported for db type: %v", qb.dbType)
+	}
+	return qb
+}
+
+/*
+WhereJSONPathExists
+
+@ column: JSON column
+@ path: JSON path, e.g. "$.a.b"
+@ Return: *QueryBuilder with jsonb_path_exists (PostgreSQL, CockroachDB) or JSON_CONTAINS_PATH (MariaDB/Mysql) added
+*/
+func (qb *QueryBuilder) WhereJSONPathExists(column, path string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	features := dialectOf(qb.dbType).Features()
+	switch {
+	case features.JSONB:
+		qb.conditions = append(qb.conditions, clause{sql: fmt.Sprintf("jsonb_path_exists(%s, ?::jsonpath)", safeCol), args: []interface{}{path}})
+	case features.JSONFunctions:
+		qb.conditions = append(qb.conditions, clause{sql: fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', ?)", safeCol), args: []interface{}{path}})
+	default:
+		qb.err = fmt.Errorf("WhereJSONPathExists() is not supported for db type: %v", qb.dbType)
+	}
+	return qb
+}
+
+/*
+SelectJSONField
+
+@ column: JSON column
+@ path: JSON path of the field, e.g. "$.name"
+@ alias: Alias of the selected value
+@ Return: *QueryBuilder selecting the field as text with jsonb_path_query_first (PostgreSQL, CockroachDB)
+or JSON_UNQUOTE(JSON_EXTRACT(...)) (MariaDB/Mysql)
+*/
+func (qb *QueryBuilder) SelectJSONField(column, path, alias string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	safeAlias, err := EscapeIdentifier(qb.dbType, alias)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	features := dialectOf(qb.dbType).Features()
+	switch {
+	case features.JSONB:
+		qb.addColumn(clause{sql: fmt.Sprintf("jsonb_path_query_first(%s, ?::jsonpath) #>> '{}' AS %s", safeCol, safeAlias), args: []interface{}{path}})
+	case features.JSONFunctions:
+		qb.addColumn(clause{sql: fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, ?)) AS %s", safeCol, safeAlias), args: []interface{}{path}})
+	default:
+		qb.err = fmt.Errorf("SelectJSONField() is not supported for db type: %v", qb.dbType)
+	}
+	return qb
+}
@@ -121,3 +121,560 @@ func TestBuildDeleteMariaDB(t *testing.T) {
 		t.Errorf("expected args %v, got %v", expectedArgs, args)
 	}
 }
+
+/*
+OrderByExpr
+
+@ Return: SELECT query with parameterized ORDER BY expression bound after WHERE args
+*/
+func TestOrderByExprMariaDB(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.MariaDB, "table_name", "col1").
+		OrderByExpr("CASE WHEN status = ? THEN 0 ELSE 1 END", "active").
+		Where("col1 = ?", 100).
+		Limit(10)
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `col1` FROM `table_name` WHERE col1 = ? ORDER BY CASE WHEN status = ? THEN 0 ELSE 1 END LIMIT ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{100, "active", 10}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+Case
+
+@ Return: CASE expression in SELECT list bound before WHERE args
+*/
+func TestCaseMariaDB(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.MariaDB, "tickets", "id").
+		Where("owner_id = ?", 7).
+		SelectExpr(gqbd.Case().When("status = ?", 1, "active").Else(gqbd.Expr("priority")).As("rank"))
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id`, CASE WHEN status = ? THEN ? ELSE priority END AS `rank` FROM `tickets` WHERE owner_id = ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"active", 1, 7}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+Aggregate with options
+
+@ Return: FILTER emulated with CASE WHEN inside the aggregate
+*/
+func TestAggregateOptionsMariaDB(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.MariaDB, "orders", "customer_id").
+		Aggregate("COUNT", "*", gqbd.AggregateFilter("status = ?", "paid"), gqbd.AggregateAs("paid_orders")).
+		GroupBy("customer_id")
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `customer_id`, COUNT(CASE WHEN status = ? THEN 1 END) AS `paid_orders` FROM `orders` GROUP BY `customer_id`"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"paid"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+GroupByRollup
+
+@ Return: GROUP BY ... WITH ROLLUP, and errors for CUBE / GROUPING SETS
+*/
+func TestGroupingMariaDB(t *testing.T) {
+	query, _, err := gqbd.BuildSelect(gqbd.MariaDB, "sales", "region").
+		Aggregate("SUM", "amount").
+		GroupByRollup("region").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `region`, SUM(`amount`) FROM `sales` GROUP BY `region` WITH ROLLUP"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.MariaDB, "sales").GroupByCube("region").Build(); err == nil {
+		t.Errorf("expected error for CUBE on MariaDB")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.MariaDB, "sales").GroupingSets([]string{"region"}).Build(); err == nil {
+		t.Errorf("expected error for GROUPING SETS on MariaDB")
+	}
+}
+
+/*
+QuoteIdentifier
+
+@ Return: Backtick quoting with embedded backticks doubled
+*/
+func TestQuoteIdentifierMariaDB(t *testing.T) {
+	quoted, err := gqbd.QuoteIdentifier(gqbd.MariaDB, "we`ird")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quoted != "`we``ird`" {
+		t.Errorf("unexpected quoted identifier: %s", quoted)
+	}
+}
+
+/*
+WhereIf and If
+
+@ Return: Optional filters applied only when their condition is true
+*/
+func TestConditionalMariaDB(t *testing.T) {
+	name, minAge := "", 20
+	qb := gqbd.BuildSelect(gqbd.MariaDB, "users", "id").
+		WhereIf(name != "", "name = ?", name).
+		WhereIf(minAge > 0, "age >= ?", minAge).
+		If(true, func(q *gqbd.QueryBuilder) {
+			q.OrderBy("id", "DESC", nil)
+		}).
+		If(false, func(q *gqbd.QueryBuilder) {
+			q.Limit(10)
+		})
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id` FROM `users` WHERE age >= ? ORDER BY `id` DESC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{20}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+WhereMap
+
+@ Return: Equality conditions built from a map with "?" placeholders
+*/
+func TestWhereMapMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildDelete(gqbd.MariaDB, "sessions").
+		WhereMap(map[string]interface{}{"user_id": 7, "revoked_at": nil}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "DELETE FROM `sessions` WHERE `revoked_at` IS NULL AND `user_id` = ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Upsert
+
+@ Return: INSERT with ON DUPLICATE KEY UPDATE; a constraint target is dropped with a warning
+*/
+func TestUpsertMariaDB(t *testing.T) {
+	data := map[string]interface{}{"email": "a@b.c", "name": "Alice"}
+
+	qb := gqbd.BuildInsert(gqbd.MariaDB, "users").
+		Values(data).
+		OnConflictConstraint("users_email_key").
+		DoUpdate("name")
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO `users` (`email`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a@b.c", "Alice"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if warnings := qb.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning, got %v", warnings)
+	}
+
+	query, _, err = gqbd.BuildInsert(gqbd.MariaDB, "users").
+		Values(data).
+		DoNothing().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "INSERT INTO `users` (`email`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `email` = `email`"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	_, _, err = gqbd.BuildInsert(gqbd.MariaDB, "users").
+		Values(data).
+		OnConflictConstraint("users_email_key").
+		DoUpdate("name").
+		Strict().
+		Build()
+	if err == nil {
+		t.Error("expected strict mode to reject OnConflictConstraint")
+	}
+}
+
+/*
+Typed Comparisons
+
+@ Return: Escaped column comparisons with parameterized values
+*/
+func TestTypedComparisonsMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildUpdate(gqbd.MariaDB, "users").
+		Set(map[string]interface{}{"status": "inactive"}).
+		WhereGt("last_login", "2024-01-01").
+		WhereLte("score", 10).
+		WhereNotEq("archived_at", nil).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "UPDATE `users` SET `status` = ? WHERE `last_login` > ? AND `score` <= ? AND `archived_at` IS NOT NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"inactive", "2024-01-01", 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+SeekAfter
+
+@ Return: Expanded keyset condition with matching ORDER BY
+*/
+func TestSeekAfterMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MariaDB, "posts", "id").
+		SeekAfter([]string{"category", "created_at", "id"}, []interface{}{3, "2024-05-01", 120}, "ASC").
+		Limit(20).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id` FROM `posts` WHERE (`category` > ? OR (`category` = ? AND `created_at` > ?) OR (`category` = ? AND `created_at` = ? AND `id` > ?)) ORDER BY `category` ASC, `created_at` ASC, `id` ASC LIMIT ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{3, 3, "2024-05-01", 3, "2024-05-01", 120, 20}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+WhereRaw
+
+@ Return: Condition passed through unchanged
+*/
+func TestWhereRawMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MariaDB, "documents", "id").
+		WhereRaw("JSON_CONTAINS(tags, ?)", `"urgent"`).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id` FROM `documents` WHERE JSON_CONTAINS(tags, ?)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{`"urgent"`}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Union
+
+@ Return: Parenthesized parts; ORDER BY without LIMIT inside a part is reported
+*/
+func TestUnionMariaDB(t *testing.T) {
+	active := gqbd.BuildSelect(gqbd.MariaDB, "users", "id", "name").
+		Where("status = ?", "active").
+		Limit(10)
+	admins := gqbd.BuildSelect(gqbd.MariaDB, "admins", "id", "name").
+		OrderBy("name", "ASC", nil)
+
+	ub := gqbd.BuildUnion(gqbd.MariaDB, active, admins).OrderBy("name", "ASC")
+	query, args, err := ub.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "(SELECT `id`, `name` FROM `users` WHERE status = ? LIMIT ?) UNION (SELECT `id`, `name` FROM `admins` ORDER BY `name` ASC) ORDER BY `name` ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if warnings := ub.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning for ORDER BY without LIMIT, got %v", warnings)
+	}
+}
+
+/*
+WithCollation
+
+@ Return: COLLATE on generated string comparisons and ORDER BY, not on numeric comparisons
+*/
+func TestWithCollationMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MariaDB, "users", "id").
+		WithCollation("utf8mb4_unicode_ci").
+		WhereEq("email", "a@b.c").
+		WhereGt("age", 18).
+		WhereIn("role", []interface{}{"admin", "owner"}).
+		OrderBy("name", "ASC", nil).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id` FROM `users` WHERE `email` COLLATE utf8mb4_unicode_ci = ? AND `age` > ? AND `role` COLLATE utf8mb4_unicode_ci IN (?, ?) ORDER BY `name` COLLATE utf8mb4_unicode_ci ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a@b.c", 18, "admin", "owner"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.MariaDB, "users").WithCollation("utf8mb4; DROP TABLE users").Build(); err == nil {
+		t.Error("expected error for invalid collation name")
+	}
+}
+
+/*
+JSON helpers
+
+@ Return: JSON_CONTAINS, JSON_CONTAINS_PATH and JSON_EXTRACT with bound values
+*/
+func TestJSONHelpersMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MariaDB, "users", "id").
+		SelectJSONField("meta", "$.name", "name").
+		WhereJSONContains("meta", []string{"admin"}).
+		WhereJSONPathExists("meta", "$.a.b").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id`, JSON_UNQUOTE(JSON_EXTRACT(`meta`, ?)) AS `name` FROM `users` WHERE JSON_CONTAINS(`meta`, ?) AND JSON_CONTAINS_PATH(`meta`, 'one', ?)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"$.name", `["admin"]`, "$.a.b"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+OrderBySpec
+
+@ Return: NULLS FIRST/LAST emulated with an IS NULL sort key
+*/
+func TestOrderBySpecMariaDB(t *testing.T) {
+	query, _, err := gqbd.BuildSelect(gqbd.MariaDB, "users", "id").
+		OrderBySpec([]gqbd.SortField{
+			{Column: "last_login", Direction: "DESC", Nulls: "LAST"},
+			{Column: "nickname", Direction: "ASC", Nulls: "FIRST"},
+		}, nil).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id` FROM `users` ORDER BY CASE WHEN `last_login` IS NULL THEN 1 ELSE 0 END, `last_login` DESC, CASE WHEN `nickname` IS NULL THEN 0 ELSE 1 END, `nickname` ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+WhereFullText
+
+@ Return: MATCH ... AGAINST in natural language mode, with the same expression as the rank
+*/
+func TestWhereFullTextMariaDB(t *testing.T) {
+	columns := []string{"title", "body"}
+	query, args, err := gqbd.BuildSelect(gqbd.MariaDB, "posts", "id").
+		WhereFullText(columns, "go sql").
+		OrderByExpression(gqbd.FullTextRank(gqbd.MariaDB, columns, "go sql"), "DESC").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `id` FROM `posts` WHERE MATCH(`title`, `body`) AGAINST (? IN NATURAL LANGUAGE MODE) ORDER BY MATCH(`title`, `body`) AGAINST (? IN NATURAL LANGUAGE MODE) DESC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"go sql", "go sql"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Values
+
+@ Return: VALUES list emulated with a UNION ALL derived table
+*/
+func TestValuesMariaDB(t *testing.T) {
+	v := gqbd.Values("v", []string{"id", "rank"}, [][]interface{}{{1, "gold"}, {2, "silver"}})
+	query, args, err := gqbd.BuildSelect(gqbd.MariaDB, "users", "name").
+		JoinValues("LEFT", v, "v.id = users.id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT `name` FROM `users` LEFT JOIN (SELECT ? AS `id`, ? AS `rank` UNION ALL SELECT ?, ?) AS `v` ON v.id = users.id"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "gold", 2, "silver"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+InsertFromSelect
+
+@ Return: INSERT ... SELECT with an ON DUPLICATE KEY UPDATE clause
+*/
+func TestInsertFromSelectMariaDB(t *testing.T) {
+	sub := gqbd.BuildSelect(gqbd.MariaDB, "users", "id", "email").Where("active = ?", true)
+	query, args, err := gqbd.InsertFromSelect("users_copy", []string{"id", "email"}, sub).
+		OnConflict("id").
+		DoUpdate("email").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO `users_copy` (`id`, `email`) SELECT `id`, `email` FROM `users` WHERE active = ? ON DUPLICATE KEY UPDATE `email` = VALUES(`email`)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{true}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Using
+
+@ Return: Multi-table DELETE naming the target before FROM
+*/
+func TestDeleteUsingMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildDelete(gqbd.MariaDB, "order_items").
+		Using("orders", "orders.id = order_items.order_id").
+		Where("orders.status = ?", "cancelled").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "DELETE `order_items` FROM `order_items` INNER JOIN `orders` ON orders.id = order_items.order_id WHERE orders.status = ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"cancelled"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+OrIgnore
+
+@ Return: INSERT IGNORE
+*/
+func TestOrIgnoreMariaDB(t *testing.T) {
+	query, _, err := gqbd.BuildInsert(gqbd.MariaDB, "events").
+		Values(map[string]interface{}{"id": "evt-1", "payload": "{}"}).
+		OrIgnore().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT IGNORE INTO `events` (`id`, `payload`) VALUES (?, ?)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+Replace
+
+@ Return: REPLACE INTO
+*/
+func TestReplaceMariaDB(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.MariaDB, "settings").
+		Values(map[string]interface{}{"key": "theme", "value": "dark"}).
+		Replace().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "REPLACE INTO `settings` (`key`, `value`) VALUES (?, ?)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"theme", "dark"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Increment
+
+@ Return: SET column = column + ? with backtick quoting
+*/
+func TestIncrementMariaDB(t *testing.T) {
+	query, _, err := gqbd.BuildUpdate(gqbd.MariaDB, "products").
+		Increment("stock", 5).
+		Where("id = ?", 42).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "UPDATE `products` SET `stock` = `stock` + ? WHERE id = ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+DebugSQL
+
+@ Return: Placeholders replaced in order with MariaDB literals, leaving escaped "??" in literals alone
+*/
+func TestDebugSQLMariaDB(t *testing.T) {
+	got := gqbd.BuildSelect(gqbd.MariaDB, "faq", "id").
+		Where("question LIKE ? AND lang = ? AND published = ?", "%reset%", "en", false).
+		DebugSQL()
+	expected := "/* gqbd DebugSQL: not for execution */ SELECT `id` FROM `faq` WHERE question LIKE '%reset%' AND lang = 'en' AND published = FALSE"
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	got = gqbd.BuildSelect(gqbd.MariaDB, "faq", "id").Where("a = '??' AND b = ?", 1).DebugSQL()
+	expected = "/* gqbd DebugSQL: not for execution */ SELECT `id` FROM `faq` WHERE a = '?' AND b = 1"
+	if got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,90 @@
+package gqbd
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// QueryEvent describes an executed statement, tagged from the builder rather than the SQL text.
+type QueryEvent struct {
+	Kind     string        // Statement kind: "SELECT", "INSERT", "UPDATE" or "DELETE"
+	Table    string        // Unescaped primary table
+	Duration time.Duration // Time spent executing, including hints and locks
+	Err      error         // Error returned by the execution, nil on success
+}
+
+// QueryMetrics receives an event for every execution it is attached to with WithMetrics.
+type QueryMetrics interface {
+	ObserveQuery(e QueryEvent)
+}
+
+/*
+WithMetrics
+
+@ m: Metrics sink, e.g. a *TableCounters or an adapter to a metrics library
+@ Return: ExecOption reporting the statement's kind, table and duration to m once it returns
+*/
+func WithMetrics(m QueryMetrics) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.metrics = m
+	}
+}
+
+// TableKey identifies a TableCounters series.
+type TableKey struct {
+	Kind  string
+	Table string
+}
+
+// TableCount is the running total of a TableCounters series.
+type TableCount struct {
+	Queries  int64         // Executions, including failed ones
+	Errors   int64         // Executions that returned an error
+	Duration time.Duration // Total execution time
+}
+
+// TableCounters counts executions per statement kind and table.
+type TableCounters struct {
+	mu     sync.Mutex
+	counts map[TableKey]TableCount
+}
+
+/*
+NewTableCounters
+
+@ Return: Empty *TableCounters to pass to executions with WithMetrics
+*/
+func NewTableCounters() *TableCounters {
+	return &TableCounters{counts: map[TableKey]TableCount{}}
+}
+
+/*
+ObserveQuery
+
+@ e: Executed statement
+@ Return: None
+*/
+func (c *TableCounters) ObserveQuery(e QueryEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := TableKey{Kind: e.Kind, Table: e.Table}
+	count := c.counts[key]
+	count.Queries++
+	if e.Err != nil {
+		count.Errors++
+	}
+	count.Duration += e.Duration
+	c.counts[key] = count
+}
+
+/*
+Snapshot
+
+@ Return: Copy of the counters so far
+*/
+func (c *TableCounters) Snapshot() map[TableKey]TableCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
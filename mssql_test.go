@@ -0,0 +1,180 @@
+package gqbd_test
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+BuildSelect
+
+@ Return: Bracket-quoted SELECT with @pN placeholders, TOP for a bare limit and OFFSET ... FETCH NEXT for pages
+*/
+func TestBuildSelectMSSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MSSQL, "users", "id", "name").
+		Where("status = ?", "active").
+		OrderBy("name", "ASC", nil).
+		Limit(10).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT TOP (@p1) [id], [name] FROM [users] WHERE status = @p2 ORDER BY [name] ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{10, "active"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildSelect(gqbd.MSSQL, "users", "id").
+		Where("status = ?", "active").
+		Paginate(3, 10).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT [id] FROM [users] WHERE status = @p1 ORDER BY (SELECT NULL) OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 20, 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+BuildInsert
+
+@ Return: INSERT with an OUTPUT clause in place of RETURNING
+*/
+func TestBuildInsertMSSQL(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.MSSQL, "users").
+		Values(map[string]interface{}{"email": "a@b.c", "name": "Alice"}).
+		Returning("id, created_at").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO [users] ([email], [name]) OUTPUT INSERTED.[id], INSERTED.[created_at] VALUES (@p1, @p2)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a@b.c", "Alice"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	_, _, err = gqbd.BuildInsert(gqbd.MSSQL, "users").
+		Values(map[string]interface{}{"email": "a@b.c"}).
+		OnConflict("email").
+		DoNothing().
+		Build()
+	if err == nil {
+		t.Error("expected error for OnConflict on MSSQL")
+	}
+}
+
+/*
+WhereRaw
+
+@ Return: Native @pN placeholders renumbered after preceding args
+*/
+func TestWhereRawMSSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MSSQL, "documents", "id").
+		WhereEq("owner_id", 7).
+		WhereRaw("CONTAINS(body, @p1)", "urgent").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT [id] FROM [documents] WHERE [owner_id] = @p1 AND CONTAINS(body, @p2)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, "urgent"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+BuildNamed
+
+@ Return: @name placeholders with sql.NamedArg args, positional values named p1, p2, ... and repeated names bound once
+*/
+func TestBuildNamedMSSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MSSQL, "users", "id").
+		Where("status = :status OR backup_status = :status", gqbd.Named("status", "active")).
+		Where("age > ?", 18).
+		Limit(10).
+		BuildNamed()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT TOP (@p1) [id] FROM [users] WHERE status = @status OR backup_status = @status AND age > @p2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{sql.Named("p1", 10), sql.Named("status", "active"), sql.Named("p2", 18)}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+WhereTupleIn
+
+@ Return: Tuple IN expanded to OR-ed equality groups
+*/
+func TestWhereTupleInMSSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.MSSQL, "memberships", "user_id").
+		WhereTupleIn([]string{"org_id", "team_id"}, [][]interface{}{{1, "x"}, {2, "y"}}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT [user_id] FROM [memberships] WHERE (([org_id] = @p1 AND [team_id] = @p2) OR ([org_id] = @p3 AND [team_id] = @p4))"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "x", 2, "y"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+BuildBatches with Expression values
+
+@ Return: Chunks sized from the args each row binds, and an error for a row over the parameter limit
+*/
+func TestMSSQLBuildBatchesExpr(t *testing.T) {
+	rows := make([]map[string]interface{}, 3000)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": i, "score": gqbd.Expr("COALESCE(?, ?)", i, 0)}
+	}
+	batches, err := gqbd.BuildInsert(gqbd.MSSQL, "scores").InsertBatch(rows).BuildBatches()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	total := 0
+	for _, batch := range batches {
+		if len(batch.Args) > 2100 {
+			t.Errorf("batch binds %d parameters, over the MSSQL limit", len(batch.Args))
+		}
+		total += len(batch.Args)
+	}
+	if len(batches) != 5 || total != 9000 {
+		t.Errorf("expected 5 batches binding 9000 args, got %d batches and %d args", len(batches), total)
+	}
+
+	wide := make([]interface{}, 2101)
+	_, err = gqbd.BuildInsert(gqbd.MSSQL, "scores").
+		InsertBatch([]map[string]interface{}{{"id": 1, "score": gqbd.Expr("COALESCE("+strings.Repeat("?, ", 2100)+"?)", wide...)}}).
+		BuildBatches()
+	if err == nil || !strings.Contains(err.Error(), "row 0 binds 2102 parameters") {
+		t.Errorf("expected parameter limit error, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package gqbd
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// NamedArg is a value bound to a :name placeholder.
+type NamedArg struct {
+	Name  string
+	Value interface{}
+}
+
+/*
+Named
+
+@ name: Placeholder name, written as :name in the condition
+@ value: Value to bind
+@ Return: NamedArg for Where and Having, e.g. Where("status = :status", Named("status", "active"))
+*/
+func Named(name string, value interface{}) NamedArg {
+	return NamedArg{Name: name, Value: value}
+}
+
+// StructArgs binds the `db`-tagged fields of a struct to :name placeholders; fields not used by the condition are ignored.
+type StructArgs struct {
+	Value interface{}
+}
+
+/*
+NamedStruct
+
+@ v: Struct or pointer to struct with `db:"column"` tags, e.g. a model also used with sqlx
+@ Return: StructArgs for Where and Having, e.g. Where("status = :status AND org_id = :org_id", NamedStruct(filter))
+*/
+func NamedStruct(v interface{}) StructArgs {
+	return StructArgs{Value: v}
+}
+
+// NamedDialect is implemented by dialects whose drivers accept sql.Named arguments, enabling BuildNamed.
+type NamedDialect interface {
+	// NamedPlaceholder returns the placeholder referring to the named argument, e.g. "@name".
+	NamedPlaceholder(name string) string
+}
+
+func (mssqlDialect) NamedPlaceholder(name string) string { return "@" + name }
+
+func (oracleDialect) NamedPlaceholder(name string) string { return ":" + name }
+
+func (sqliteDialect) NamedPlaceholder(name string) string { return ":" + name }
+
+/*
+namedArgs
+
+@ args: Arguments passed to Where or Having
+@ Return: Arguments by name when they are NamedArg values, a single map[string]interface{} or StructArgs, and whether they are named.
+Error if named and positional arguments are mixed
+*/
+func namedArgs(args []interface{}) (map[string]interface{}, bool, error) {
+	if len(args) == 1 {
+		switch arg := args[0].(type) {
+		case map[string]interface{}:
+			return arg, true, nil
+		case StructArgs:
+			columns, values, err := structColumns(arg.Value, []StructOption{IncludeZeroValues()})
+			if err != nil {
+				return nil, false, err
+			}
+			named := make(map[string]interface{}, len(columns))
+			for i, col := range columns {
+				named[col] = values[i]
+			}
+			return named, true, nil
+		}
+	}
+	var named map[string]interface{}
+	for _, arg := range args {
+		na, ok := arg.(NamedArg)
+		if !ok {
+			continue
+		}
+		if named == nil {
+			named = make(map[string]interface{}, len(args))
+		}
+		named[na.Name] = na.Value
+	}
+	if named == nil {
+		return nil, false, nil
+	}
+	if len(named) != len(args) {
+		return nil, false, fmt.Errorf("named and positional arguments cannot be mixed")
+	}
+	return named, true, nil
+}
+
+/*
+bindNamed
+
+@ condition: Condition with :name placeholders
+@ args: Arguments passed with the condition
+@ Return: Condition with "?" placeholders and NamedArg values in placeholder order, unchanged when args are positional,
+and error if a placeholder has no argument or an argument other than a StructArgs field is not used.
+Casts such as "::int" and text inside single quotes are left alone
+*/
+func bindNamed(condition string, args []interface{}) (string, []interface{}, error) {
+	named, ok, err := namedArgs(args)
+	if err != nil || !ok {
+		return condition, args, err
+	}
+	var sb strings.Builder
+	var bound []interface{}
+	used := make(map[string]bool, len(named))
+	inQuote := false
+	for i := 0; i < len(condition); i++ {
+		ch := condition[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+		case inQuote || ch != ':':
+		case i+1 < len(condition) && condition[i+1] == ':':
+			sb.WriteString("::")
+			i++
+			continue
+		case i+1 < len(condition) && isNameStart(condition[i+1]):
+			j := i + 1
+			for j < len(condition) && isNamePart(condition[j]) {
+				j++
+			}
+			name := condition[i+1 : j]
+			value, ok := named[name]
+			if !ok {
+				return "", nil, fmt.Errorf("named placeholder :%s has no matching argument", name)
+			}
+			used[name] = true
+			sb.WriteByte('?')
+			bound = append(bound, NamedArg{Name: name, Value: value})
+			i = j - 1
+			continue
+		}
+		sb.WriteByte(ch)
+	}
+	if _, partial := args[0].(StructArgs); partial {
+		return sb.String(), bound, nil
+	}
+	for name := range named {
+		if !used[name] {
+			return "", nil, fmt.Errorf("named argument %s is not used in the condition", name)
+		}
+	}
+	return sb.String(), bound, nil
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isNamePart(ch byte) bool {
+	return isNameStart(ch) || ch >= '0' && ch <= '9'
+}
+
+/*
+BuildNamed
+
+@ Return: Query string using the dialect's named placeholders, arguments as sql.NamedArg values, and error if any.
+Positional arguments are named p1, p2, ... in order. Only dialects implementing NamedDialect (MSSQL, Oracle, SQLite) are supported
+*/
+func (qb *QueryBuilder) BuildNamed() (string, []interface{}, error) {
+	if _, ok := dialectOf(qb.dbType).Dialect.(NamedDialect); !ok {
+		return "", nil, fmt.Errorf("BuildNamed() is not supported for db type: %v", qb.dbType)
+	}
+	named := *qb
+	named.namedOutput = true
+	return named.Build()
+}
+
+/*
+namedPlaceholder
+
+@ arg: Argument to bind, either a NamedArg or a positional value
+@ Return: Named placeholder for the argument; the argument is recorded once per name as sql.NamedArg
+*/
+func (w *queryWriter) namedPlaceholder(arg interface{}) string {
+	na, ok := arg.(NamedArg)
+	if !ok {
+		w.positional++
+		na = NamedArg{Name: fmt.Sprintf("p%d", w.positional), Value: arg}
+	}
+	if w.names == nil {
+		w.names = make(map[string]interface{})
+	}
+	if prev, seen := w.names[na.Name]; seen {
+		if !reflect.DeepEqual(prev, na.Value) && w.err == nil {
+			w.err = fmt.Errorf("named argument %s is bound to different values", na.Name)
+		}
+	} else {
+		w.names[na.Name] = na.Value
+		w.args = append(w.args, sql.Named(na.Name, na.Value))
+	}
+	return w.dialect.Dialect.(NamedDialect).NamedPlaceholder(na.Name)
+}
+
+/*
+plainArg
+
+@ arg: Argument as given to the builder
+@ Return: Value of a NamedArg, other arguments unchanged
+*/
+func plainArg(arg interface{}) interface{} {
+	if na, ok := arg.(NamedArg); ok {
+		return na.Value
+	}
+	return arg
+}
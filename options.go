@@ -0,0 +1,134 @@
+package gqbd
+
+import "fmt"
+
+// BuilderOption configures a builder when it is created, e.g. with BuildSelectWith.
+type BuilderOption func(*QueryBuilder)
+
+/*
+WithSchema
+
+@ schema: Schema the table belongs to, e.g. "app"; empty keeps the table unqualified
+@ Return: BuilderOption qualifying the builder's table as schema.table
+*/
+func WithSchema(schema string) BuilderOption {
+	return func(qb *QueryBuilder) {
+		if schema == "" {
+			return
+		}
+		safeTable, err := EscapeIdentifier(qb.dbType, schema+"."+qb.tableName)
+		if err != nil {
+			qb.err = err
+			return
+		}
+		qb.table = safeTable
+		qb.schema = schema
+	}
+}
+
+/*
+WithStrict
+
+@ Return: BuilderOption enabling strict mode, as Strict does
+*/
+func WithStrict() BuilderOption {
+	return func(qb *QueryBuilder) {
+		qb.strict = true
+	}
+}
+
+/*
+WithMaxLimit
+
+@ max: Largest number of rows a SELECT may return
+@ Return: BuilderOption capping LIMIT at max; a SELECT without a LIMIT gets LIMIT max.
+BuildCount and SumExec ignore the cap, and ChunkByID and ChunkBy reject chunks larger than max
+*/
+func WithMaxLimit(max int) BuilderOption {
+	return func(qb *QueryBuilder) {
+		if max < 1 {
+			qb.err = fmt.Errorf("WithMaxLimit() must be positive, got %d", max)
+			return
+		}
+		qb.maxLimit = max
+	}
+}
+
+/*
+applyOptions
+
+@ qb: Newly created builder
+@ opts: Options to apply in order
+@ Return: qb with the options applied, stopping at the first error
+*/
+func applyOptions(qb *QueryBuilder, opts []BuilderOption) *QueryBuilder {
+	for _, opt := range opts {
+		if qb.err != nil {
+			break
+		}
+		opt(qb)
+	}
+	return qb
+}
+
+/*
+NewQueryBuilderWith
+
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
+@ table: Table name
+@ columns: Columns to select
+@ opts: Builder options, e.g. WithSchema, WithStrict or WithMaxLimit
+@ Return: *QueryBuilder instance with the options applied
+*/
+func NewQueryBuilderWith(dbType DBType, table string, columns []string, opts ...BuilderOption) *QueryBuilder {
+	return applyOptions(NewQueryBuilder(dbType, table, columns...), opts)
+}
+
+/*
+BuildSelectWith
+
+@ dbType: Database type
+@ table: Table name
+@ columns: Columns to select
+@ opts: Builder options
+@ Return: *QueryBuilder with SELECT operation and the options applied
+*/
+func BuildSelectWith(dbType DBType, table string, columns []string, opts ...BuilderOption) *QueryBuilder {
+	return applyOptions(BuildSelect(dbType, table, columns...), opts)
+}
+
+/*
+BuildInsertWith
+
+@ dbType: Database type
+@ table: Table name
+@ opts: Builder options
+@ Return: *QueryBuilder with INSERT operation and the options applied
+*/
+func BuildInsertWith(dbType DBType, table string, opts ...BuilderOption) *QueryBuilder {
+	return applyOptions(BuildInsert(dbType, table), opts)
+}
+
+/*
+BuildUpdateWith
+
+@ dbType: Database type
+@ table: Table name
+@ opts: Builder options
+@ Return: *QueryBuilder with UPDATE operation and the options applied
+*/
+func BuildUpdateWith(dbType DBType, table string, opts ...BuilderOption) *QueryBuilder {
+	return applyOptions(BuildUpdate(dbType, table), opts)
+}
+
+/*
+BuildDeleteWith
+
+@ dbType: Database type
+@ table: Table name
+@ opts: Builder options
+@ Return: *QueryBuilder with DELETE operation and the options applied
+*/
+func BuildDeleteWith(dbType DBType, table string, opts ...BuilderOption) *QueryBuilder {
+	return applyOptions(BuildDelete(dbType, table), opts)
+}
@@ -0,0 +1,63 @@
+package gqbd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+BuildSelect
+
+@ Return: Upper-cased quoted identifiers, :N placeholders and OFFSET/FETCH pagination
+*/
+func TestBuildSelectOracle(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.Oracle, "users", "id", "display name").
+		Where("status = ?", "active").
+		OrderBy("id", "ASC", nil).
+		Limit(10).
+		Offset(20).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "ID", "display name" FROM "USERS" WHERE status = :1 ORDER BY "ID" ASC OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 20, 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildSelect(gqbd.Oracle, "users", "id").Limit(5).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = `SELECT "ID" FROM "USERS" FETCH FIRST :1 ROWS ONLY`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+BuildCount
+
+@ Return: Derived table aliased without AS
+*/
+func TestBuildCountOracle(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.Oracle, "orders", "customer_id").
+		Distinct().
+		Where("total > ?", 100).
+		BuildCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT COUNT(*) FROM (SELECT DISTINCT "CUSTOMER_ID" FROM "ORDERS" WHERE total > :1) gqbd_count`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{100}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
@@ -0,0 +1,408 @@
+package gqbd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+SeekAfter
+
+@ columns: Cursor columns, most significant first; the last should be unique (e.g. primary key)
+@ values: Cursor values of the last row of the previous page, one per column
+@ direction: Order direction ("ASC" or "DESC"); rows after the cursor in that order are returned
+@ Return: *QueryBuilder with the keyset condition and matching ORDER BY added
+*/
+func (qb *QueryBuilder) SeekAfter(columns []string, values []interface{}, direction string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "SELECT" {
+		qb.err = fmt.Errorf("SeekAfter() can only be used with SELECT operation")
+		return qb
+	}
+	if len(columns) == 0 || len(columns) != len(values) {
+		qb.err = fmt.Errorf("SeekAfter() requires one value per column, got %d columns and %d values", len(columns), len(values))
+		return qb
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	orderCols := make([]string, len(safeCols))
+	for i, safeCol := range safeCols {
+		orderCols[i] = qb.collateOrder(safeCol)
+		safeCols[i] = qb.collate(safeCol, values[i])
+	}
+	direction = ValidateDirection(direction)
+	op := ">"
+	if direction == "DESC" {
+		op = "<"
+	}
+
+	if len(safeCols) > 1 && dialectOf(qb.dbType).Features().RowValues {
+		qb.conditions = append(qb.conditions, clause{
+			sql:  fmt.Sprintf("(%s) %s (%s)", strings.Join(safeCols, ", "), op, strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")),
+			args: values,
+		})
+	} else {
+		// (a, b) > (x, y) expands to a > x OR (a = x AND b > y), which MariaDB can use indexes for.
+		var ors []string
+		var args []interface{}
+		for i := range safeCols {
+			var ands []string
+			for j := 0; j < i; j++ {
+				ands = append(ands, safeCols[j]+" = ?")
+				args = append(args, values[j])
+			}
+			ands = append(ands, fmt.Sprintf("%s %s ?", safeCols[i], op))
+			args = append(args, values[i])
+			if len(ands) == 1 {
+				ors = append(ors, ands[0])
+			} else {
+				ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+			}
+		}
+		sql := strings.Join(ors, " OR ")
+		if len(ors) > 1 {
+			sql = "(" + sql + ")"
+		}
+		qb.conditions = append(qb.conditions, clause{sql: sql, args: args})
+	}
+
+	for _, orderCol := range orderCols {
+		qb.orderBy = append(qb.orderBy, clause{sql: orderCol + " " + direction})
+	}
+	return qb
+}
+
+// Defaults applied by Paginate.
+const (
+	DefaultPerPage    = 20
+	DefaultMaxPerPage = 100
+)
+
+// Page describes the effective page of a paginated query.
+type Page struct {
+	Number  int // 1-based page number
+	PerPage int // Rows per page after clamping
+	Offset  int // Rows skipped
+}
+
+/*
+TotalPages
+
+@ total: Total number of rows, e.g. from BuildCount
+@ Return: Number of pages needed to show every row
+*/
+func (p Page) TotalPages(total int) int {
+	if p.PerPage <= 0 || total <= 0 {
+		return 0
+	}
+	return (total + p.PerPage - 1) / p.PerPage
+}
+
+/*
+MaxPerPage
+
+@ max: Largest page size accepted by Paginate; must be called before Paginate
+@ Return: *QueryBuilder with the page size limit set
+*/
+func (qb *QueryBuilder) MaxPerPage(max int) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if max < 1 {
+		qb.err = fmt.Errorf("MaxPerPage() must be positive, got %d", max)
+		return qb
+	}
+	qb.maxPerPage = max
+	return qb
+}
+
+/*
+Paginate
+
+@ page: 1-based page number; values below 1 select the first page
+@ perPage: Rows per page; values below 1 use DefaultPerPage, values above the limit are clamped to MaxPerPage
+@ Return: *QueryBuilder with LIMIT and OFFSET set for the page
+*/
+func (qb *QueryBuilder) Paginate(page, perPage int) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "SELECT" {
+		qb.err = fmt.Errorf("Paginate() can only be used with SELECT operation")
+		return qb
+	}
+	max := qb.maxPerPage
+	if max == 0 {
+		max = DefaultMaxPerPage
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+	if perPage > max {
+		perPage = max
+	}
+	qb.page = &Page{Number: page, PerPage: perPage, Offset: (page - 1) * perPage}
+	qb.limit = perPage
+	qb.offset = qb.page.Offset
+	return qb
+}
+
+/*
+PageInfo
+
+@ Return: Effective page set by Paginate, and whether Paginate was called
+*/
+func (qb *QueryBuilder) PageInfo() (Page, bool) {
+	if qb.page == nil {
+		return Page{}, false
+	}
+	return *qb.page, true
+}
+
+/*
+BuildCount
+
+@ Return: SELECT COUNT(*) over the same FROM/JOIN/WHERE without ORDER BY/LIMIT/OFFSET, arguments slice, and error if any.
+Queries with GROUP BY, HAVING or DISTINCT are counted through a subquery
+*/
+func (qb *QueryBuilder) BuildCount() (string, []interface{}, error) {
+	if qb.err != nil {
+		return "", nil, qb.err
+	}
+	if qb = qb.withScopes(); qb.err != nil {
+		return "", nil, qb.err
+	}
+	if qb.op != "SELECT" {
+		return "", nil, fmt.Errorf("BuildCount() can only be used with SELECT operation")
+	}
+	if qb.strict {
+		if warnings := qb.Warnings(); len(warnings) > 0 {
+			return "", nil, fmt.Errorf("strict mode: %s", strings.Join(warnings, "; "))
+		}
+	}
+	inner := *qb
+	inner.orderBy = nil
+	inner.limit = 0
+	inner.maxLimit = 0
+	inner.offset = 0
+	w := newQueryWriter(qb.dbType)
+	if len(qb.groupBy) > 0 || len(qb.having) > 0 || qb.distinct {
+		w.write("SELECT COUNT(*) FROM (")
+		w.derived = true
+		inner.writeSelect(w)
+		w.write(")" + tableAlias(qb.dbType, "gqbd_count"))
+		return w.String(), w.args, nil
+	}
+	inner.columns = []clause{{sql: "COUNT(*)"}}
+	inner.writeSelect(w)
+	return w.String(), w.args, nil
+}
+
+// Pagination is a strategy for splitting a SELECT into pages, selected per builder with PaginateWith.
+type Pagination interface {
+	// Apply adds the strategy's clauses to the builder.
+	Apply(qb *QueryBuilder) (*QueryBuilder, error)
+	// Meta describes the fetched page; lastRow holds the cursor column values of the last fetched row, if any.
+	Meta(qb *QueryBuilder, fetched int, lastRow []interface{}) (PageMeta, error)
+}
+
+// PageMeta is the response metadata of a fetched page.
+type PageMeta struct {
+	HasMore    bool          // Whether another page probably follows (the page was full)
+	Page       *Page         // Effective page, for LimitOffset
+	NextCursor []interface{} // Cursor values for the next page, for Keyset
+	NextToken  string        // Opaque token for the next page, for CursorToken
+}
+
+/*
+PaginateWith
+
+@ p: Pagination strategy
+@ Return: *QueryBuilder with the strategy's clauses added
+*/
+func (qb *QueryBuilder) PaginateWith(p Pagination) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	paged, err := p.Apply(qb)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	return paged
+}
+
+// LimitOffset pages by page number, using Paginate.
+type LimitOffset struct {
+	Page    int // 1-based page number
+	PerPage int // Rows per page, clamped like Paginate
+}
+
+/*
+Apply
+
+@ qb: SELECT builder
+@ Return: *QueryBuilder with LIMIT and OFFSET set, and error if any
+*/
+func (p LimitOffset) Apply(qb *QueryBuilder) (*QueryBuilder, error) {
+	qb = qb.Paginate(p.Page, p.PerPage)
+	return qb, qb.err
+}
+
+/*
+Meta
+
+@ qb: Builder the strategy was applied to
+@ fetched: Number of rows fetched
+@ lastRow: Unused
+@ Return: PageMeta with the effective page
+*/
+func (p LimitOffset) Meta(qb *QueryBuilder, fetched int, lastRow []interface{}) (PageMeta, error) {
+	page, ok := qb.PageInfo()
+	if !ok {
+		return PageMeta{}, fmt.Errorf("LimitOffset was not applied to the builder")
+	}
+	return PageMeta{HasMore: fetched >= page.PerPage, Page: &page}, nil
+}
+
+// Keyset pages by the cursor column values of the last row, using SeekAfter.
+type Keyset struct {
+	Columns   []string      // Cursor columns, the last one unique
+	After     []interface{} // Cursor of the previous page, nil for the first page
+	Direction string        // "ASC" or "DESC"
+	Size      int           // Rows per page
+}
+
+/*
+Apply
+
+@ qb: SELECT builder
+@ Return: *QueryBuilder with the keyset condition, ORDER BY and LIMIT added, and error if any
+*/
+func (p Keyset) Apply(qb *QueryBuilder) (*QueryBuilder, error) {
+	if p.Size < 1 {
+		return qb, fmt.Errorf("keyset page size must be positive, got %d", p.Size)
+	}
+	if p.After != nil {
+		qb = qb.SeekAfter(p.Columns, p.After, p.Direction)
+	} else {
+		qb = qb.mutable()
+		for _, col := range p.Columns {
+			if qb.err != nil {
+				return qb, qb.err
+			}
+			qb = qb.setOrderBy(col, p.Direction)
+		}
+	}
+	qb = qb.Limit(p.Size)
+	return qb, qb.err
+}
+
+/*
+Meta
+
+@ qb: Builder the strategy was applied to
+@ fetched: Number of rows fetched
+@ lastRow: Cursor column values of the last fetched row
+@ Return: PageMeta with the cursor of the next page when the page was full
+*/
+func (p Keyset) Meta(qb *QueryBuilder, fetched int, lastRow []interface{}) (PageMeta, error) {
+	if fetched < p.Size {
+		return PageMeta{}, nil
+	}
+	if len(lastRow) != len(p.Columns) {
+		return PageMeta{}, fmt.Errorf("expected %d cursor values, got %d", len(p.Columns), len(lastRow))
+	}
+	return PageMeta{HasMore: true, NextCursor: lastRow}, nil
+}
+
+// CursorToken pages like Keyset, with the cursor passed around as an opaque token.
+type CursorToken struct {
+	Columns   []string // Cursor columns, the last one unique
+	Token     string   // Token of the previous page, empty for the first page
+	Direction string   // "ASC" or "DESC"
+	Size      int      // Rows per page
+}
+
+/*
+Apply
+
+@ qb: SELECT builder
+@ Return: *QueryBuilder with the decoded keyset condition, ORDER BY and LIMIT added, and error if the token is invalid
+*/
+func (p CursorToken) Apply(qb *QueryBuilder) (*QueryBuilder, error) {
+	var after []interface{}
+	if p.Token != "" {
+		var err error
+		if after, err = decodeCursor(p.Token); err != nil {
+			return qb, err
+		}
+	}
+	return Keyset{Columns: p.Columns, After: after, Direction: p.Direction, Size: p.Size}.Apply(qb)
+}
+
+/*
+Meta
+
+@ qb: Builder the strategy was applied to
+@ fetched: Number of rows fetched
+@ lastRow: Cursor column values of the last fetched row
+@ Return: PageMeta with the token of the next page when the page was full
+*/
+func (p CursorToken) Meta(qb *QueryBuilder, fetched int, lastRow []interface{}) (PageMeta, error) {
+	meta, err := Keyset{Columns: p.Columns, Size: p.Size}.Meta(qb, fetched, lastRow)
+	if err != nil || !meta.HasMore {
+		return meta, err
+	}
+	data, err := json.Marshal(meta.NextCursor)
+	if err != nil {
+		return PageMeta{}, err
+	}
+	return PageMeta{HasMore: true, NextToken: base64.RawURLEncoding.EncodeToString(data)}, nil
+}
+
+/*
+decodeCursor
+
+@ token: Token produced by CursorToken.Meta
+@ Return: Cursor values, with integral numbers as int64, and error if the token is invalid
+*/
+func decodeCursor(token string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor token: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values []interface{}
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid cursor token: %w", err)
+	}
+	for i, v := range values {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if iv, err := n.Int64(); err == nil {
+			values[i] = iv
+		} else if fv, err := n.Float64(); err == nil {
+			values[i] = fv
+		}
+	}
+	return values, nil
+}
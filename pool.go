@@ -0,0 +1,70 @@
+package gqbd
+
+import "sync"
+
+// maxPooledCap is the largest slice capacity a released builder keeps, so one huge query does not pin its
+// buffers in the pool.
+const maxPooledCap = 64
+
+var builderPool = sync.Pool{New: func() interface{} { return new(QueryBuilder) }}
+
+/*
+Get
+
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
+@ table: Table name
+@ columns: Columns to select
+@ Return: SELECT *QueryBuilder taken from a pool, as BuildSelect. Call Release once the query is built
+and its args are no longer needed by the builder
+*/
+func Get(dbType DBType, table string, columns ...string) *QueryBuilder {
+	qb := builderPool.Get().(*QueryBuilder)
+	qb.init(dbType, table, columns)
+	qb.op = "SELECT"
+	return qb
+}
+
+/*
+Release
+
+@ Return: None. Resets the builder and returns it to the pool used by Get. The builder must not be used
+afterwards. Frozen builders may be shared and are left untouched
+*/
+func (qb *QueryBuilder) Release() {
+	if qb == nil || qb.frozen {
+		return
+	}
+	qb.reset()
+	builderPool.Put(qb)
+}
+
+/*
+reset
+
+@ Return: None. Clears every field, keeping the capacity of the clause slices the builder owns
+*/
+func (qb *QueryBuilder) reset() {
+	*qb = QueryBuilder{
+		columns:    recycle(qb.columns),
+		joins:      recycle(qb.joins),
+		conditions: recycle(qb.conditions),
+		groupBy:    recycle(qb.groupBy),
+		having:     recycle(qb.having),
+		orderBy:    recycle(qb.orderBy),
+		aliases:    recycle(qb.aliases),
+	}
+}
+
+/*
+recycle
+
+@ s: Slice owned by a released builder
+@ Return: s emptied with its elements zeroed so they release their references, or nil if it is too large to keep
+*/
+func recycle[S ~[]E, E any](s S) S {
+	if cap(s) > maxPooledCap {
+		return nil
+	}
+	clear(s)
+	return s[:0]
+}
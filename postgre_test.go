@@ -1,9 +1,13 @@
 package gqbd_test
 
 import (
+	"fmt"
+	"log/slog"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/donghquinn/gqbd"
 )
@@ -119,3 +123,2593 @@ func TestBuildDeletePostgreSQL(t *testing.T) {
 		t.Errorf("expected args %v, got %v", expectedArgs, args)
 	}
 }
+
+/*
+OrderByStrict
+
+@ Return: Error when the requested column is not in the allowlist
+*/
+func TestOrderByStrictPostgreSQL(t *testing.T) {
+	allowed := map[string]bool{"col1": true}
+
+	_, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "table_name", "col1").
+		OrderByStrict("col2", "ASC", allowed).
+		Build()
+	if err == nil {
+		t.Fatalf("expected error for disallowed order column")
+	}
+
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "table_name", "col1").
+		DefaultOrderColumn("created_at").
+		OrderBy("col2", "ASC", allowed).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"col1\" FROM \"table_name\" ORDER BY \"created_at\" ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+OrderByExpr
+
+@ Return: SELECT query with parameterized ORDER BY expression numbered after WHERE args
+*/
+func TestOrderByExprPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "table_name", "col1").
+		OrderByExpr("CASE WHEN status = ? THEN 0 ELSE 1 END", "active").
+		OrderBy("col1", "DESC", nil).
+		Where("col1 > ?", 100)
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"col1\" FROM \"table_name\" WHERE col1 > $1 ORDER BY CASE WHEN status = $2 THEN 0 ELSE 1 END, \"col1\" DESC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{100, "active"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+Partition key validation
+
+@ Return: Warning (or strict mode error) when a partitioned table is read without a partition key filter
+*/
+func TestPartitionFilterPostgreSQL(t *testing.T) {
+	registry := gqbd.NewSchemaRegistry().
+		Register("events", gqbd.TableSchema{PartitionKey: "created_on"})
+
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "events", "id").
+		WithRegistry(registry).
+		Where("user_id = ?", 1)
+	if _, _, err := qb.Build(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(qb.Warnings()) != 1 {
+		t.Errorf("expected 1 warning, got %v", qb.Warnings())
+	}
+
+	if _, _, err := qb.Strict().Build(); err == nil {
+		t.Errorf("expected strict mode error for missing partition key filter")
+	}
+
+	filtered := gqbd.BuildSelect(gqbd.PostgreSQL, "events", "id").
+		WithRegistry(registry).
+		Strict().
+		Where("user_id = ?", 1).
+		Where("e.created_on >= ?", "2024-01-01")
+	if _, _, err := filtered.Build(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for condition, warns := range map[string]bool{
+		"created_on = ?":            false,
+		"day = ? OR created_on = ?": false,
+		"created_on_date = ?":       true,
+		"old_created_on = ?":        true,
+		"\"created_on_v2\" = ?":     true,
+	} {
+		args := make([]interface{}, strings.Count(condition, "?"))
+		qb := gqbd.BuildSelect(gqbd.PostgreSQL, "events", "id").WithRegistry(registry).Where(condition, args...)
+		if got := len(qb.Warnings()) == 1; got != warns {
+			t.Errorf("%s: expected warning %v, got %v", condition, warns, qb.Warnings())
+		}
+	}
+}
+
+/*
+Raw
+
+@ Return: Raw expressions emitted verbatim only through SelectRaw, GroupByRaw and OrderByRaw
+*/
+func TestRawPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users").
+		SelectRaw(gqbd.Raw("COALESCE(name, '') AS name")).
+		Aggregate("COUNT", "id", gqbd.AggregateDistinct()).
+		GroupByRaw(gqbd.Raw("COALESCE(name, '')")).
+		OrderByRaw(gqbd.Raw("lower(name)"), "ASC")
+
+	query, _, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT COALESCE(name, '') AS name, COUNT(DISTINCT \"id\") FROM \"users\" GROUP BY COALESCE(name, '') ORDER BY lower(name) ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	injected := "\x00raw:1=1 OR tenant_id"
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").WhereMap(map[string]interface{}{injected: 1}).Build(); err == nil {
+		t.Error("expected error for raw marker in WhereMap key")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").OrderBy(injected, "ASC", nil).Build(); err == nil {
+		t.Error("expected error for raw marker in OrderBy column")
+	}
+	if _, err := gqbd.EscapeIdentifier(gqbd.PostgreSQL, injected); err == nil {
+		t.Error("expected EscapeIdentifier to reject raw marker")
+	}
+}
+
+/*
+Case
+
+@ Return: CASE expressions in SELECT and ORDER BY with parameterized WHEN arguments
+*/
+func TestCasePostgreSQL(t *testing.T) {
+	label := gqbd.Case().
+		When("status = ?", "open", "active").
+		When("status = ?", "closed", "archived").
+		Else("unknown").
+		As("label")
+	priority := gqbd.Case().When("status = ?", 0, "active").Else(1).End()
+
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "tickets", "id").
+		SelectExpr(label).
+		Where("owner_id = ?", 7).
+		OrderByExpression(priority, "ASC")
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", CASE WHEN status = $1 THEN $2 WHEN status = $3 THEN $4 ELSE $5 END AS \"label\" FROM \"tickets\" WHERE owner_id = $6 ORDER BY CASE WHEN status = $7 THEN $8 ELSE $9 END ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"active", "open", "archived", "closed", "unknown", 7, "active", 0, 1}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "tickets").SelectExpr(gqbd.Case().As("x")).Build(); err == nil {
+		t.Errorf("expected error for CASE without WHEN")
+	}
+}
+
+/*
+Aggregate with options
+
+@ Return: Aggregates with alias, DISTINCT and FILTER clause bound before WHERE args
+*/
+func TestAggregateOptionsPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "customer_id").
+		Aggregate("COUNT", "product_id", gqbd.AggregateDistinct(), gqbd.AggregateAs("products")).
+		Aggregate("SUM", "amount", gqbd.AggregateFilter("status = ?", "paid"), gqbd.AggregateAs("paid_total")).
+		Where("created_at >= ?", "2024-01-01").
+		GroupBy("customer_id")
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"customer_id\", COUNT(DISTINCT \"product_id\") AS \"products\", SUM(\"amount\") FILTER (WHERE status = $1) AS \"paid_total\" FROM \"orders\" WHERE created_at >= $2 GROUP BY \"customer_id\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"paid", "2024-01-01"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+Aggregate function validation
+
+@ Return: Error for unknown aggregate functions, RawAggregate emitted verbatim
+*/
+func TestAggregateFunctionPostgreSQL(t *testing.T) {
+	_, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders").
+		Aggregate("COUNT(*)); DROP TABLE orders; --", "id").
+		Build()
+	if err == nil {
+		t.Fatalf("expected error for unknown aggregate function")
+	}
+
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "region").
+		Aggregate("sum", "amount").
+		RawAggregate("percentile_agg", "amount").
+		GroupBy("region").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"region\", SUM(\"amount\"), percentile_agg(\"amount\") FROM \"orders\" GROUP BY \"region\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+GroupByRollup, GroupByCube and GroupingSets
+
+@ Return: Summary grouping clauses
+*/
+func TestGroupingPostgreSQL(t *testing.T) {
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "sales", "region", "product").
+		Aggregate("SUM", "amount").
+		GroupByRollup("region", "product").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"region\", \"product\", SUM(\"amount\") FROM \"sales\" GROUP BY ROLLUP (\"region\", \"product\")"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "sales", "region", "product").
+		GroupByCube("region", "product").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(query, "GROUP BY CUBE (\"region\", \"product\")") {
+		t.Errorf("expected CUBE grouping, got %s", query)
+	}
+
+	query, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "sales", "region", "product").
+		GroupingSets([]string{"region", "product"}, []string{"region"}, nil).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(query, "GROUP BY GROUPING SETS ((\"region\", \"product\"), (\"region\"), ())") {
+		t.Errorf("expected GROUPING SETS, got %s", query)
+	}
+}
+
+/*
+EscapeLike and QuoteIdentifier
+
+@ Return: Standalone escaping helpers matching the builder's rules
+*/
+func TestEscapeHelpersPostgreSQL(t *testing.T) {
+	if got := gqbd.EscapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("unexpected LIKE escaping: %s", got)
+	}
+	quoted, err := gqbd.QuoteIdentifier(gqbd.PostgreSQL, `we"ird`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if quoted != `"we""ird"` {
+		t.Errorf("unexpected quoted identifier: %s", quoted)
+	}
+	if _, err := gqbd.QuoteIdentifier("unknown", "name"); err == nil {
+		t.Errorf("expected error for unsupported db type")
+	}
+}
+
+/*
+InsertColumns and ValuesRow
+
+@ Return: Multi-row INSERT, with row length and registry column validation at build time
+*/
+func TestInsertRowsPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		InsertColumns("name", "age").
+		ValuesRow("kim", 30).
+		ValuesRow("lee", 25).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"users\" (\"name\", \"age\") VALUES ($1, $2), ($3, $4)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"kim", 30, "lee", 25}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+
+	_, _, err = gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		InsertColumns("name", "age").
+		ValuesRow("kim", 30).
+		ValuesRow("lee").
+		Build()
+	if err == nil {
+		t.Errorf("expected error for row length mismatch")
+	}
+
+	registry := gqbd.NewSchemaRegistry().
+		Register("users", gqbd.TableSchema{Columns: []string{"id", "name", "age"}})
+	_, _, err = gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		WithRegistry(registry).
+		InsertColumns("name", "agee").
+		ValuesRow("kim", 30).
+		Build()
+	if err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+}
+
+/*
+Generated aliases
+
+@ Return: Deterministic aliases for subqueries, and for aggregates of derived tables, with placeholders renumbered across the outer query
+*/
+func TestGeneratedAliasesPostgreSQL(t *testing.T) {
+	orderCount := gqbd.BuildSelect(gqbd.PostgreSQL, "orders").
+		Aggregate("COUNT", "*", gqbd.AggregateAs("n")).
+		Where("orders.user_id = users.id AND status = ?", "paid")
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		SelectSubquery(orderCount, "").
+		Aggregate("MAX", "score").
+		Where("active = ?", true)
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", (SELECT COUNT(*) AS \"n\" FROM \"orders\" WHERE orders.user_id = users.id AND status = $1) AS \"sub_1\", MAX(\"score\") FROM \"users\" WHERE active = $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"paid", true}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+	if !reflect.DeepEqual(qb.GeneratedAliases(), []string{"sub_1", "max_score"}) {
+		t.Errorf("unexpected generated aliases: %v", qb.GeneratedAliases())
+	}
+
+	inner := gqbd.BuildSelect(gqbd.PostgreSQL, "events", "user_id").
+		Aggregate("COUNT", "id").
+		Where("kind = ?", "login").
+		GroupBy("user_id")
+	query, args, err = gqbd.BuildSelectFrom(inner, "", "user_id").
+		Where("count_id > ?", 10).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"user_id\" FROM (SELECT \"user_id\", COUNT(\"id\") AS \"count_id\" FROM \"events\" WHERE kind = $1 GROUP BY \"user_id\") AS \"sub_1\" WHERE count_id > $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"login", 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = inner.BuildCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT COUNT(*) FROM (SELECT \"user_id\", COUNT(\"id\") AS \"count_id\" FROM \"events\" WHERE kind = $1 GROUP BY \"user_id\") AS gqbd_count"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+WhereMap
+
+@ Return: Equality conditions built from a map, with nil values as IS NULL
+*/
+func TestWhereMapPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		WhereMap(map[string]interface{}{
+			"tenant_id":  42,
+			"status":     "active",
+			"deleted_at": nil,
+		})
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE \"deleted_at\" IS NULL AND \"status\" = $1 AND \"tenant_id\" = $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"active", 42}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+}
+
+/*
+SplitAggregateFilters
+
+@ Return: Filters on aggregate aliases routed to HAVING, the rest to WHERE
+*/
+func TestSplitAggregateFiltersPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "customer_id").
+		Aggregate("SUM", "amount", gqbd.AggregateAs("total")).
+		Aggregate("COUNT", "id").
+		GroupBy("customer_id").
+		SplitAggregateFilters(
+			gqbd.Filter{Column: "total", Op: ">=", Value: 1000},
+			gqbd.Filter{Column: "region", Op: "=", Value: "EU"},
+			gqbd.Filter{Column: "count_id", Op: ">", Value: 3},
+		)
+
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"customer_id\", SUM(\"amount\") AS \"total\", COUNT(\"id\") FROM \"orders\" WHERE \"region\" = $1 GROUP BY \"customer_id\" HAVING SUM(\"amount\") >= $2 AND COUNT(\"id\") > $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"EU", 1000, 3}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "orders").
+		SplitAggregateFilters(gqbd.Filter{Column: "id", Op: "= 1 OR 1 =", Value: 1}).
+		Build()
+	if err == nil {
+		t.Errorf("expected error for unsupported operator")
+	}
+}
+
+/*
+WhereStruct
+
+@ Return: Equality conditions derived from db-tagged struct fields, skipping zero values by default
+*/
+func TestWhereStructPostgreSQL(t *testing.T) {
+	type Paging struct {
+		TenantID int `db:"tenant_id"`
+	}
+	type UserFilter struct {
+		Paging
+		Status   string  `db:"status"`
+		Role     *string `db:"role"`
+		MinAge   int     `db:"-"`
+		internal string
+	}
+
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		WhereStruct(UserFilter{Paging: Paging{TenantID: 42}, Status: "active"}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE \"tenant_id\" = $1 AND \"status\" = $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{42, "active"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		WhereStruct(&UserFilter{Status: "active"}, gqbd.IncludeZeroValues()).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\" FROM \"users\" WHERE \"tenant_id\" = $1 AND \"status\" = $2 AND \"role\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{0, "active"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").WhereStruct("status").Build(); err == nil {
+		t.Errorf("expected error for non-struct filter")
+	}
+}
+
+/*
+Upsert
+
+@ Return: INSERT with ON CONFLICT targeting columns or a named constraint
+*/
+func TestUpsertPostgreSQL(t *testing.T) {
+	data := map[string]interface{}{"email": "a@b.c", "name": "Alice"}
+
+	query, args, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		Values(data).
+		OnConflictConstraint("users_email_key").
+		DoUpdate("name").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"users\" (\"email\", \"name\") VALUES ($1, $2) ON CONFLICT ON CONSTRAINT \"users_email_key\" DO UPDATE SET \"name\" = EXCLUDED.\"name\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a@b.c", "Alice"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		Values(data).
+		OnConflict("email").
+		DoNothing().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "INSERT INTO \"users\" (\"email\", \"name\") VALUES ($1, $2) ON CONFLICT (\"email\") DO NOTHING"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	if _, _, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").Values(data).DoUpdate("name").Build(); err == nil {
+		t.Error("expected error for DoUpdate without conflict target")
+	}
+	if _, _, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").Values(data).OnConflict("email").Build(); err == nil {
+		t.Error("expected error for OnConflict without action")
+	}
+}
+
+/*
+Typed Comparisons
+
+@ Return: Escaped column comparisons with parameterized values
+*/
+func TestTypedComparisonsPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		WhereEq("status", "active").
+		WhereNotEq("role", "guest").
+		WhereGte("age", 18).
+		WhereLt("age", 65).
+		WhereLike("name", gqbd.EscapeLike("A_")+"%").
+		WhereEq("deleted_at", nil).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE \"status\" = $1 AND \"role\" <> $2 AND \"age\" >= $3 AND \"age\" < $4 AND \"name\" LIKE $5 AND \"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", "guest", 18, 65, "A\\_%"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+SeekAfter
+
+@ Return: Row-value keyset condition with matching ORDER BY
+*/
+func TestSeekAfterPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "posts", "id", "title").
+		Where("status = ?", "published").
+		SeekAfter([]string{"created_at", "id"}, []interface{}{"2024-05-01", 120}, "DESC").
+		Limit(20).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"title\" FROM \"posts\" WHERE status = $1 AND (\"created_at\", \"id\") < ($2, $3) ORDER BY \"created_at\" DESC, \"id\" DESC LIMIT $4"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"published", "2024-05-01", 120, 20}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "posts").SeekAfter([]string{"id"}, nil, "ASC").Build(); err == nil {
+		t.Error("expected error for missing cursor values")
+	}
+}
+
+/*
+WhereRaw
+
+@ Return: Native placeholders renumbered after preceding args, "?" operators untouched
+*/
+func TestWhereRawPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "documents", "id").
+		Where("owner_id = ?", 7).
+		WhereRaw("tags ? $1 AND attrs ?| $2", "urgent", []string{"a", "b"}).
+		WhereEq("status", "open").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"documents\" WHERE owner_id = $1 AND tags ? $2 AND attrs ?| $3 AND \"status\" = $4"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, "urgent", []string{"a", "b"}, "open"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "documents").WhereRaw("tags ? $2", "urgent").Build(); err == nil {
+		t.Error("expected error for placeholder without argument")
+	}
+}
+
+/*
+Paginate
+
+@ Return: LIMIT/OFFSET computed from page numbers, clamped to the page size limit
+*/
+func TestPaginatePostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		MaxPerPage(50).
+		Paginate(3, 500)
+	query, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" LIMIT $1 OFFSET $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{50, 100}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	page, ok := qb.PageInfo()
+	if !ok {
+		t.Fatal("expected page info")
+	}
+	if page != (gqbd.Page{Number: 3, PerPage: 50, Offset: 100}) {
+		t.Errorf("unexpected page: %+v", page)
+	}
+	if pages := page.TotalPages(101); pages != 3 {
+		t.Errorf("expected 3 pages, got %d", pages)
+	}
+
+	page, _ = gqbd.BuildSelect(gqbd.PostgreSQL, "users").Paginate(0, 0).PageInfo()
+	if page != (gqbd.Page{Number: 1, PerPage: gqbd.DefaultPerPage, Offset: 0}) {
+		t.Errorf("unexpected default page: %+v", page)
+	}
+}
+
+/*
+Union
+
+@ Return: Parenthesized parts with per-part LIMIT and an outer ORDER BY/LIMIT
+*/
+func TestUnionPostgreSQL(t *testing.T) {
+	recent := gqbd.BuildSelect(gqbd.PostgreSQL, "posts", "id", "created_at").
+		Where("author_id = ?", 1).
+		OrderBy("created_at", "DESC", nil).
+		Limit(10)
+	pinned := gqbd.BuildSelect(gqbd.PostgreSQL, "posts", "id", "created_at").
+		Where("pinned = ?", true)
+
+	query, args, err := gqbd.BuildUnionAll(gqbd.PostgreSQL, recent, pinned).
+		OrderBy("created_at", "DESC").
+		Limit(15).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "(SELECT \"id\", \"created_at\" FROM \"posts\" WHERE author_id = $1 ORDER BY \"created_at\" DESC LIMIT $2) UNION ALL (SELECT \"id\", \"created_at\" FROM \"posts\" WHERE pinned = $3) ORDER BY \"created_at\" DESC LIMIT $4"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, 10, true, 15}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildUnion(gqbd.PostgreSQL, recent, gqbd.BuildSelect(gqbd.MariaDB, "posts")).Build(); err == nil {
+		t.Error("expected error for mixed db types")
+	}
+}
+
+/*
+BuildCount
+
+@ Return: COUNT(*) over the same filters, wrapped in a subquery for grouped queries
+*/
+func TestBuildCountPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name").
+		LeftJoin("orders", "users.id = orders.user_id").
+		Where("users.status = ?", "active").
+		OrderBy("name", "ASC", nil).
+		Paginate(2, 10)
+
+	query, args, err := qb.BuildCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT COUNT(*) FROM \"users\" LEFT JOIN \"orders\" ON users.id = orders.user_id WHERE users.status = $1"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "user_id").
+		Where("status = ?", "paid").
+		GroupBy("user_id").
+		Having("COUNT(*) > ?", 2).
+		Limit(5).
+		BuildCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT COUNT(*) FROM (SELECT \"user_id\" FROM \"orders\" WHERE status = $1 GROUP BY \"user_id\" HAVING COUNT(*) > $2) AS gqbd_count"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"paid", 2}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Clone
+
+@ Return: Branches of one base query that do not share conditions or ordering
+*/
+func TestClonePostgreSQL(t *testing.T) {
+	base := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id", "total").
+		Where("tenant_id = ?", 7).
+		Where("deleted_at IS NULL").
+		Where("status <> ?", "draft")
+
+	paid := base.Clone().Where("status = ?", "paid").OrderBy("id", "DESC", nil)
+	refunded := base.Clone().Where("status = ?", "refunded")
+
+	query, args, err := paid.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"total\" FROM \"orders\" WHERE tenant_id = $1 AND deleted_at IS NULL AND status <> $2 AND status = $3 ORDER BY \"id\" DESC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, "draft", "paid"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = refunded.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\", \"total\" FROM \"orders\" WHERE tenant_id = $1 AND deleted_at IS NULL AND status <> $2 AND status = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, "draft", "refunded"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = base.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\", \"total\" FROM \"orders\" WHERE tenant_id = $1 AND deleted_at IS NULL AND status <> $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+Statement
+
+@ Return: Public AST of a SELECT that renders back to the same query after edits
+*/
+func TestStatementPostgreSQL(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name").
+		LeftJoin("teams", "teams.id = users.team_id").
+		Where("users.status = ?", "active").
+		OrderBy("name", "ASC", nil).
+		Limit(10)
+
+	stmt, err := qb.Statement()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(stmt.Joins, []gqbd.Join{{Type: "LEFT", Table: "teams", On: "teams.id = users.team_id"}}) {
+		t.Errorf("unexpected joins: %+v", stmt.Joins)
+	}
+	if len(stmt.Where) != 1 || stmt.Where[0].SQL != "users.status = ?" {
+		t.Errorf("unexpected conditions: %+v", stmt.Where)
+	}
+
+	query, args, err := stmt.Render()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery, expectedArgs, _ := qb.Build()
+	if query != expectedQuery || !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected render to match Build:\n%s %v\ngot:\n%s %v", expectedQuery, expectedArgs, query, args)
+	}
+
+	stmt.Where = append(stmt.Where, gqbd.Condition{SQL: "users.tenant_id = ?", Args: []interface{}{7}})
+	query, args, err = stmt.Render()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\", \"name\" FROM \"users\" LEFT JOIN \"teams\" ON teams.id = users.team_id WHERE users.status = $1 AND users.tenant_id = $2 ORDER BY \"name\" ASC LIMIT $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 7, 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	trashed := gqbd.BuildSelectWith(gqbd.PostgreSQL, "users", []string{"id"}, gqbd.WithMaxLimit(50)).
+		SoftDelete("deleted_at").
+		WithCollation("und-x-icu").
+		WhereEq("name", "Zoë")
+	if stmt, err = trashed.Statement(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query, args, err = stmt.Render()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery, expectedArgs, _ = trashed.Build()
+	if query != expectedQuery || !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected render to match Build:\n%s %v\ngot:\n%s %v", expectedQuery, expectedArgs, query, args)
+	}
+	if stmt.Collation != "\"und-x-icu\"" || stmt.Limit != 50 {
+		t.Errorf("expected collation and max limit in the statement, got %q and %d", stmt.Collation, stmt.Limit)
+	}
+
+	for _, qb := range []*gqbd.QueryBuilder{
+		gqbd.BuildSelect(gqbd.CockroachDB, "users").AsOfSystemTime("-10s"),
+		gqbd.BuildSelect(gqbd.CockroachDB, "users").ForceIndex("users_name_idx"),
+		gqbd.BuildSelect(gqbd.ClickHouse, "events").Final(),
+		gqbd.BuildSelect(gqbd.ClickHouse, "events").Sample(0.1),
+		gqbd.BuildSelect(gqbd.ClickHouse, "events").LimitBy(1, "user_id"),
+	} {
+		if _, err := qb.Statement(); err == nil || !strings.Contains(err.Error(), "Statement() does not support") {
+			t.Errorf("expected unsupported clause error, got %v", err)
+		}
+	}
+}
+
+/*
+WithCollation
+
+@ Return: Quoted COLLATE on generated string comparisons and ORDER BY
+*/
+func TestWithCollationPostgreSQL(t *testing.T) {
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		WithCollation("und-x-icu").
+		WhereMap(map[string]interface{}{"name": "Zoë", "tenant_id": 7}).
+		OrderBy("name", "ASC", nil).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE \"name\" COLLATE \"und-x-icu\" = $1 AND \"tenant_id\" = $2 ORDER BY \"name\" COLLATE \"und-x-icu\" ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+Freeze
+
+@ Return: Frozen base query shared between goroutines, each branch building independently
+*/
+func TestFreezePostgreSQL(t *testing.T) {
+	base := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id").
+		Where("tenant_id = ?", 7).
+		Freeze()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			query, args, err := base.Where("status = ?", "paid").Limit(i).Build()
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			expectedQuery := "SELECT \"id\" FROM \"orders\" WHERE tenant_id = $1 AND status = $2 LIMIT $3"
+			if query != expectedQuery {
+				t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+			}
+			if !reflect.DeepEqual(args, []interface{}{7, "paid", i}) {
+				t.Errorf("unexpected args: %v", args)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	query, _, err := base.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"orders\" WHERE tenant_id = $1"
+	if query != expectedQuery {
+		t.Errorf("expected frozen base to be unchanged, got:\n%s", query)
+	}
+}
+
+/*
+PaginateWith
+
+@ Return: Clauses and response metadata from interchangeable pagination strategies
+*/
+func TestPaginateWithPostgreSQL(t *testing.T) {
+	base := gqbd.BuildSelect(gqbd.PostgreSQL, "posts", "id").Where("status = ?", "published").Freeze()
+
+	offsetPaging := gqbd.LimitOffset{Page: 2, PerPage: 10}
+	qb := base.PaginateWith(offsetPaging)
+	query, _, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"posts\" WHERE status = $1 LIMIT $2 OFFSET $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	meta, err := offsetPaging.Meta(qb, 10, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.HasMore || meta.Page == nil || meta.Page.Offset != 10 {
+		t.Errorf("unexpected meta: %+v", meta)
+	}
+
+	first := gqbd.CursorToken{Columns: []string{"id"}, Direction: "DESC", Size: 2}
+	qb = base.PaginateWith(first)
+	query, _, err = qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\" FROM \"posts\" WHERE status = $1 ORDER BY \"id\" DESC LIMIT $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	meta, err = first.Meta(qb, 2, []interface{}{int64(98)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.HasMore || meta.NextToken == "" {
+		t.Fatalf("expected next token, got %+v", meta)
+	}
+
+	next := gqbd.CursorToken{Columns: []string{"id"}, Token: meta.NextToken, Direction: "DESC", Size: 2}
+	query, args, err := base.PaginateWith(next).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\" FROM \"posts\" WHERE status = $1 AND \"id\" < $2 ORDER BY \"id\" DESC LIMIT $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"published", int64(98), 2}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	bad := gqbd.CursorToken{Columns: []string{"id"}, Token: "not a token", Size: 2}
+	if _, _, err := base.PaginateWith(bad).Build(); err == nil {
+		t.Error("expected error for invalid token")
+	}
+
+	if _, err := (gqbd.Keyset{Columns: []string{"id", ""}, Size: 2}).Apply(base); err == nil {
+		t.Error("expected error for invalid keyset column")
+	}
+	if query, _, err = base.Build(); err != nil || query != "SELECT \"id\" FROM \"posts\" WHERE status = $1" {
+		t.Errorf("expected the frozen base to be unchanged, got %s (%v)", query, err)
+	}
+}
+
+/*
+Factory defaults
+
+@ Return: INSERT completed with registered column defaults, explicit values taking precedence
+*/
+func TestFactoryDefaultsPostgreSQL(t *testing.T) {
+	n := 0
+	f := gqbd.NewFactory(gqbd.PostgreSQL).
+		SetDefaults("orders", map[string]interface{}{
+			"status":   "pending",
+			"id":       gqbd.Expr("gen_random_uuid()"),
+			"sequence": func() interface{} { n++; return n },
+		})
+
+	query, args, err := f.Insert("orders").
+		InsertColumns("customer_id", "status").
+		ValuesRow(1, "paid").
+		ValuesRow(2, "paid").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"orders\" (\"customer_id\", \"status\", \"id\", \"sequence\") VALUES ($1, $2, gen_random_uuid(), $3), ($4, $5, gen_random_uuid(), $6)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "paid", 1, 2, "paid", 2}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = f.Insert("orders").Values(map[string]interface{}{"customer_id": 3}).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "INSERT INTO \"orders\" (\"customer_id\", \"id\", \"sequence\", \"status\") VALUES ($1, gen_random_uuid(), $2, $3)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{3, 3, "pending"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Fixtures
+
+@ Return: INSERTs in the order rows were added and cleanup DELETEs in reverse table order, by key when one is registered
+*/
+func TestFixturesPostgreSQL(t *testing.T) {
+	type User struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	type Tag struct {
+		UserID int    `db:"user_id"`
+		Label  string `db:"label"`
+	}
+	registry := gqbd.NewSchemaRegistry().
+		Register("users", gqbd.TableSchema{Columns: []string{"id", "name"}, PrimaryKey: "id"}).
+		Register("tags", gqbd.TableSchema{Columns: []string{"user_id", "label"}})
+
+	fixtures := gqbd.NewFixtures(gqbd.PostgreSQL, registry).
+		Add("users", User{ID: 1, Name: "Alice"}, &User{ID: 2, Name: "Bob"}).
+		Add("tags", Tag{UserID: 1, Label: "admin"})
+
+	inserts, err := fixtures.Inserts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var queries []string
+	var args [][]interface{}
+	for _, qb := range inserts {
+		query, queryArgs, err := qb.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		queries = append(queries, query)
+		args = append(args, queryArgs)
+	}
+	expectedQueries := []string{
+		`INSERT INTO "users" ("id", "name") VALUES ($1, $2)`,
+		`INSERT INTO "users" ("id", "name") VALUES ($1, $2)`,
+		`INSERT INTO "tags" ("label", "user_id") VALUES ($1, $2)`,
+	}
+	if !reflect.DeepEqual(queries, expectedQueries) {
+		t.Errorf("expected queries:\n%v\ngot:\n%v", expectedQueries, queries)
+	}
+	if !reflect.DeepEqual(args, [][]interface{}{{1, "Alice"}, {2, "Bob"}, {"admin", 1}}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	deletes, err := fixtures.Cleanup()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queries, args = nil, nil
+	for _, qb := range deletes {
+		query, queryArgs, err := qb.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		queries = append(queries, query)
+		args = append(args, queryArgs)
+	}
+	expectedQueries = []string{
+		`DELETE FROM "tags" WHERE "user_id" = $1 AND "label" = $2`,
+		`DELETE FROM "users" WHERE "id" IN ($1, $2)`,
+	}
+	if !reflect.DeepEqual(queries, expectedQueries) {
+		t.Errorf("expected queries:\n%v\ngot:\n%v", expectedQueries, queries)
+	}
+	if !reflect.DeepEqual(args, [][]interface{}{{1, "admin"}, {1, 2}}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, err := gqbd.NewFixtures(gqbd.PostgreSQL, registry).Add("orders", User{ID: 1}).Inserts(); err == nil {
+		t.Error("expected error for unregistered fixture table")
+	}
+	if _, err := gqbd.NewFixtures(gqbd.PostgreSQL, registry).Add("users", User{Name: "NoKey"}).Cleanup(); err == nil {
+		t.Error("expected error for fixture without key value")
+	}
+}
+
+/*
+Named arguments
+
+@ Return: :name placeholders converted to $N in order of appearance, with casts and quoted text left alone
+*/
+func TestNamedArgsPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		Where("status = :status AND created_at::date > :since AND note <> ':status'", gqbd.Named("status", "active"), gqbd.Named("since", "2024-01-01")).
+		Where("role = :role OR owner = :role", map[string]interface{}{"role": "admin"}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id" FROM "users" WHERE status = $1 AND created_at::date > $2 AND note <> ':status' AND role = $3 OR owner = $4`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", "2024-01-01", "admin", "admin"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("status = :status", gqbd.Named("state", "active")).Build(); err == nil {
+		t.Error("expected error for missing named argument")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("a = :a AND b = ?", gqbd.Named("a", 1), 2).Build(); err == nil {
+		t.Error("expected error for mixed named and positional arguments")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("a = :a", gqbd.Named("a", 1)).BuildNamed(); err == nil {
+		t.Error("expected error for BuildNamed on PostgreSQL")
+	}
+}
+
+/*
+Placeholder count validation
+
+@ Return: Build error when a Where or Having condition has more or fewer placeholders than args
+*/
+func TestPlaceholderCountPostgreSQL(t *testing.T) {
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("a = ? AND b = ?", 1).Build(); err == nil {
+		t.Error("expected error for missing argument")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("a = ?", 1, 2).Build(); err == nil {
+		t.Error("expected error for extra argument")
+	}
+	_, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "user_id").
+		GroupBy("user_id").
+		Having("COUNT(*) > ?").
+		Build()
+	if err == nil {
+		t.Error("expected error for missing HAVING argument")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("a = ? AND b = ?", 1, 2).Build(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+/*
+WhereDateRange
+
+@ Return: Half-open range conditions, with the zero time leaving an end open
+*/
+func TestWhereDateRangePostgreSQL(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id").
+		WhereDateRange("created_at", from, to).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id" FROM "orders" WHERE "created_at" >= $1 AND "created_at" < $2`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{from, to}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id").
+		WhereDateRange("created_at", time.Time{}, to).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = `SELECT "id" FROM "orders" WHERE "created_at" < $1`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{to}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders").WhereDateRange("created_at", to, from).Build(); err == nil {
+		t.Error("expected error for from after to")
+	}
+}
+
+/*
+Factory write policy
+
+@ Return: Build error when Insert or Update writes a protected column, unless AllowWrite exempts it
+*/
+func TestFactoryWritePolicyPostgreSQL(t *testing.T) {
+	f := gqbd.NewFactory(gqbd.PostgreSQL).
+		WithWritePolicy(gqbd.WritePolicy{
+			Protected: []string{"id", "created_at"},
+			Tables:    map[string][]string{"users": {"tenant_id"}},
+		})
+
+	if _, _, err := f.Update("users").Set(map[string]interface{}{"name": "Alice", "tenant_id": 2}).Build(); err == nil {
+		t.Error("expected error for update of protected tenant_id")
+	}
+	if _, _, err := f.Insert("orders").Values(map[string]interface{}{"id": 1, "total": 10}).Build(); err == nil {
+		t.Error("expected error for insert of protected id")
+	}
+	_, _, err := f.Insert("users").
+		Values(map[string]interface{}{"email": "a@b.c"}).
+		OnConflict("email").
+		DoUpdate("created_at").
+		Build()
+	if err == nil {
+		t.Error("expected error for upsert of protected created_at")
+	}
+
+	query, args, err := f.Insert("orders").
+		Values(map[string]interface{}{"id": 1, "total": 10}).
+		AllowWrite("id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `INSERT INTO "orders" ("id", "total") VALUES ($1, $2)`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if _, _, err := f.Update("orders").Set(map[string]interface{}{"tenant_id": 2}).Build(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+/*
+Escaped question mark
+
+@ Return: "??" written as a literal "?" so jsonb operators can be used next to placeholders
+*/
+func TestEscapedQuestionMarkPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		Where("tags ??| ? AND meta ?? 'admin'", "{a,b}").
+		Where("age > ?", 18).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id" FROM "users" WHERE tags ?| $1 AND meta ? 'admin' AND age > $2`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"{a,b}", 18}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if got := gqbd.ReplacePlaceholders(gqbd.MariaDB, "a = ? AND b ?? c", 1); got != "a = ? AND b ? c" {
+		t.Errorf("unexpected replacement: %s", got)
+	}
+}
+
+/*
+JSON helpers
+
+@ Return: jsonb containment, path existence and field extraction with bound values
+*/
+func TestJSONHelpersPostgreSQL(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		SelectJSONField("meta", "$.name", "name").
+		WhereJSONContains("meta", map[string]interface{}{"role": "admin"}).
+		WhereJSONPathExists("meta", "$.a.b").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id", jsonb_path_query_first("meta", $1::jsonpath) #>> '{}' AS "name" FROM "users" WHERE "meta" @> $2::jsonb AND jsonb_path_exists("meta", $3::jsonpath)`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"$.name", `{"role":"admin"}`, "$.a.b"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.SQLite, "users").WhereJSONContains("meta", 1).Build(); err == nil {
+		t.Error("expected error for WhereJSONContains on SQLite")
+	}
+}
+
+/*
+ArgsTyped and StrictArgs
+
+@ Return: Type and column of each argument, and a build error for arguments database/sql cannot convert
+*/
+func TestArgsTypedPostgreSQL(t *testing.T) {
+	infos, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		Set(map[string]interface{}{"name": "Alice", "age": int64(30)}).
+		WhereEq("id", 7).
+		Where("deleted_at IS ?", nil).
+		ArgsTyped()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []gqbd.ArgInfo{
+		{Position: 1, Type: "int64", Column: "age"},
+		{Position: 2, Type: "string", Column: "name"},
+		{Position: 3, Type: "int", Column: "id"},
+		{Position: 4, Type: "nil"},
+	}
+	if !reflect.DeepEqual(infos, expected) {
+		t.Errorf("expected %v, got %v", expected, infos)
+	}
+
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users").
+		Where("filter = ?", struct{ A int }{1}).
+		StrictArgs().
+		Build()
+	if err == nil {
+		t.Error("expected error for struct argument without driver.Valuer")
+	}
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users").
+		Where("created_at > ?", time.Now()).
+		StrictArgs().
+		Build()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+/*
+OrderBySpec
+
+@ Return: ORDER BY built from stored sort preferences with NULLS placement, rejecting columns outside the allowlist
+*/
+func TestOrderBySpec(t *testing.T) {
+	allowed := map[string]bool{"name": true, "last_login": true}
+	fields := []gqbd.SortField{
+		{Column: "last_login", Direction: "desc", Nulls: "last"},
+		{Column: "name", Direction: "ASC"},
+	}
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		OrderBySpec(fields, allowed).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" ORDER BY \"last_login\" DESC NULLS LAST, \"name\" ASC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		OrderBySpec([]gqbd.SortField{{Column: "password", Direction: "ASC"}}, allowed).
+		Build()
+	if err == nil {
+		t.Error("expected error for column outside the allowlist")
+	}
+
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		OrderBySpec([]gqbd.SortField{{Column: "name", Direction: "ASC", Nulls: "middle"}}, allowed).
+		Build()
+	if err == nil {
+		t.Error("expected error for invalid nulls placement")
+	}
+}
+
+/*
+WhereFullText
+
+@ Return: to_tsvector over the columns matched with plainto_tsquery, ranked with ts_rank
+*/
+func TestWhereFullText(t *testing.T) {
+	columns := []string{"title", "body"}
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "posts", "id").
+		SelectExpr(gqbd.FullTextRank(gqbd.PostgreSQL, columns, "go sql").As("rank")).
+		WhereFullText(columns, "go sql").
+		OrderByExpression(gqbd.FullTextRank(gqbd.PostgreSQL, columns, "go sql"), "DESC").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", ts_rank(to_tsvector(concat_ws(' ', \"title\", \"body\")), plainto_tsquery($1)) AS \"rank\" FROM \"posts\" WHERE to_tsvector(concat_ws(' ', \"title\", \"body\")) @@ plainto_tsquery($2) ORDER BY ts_rank(to_tsvector(concat_ws(' ', \"title\", \"body\")), plainto_tsquery($3)) DESC"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"go sql", "go sql", "go sql"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.SQLite, "posts").WhereFullText(columns, "go").Build(); err == nil {
+		t.Error("expected error for WhereFullText on SQLite")
+	}
+}
+
+/*
+WhereTupleIn
+
+@ Return: Row-value IN list numbered after preceding args
+*/
+func TestWhereTupleIn(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "memberships", "user_id").
+		WhereEq("active", true).
+		WhereTupleIn([]string{"org_id", "team_id"}, [][]interface{}{{1, "x"}, {2, "y"}}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"user_id\" FROM \"memberships\" WHERE \"active\" = $1 AND (\"org_id\", \"team_id\") IN (($2, $3), ($4, $5))"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{true, 1, "x", 2, "y"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "memberships").
+		WhereTupleIn([]string{"org_id", "team_id"}, [][]interface{}{{1}}).
+		Build()
+	if err == nil {
+		t.Error("expected error for row with missing values")
+	}
+}
+
+/*
+Values
+
+@ Return: VALUES list as a FROM source and as a JOIN source, numbered in query order
+*/
+func TestValues(t *testing.T) {
+	v := gqbd.Values("v", []string{"id", "rank"}, [][]interface{}{{1, "gold"}, {2, "silver"}})
+	query, args, err := gqbd.BuildSelectValues(gqbd.PostgreSQL, v, "id", "rank").
+		Where("id > ?", 0).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"rank\" FROM (VALUES ($1, $2), ($3, $4)) AS \"v\"(\"id\", \"rank\") WHERE id > $5"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "gold", 2, "silver", 0}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users", "users.name", "v.rank").
+		JoinValues("INNER", v, "v.id = users.id").
+		Where("users.active = ?", true).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"users\".\"name\", \"v\".\"rank\" FROM \"users\" INNER JOIN (VALUES ($1, $2), ($3, $4)) AS \"v\"(\"id\", \"rank\") ON v.id = users.id WHERE users.active = $5"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "gold", 2, "silver", true}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	bad := gqbd.Values("v", []string{"id", "rank"}, [][]interface{}{{1}})
+	if _, _, err := gqbd.BuildSelectValues(gqbd.PostgreSQL, bad).Build(); err == nil {
+		t.Error("expected error for row with missing values")
+	}
+}
+
+/*
+InsertFromSelect
+
+@ Return: INSERT ... SELECT with the subquery's placeholders numbered in order, followed by RETURNING
+*/
+func TestInsertFromSelect(t *testing.T) {
+	sub := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id", "total", "created_at").
+		Where("created_at < ?", "2024-01-01").
+		WhereEq("status", "closed")
+	query, args, err := gqbd.InsertFromSelect("orders_archive", []string{"id", "total", "created_at"}, sub).
+		Returning("id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"orders_archive\" (\"id\", \"total\", \"created_at\") SELECT \"id\", \"total\", \"created_at\" FROM \"orders\" WHERE created_at < $1 AND \"status\" = $2 RETURNING id"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"2024-01-01", "closed"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	_, _, err = gqbd.InsertFromSelect("orders_archive", []string{"id"}, sub).
+		Values(map[string]interface{}{"id": 1}).
+		Build()
+	if err == nil {
+		t.Error("expected error for InsertFromSelect combined with Values")
+	}
+}
+
+/*
+Using
+
+@ Return: DELETE ... USING with the join condition ahead of the WHERE conditions
+*/
+func TestDeleteUsing(t *testing.T) {
+	query, args, err := gqbd.BuildDelete(gqbd.PostgreSQL, "order_items").
+		Using("orders", "orders.id = order_items.order_id").
+		Where("orders.status = ?", "cancelled").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "DELETE FROM \"order_items\" USING \"orders\" WHERE orders.id = order_items.order_id AND orders.status = $1"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"cancelled"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders").Using("users", "users.id = orders.user_id").Build(); err == nil {
+		t.Error("expected error for Using on SELECT")
+	}
+}
+
+/*
+SelectAllowed
+
+@ Return: Requested API fields mapped to columns, unknown fields dropped and required keys always selected
+*/
+func TestSelectAllowed(t *testing.T) {
+	allowed := map[string]string{"name": "name", "email": "email_address", "createdAt": "created_at"}
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").
+		SelectAllowed([]string{"email", "password", "name", "email"}, allowed, "id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"email_address\" AS \"email\", \"name\", \"id\" FROM \"users\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users").
+		SelectAllowed(nil, allowed, "id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"created_at\" AS \"createdAt\", \"email_address\" AS \"email\", \"name\", \"id\" FROM \"users\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	_, _, err = gqbd.BuildSelect(gqbd.PostgreSQL, "users").
+		SelectAllowedStrict([]string{"name", "password"}, allowed, "id").
+		Build()
+	if err == nil {
+		t.Error("expected error for field outside the allowlist")
+	}
+}
+
+/*
+BuildSelectWith
+
+@ Return: Builder created with schema, strict and max limit options
+*/
+func TestBuildSelectWith(t *testing.T) {
+	query, args, err := gqbd.BuildSelectWith(gqbd.PostgreSQL, "users", []string{"id", "name"},
+		gqbd.WithSchema("app"), gqbd.WithStrict(), gqbd.WithMaxLimit(200)).
+		Where("active = ?", true).
+		Limit(500).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"name\" FROM \"app\".\"users\" WHERE active = $1 LIMIT $2"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{true, 200}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildSelectWith(gqbd.PostgreSQL, "users", nil, gqbd.WithMaxLimit(50)).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "SELECT * FROM \"users\" LIMIT $1"; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = gqbd.BuildSelectWith(gqbd.PostgreSQL, "users", []string{"role"}, gqbd.WithMaxLimit(50)).GroupBy("role").BuildCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "SELECT COUNT(*) FROM (SELECT \"role\" FROM \"users\" GROUP BY \"role\") AS gqbd_count"; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	stmt, err := gqbd.BuildSelectWith(gqbd.PostgreSQL, "users", []string{"id"}, gqbd.WithSchema("app")).Statement()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query, _, err = stmt.Render(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "SELECT \"id\" FROM \"app\".\"users\""; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	if _, _, err := gqbd.BuildDeleteWith(gqbd.PostgreSQL, "users", gqbd.WithMaxLimit(0)).Build(); err == nil {
+		t.Error("expected error for non-positive max limit")
+	}
+}
+
+/*
+BuildBatches
+
+@ Return: Multi-row inserts split so that no statement exceeds the bind parameter limit, skipping auto columns
+*/
+func TestBuildBatches(t *testing.T) {
+	type event struct {
+		Name  string `db:"name"`
+		Count int    `db:"count"`
+		Note  string `db:"note"`
+	}
+	events := make([]event, 30000)
+	for i := range events {
+		events[i] = event{Name: "e", Count: i}
+	}
+	batches, err := gqbd.BuildInsert(gqbd.PostgreSQL, "events").InsertBatch(events).BuildBatches()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0].Args) != 21845*3 || len(batches[1].Args) != (30000-21845)*3 {
+		t.Fatalf("unexpected batches: %d", len(batches))
+	}
+	if !strings.HasPrefix(batches[1].Query, "INSERT INTO \"events\" (\"name\", \"count\", \"note\") VALUES ($1, $2, $3), ($4, $5, $6)") {
+		t.Errorf("unexpected query: %.80s", batches[1].Query)
+	}
+	if batches[1].Args[1] != 21845 {
+		t.Errorf("expected second batch to start at row 21845, got %v", batches[1].Args[1])
+	}
+
+	query, args, err := gqbd.BuildInsert(gqbd.PostgreSQL, "events").
+		InsertBatch([]map[string]interface{}{{"name": "a", "count": 1}, {"name": "b", "count": 2}}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"events\" (\"count\", \"name\") VALUES ($1, $2), ($3, $4)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "a", 2, "b"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	_, _, err = gqbd.BuildInsert(gqbd.PostgreSQL, "events").
+		InsertBatch([]map[string]interface{}{{"name": "a"}, {"count": 2}}).
+		Build()
+	if err == nil {
+		t.Error("expected error for rows with different columns")
+	}
+
+	type user struct {
+		ID   int64  `db:"id,auto"`
+		Name string `db:"name"`
+	}
+	query, args, err = gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		InsertBatch([]user{{ID: 1, Name: "a"}, {Name: "b"}}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "INSERT INTO \"users\" (\"name\") VALUES ($1), ($2)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", "b"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+OrIgnore
+
+@ Return: ON CONFLICT DO NOTHING without a conflict target
+*/
+func TestOrIgnore(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.PostgreSQL, "events").
+		Values(map[string]interface{}{"id": "evt-1", "payload": "{}"}).
+		OrIgnore().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"events\" (\"id\", \"payload\") VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"evt-1", "{}"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildInsert(gqbd.MSSQL, "events").Values(map[string]interface{}{"id": 1}).OrIgnore().Build(); err == nil {
+		t.Error("expected error for OrIgnore on MSSQL")
+	}
+}
+
+/*
+Replace
+
+@ Return: REPLACE emulated with ON CONFLICT ... DO UPDATE of every inserted column
+*/
+func TestReplace(t *testing.T) {
+	query, _, err := gqbd.BuildInsert(gqbd.PostgreSQL, "settings").
+		Values(map[string]interface{}{"key": "theme", "value": "dark"}).
+		OnConflict("key").
+		Replace().
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"settings\" (\"key\", \"value\") VALUES ($1, $2) ON CONFLICT (\"key\") DO UPDATE SET \"key\" = EXCLUDED.\"key\", \"value\" = EXCLUDED.\"value\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	_, _, err = gqbd.BuildInsert(gqbd.PostgreSQL, "settings").
+		Values(map[string]interface{}{"key": "theme"}).
+		Replace().
+		Build()
+	if err == nil {
+		t.Error("expected error for Replace without a conflict target")
+	}
+}
+
+/*
+Value and SetValue
+
+@ Return: Server-side expressions mixed with bound values, numbered in column order
+*/
+func TestWriteExpressions(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.PostgreSQL, "posts").
+		Values(map[string]interface{}{"title": "Hello"}).
+		Value("created_at", gqbd.Expr("now()")).
+		Value("slug", gqbd.Expr("lower(?)", "Hello")).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"posts\" (\"created_at\", \"slug\", \"title\") VALUES (now(), lower($1), $2)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Hello", "Hello"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildUpdate(gqbd.PostgreSQL, "posts").
+		Set(map[string]interface{}{"title": "Updated"}).
+		SetValue("views", gqbd.Expr("views + ?", 1)).
+		Where("id = ?", 7).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "UPDATE \"posts\" SET \"title\" = $1, \"views\" = views + $2 WHERE id = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Updated", 1, 7}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Increment and Decrement
+
+@ Return: SET column = column +/- $n with the amount bound
+*/
+func TestIncrementDecrement(t *testing.T) {
+	query, args, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "products").
+		Decrement("stock", 1).
+		Increment("sold", 1).
+		Where("id = ?", 42).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "UPDATE \"products\" SET \"sold\" = \"sold\" + $1, \"stock\" = \"stock\" - $2 WHERE id = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, 1, 42}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildUpdate(gqbd.PostgreSQL, "products").
+		Increment("stock", 2).
+		Set(map[string]interface{}{"name": "Widget"}).
+		Where("id = ?", 42).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "UPDATE \"products\" SET \"name\" = $1, \"stock\" = \"stock\" + $2 WHERE id = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Widget", 2, 42}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildInsert(gqbd.PostgreSQL, "products").Increment("stock", 1).Build(); err == nil {
+		t.Error("expected error for Increment on INSERT")
+	}
+}
+
+/*
+Timestamps
+
+@ Return: created_at added to INSERT and updated_at to UPDATE, from the database clock or a Go clock
+*/
+func TestTimestamps(t *testing.T) {
+	query, _, err := gqbd.BuildInsertWith(gqbd.PostgreSQL, "posts", gqbd.WithTimestamps(gqbd.Timestamps{})).
+		Values(map[string]interface{}{"title": "Hello"}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"posts\" (\"title\", \"created_at\") VALUES ($1, CURRENT_TIMESTAMP)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	factory := gqbd.NewFactory(gqbd.PostgreSQL).
+		WithTimestamps(gqbd.Timestamps{UpdatedAt: "modified_at", Now: func() time.Time { return now }})
+	query, args, err := factory.Update("posts").
+		Set(map[string]interface{}{"title": "Updated"}).
+		Where("id = ?", 7).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "UPDATE \"posts\" SET \"modified_at\" = $1, \"title\" = $2 WHERE id = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{now, "Updated", 7}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = factory.Update("posts").
+		Set(map[string]interface{}{"title": "Updated", "modified_at": gqbd.Expr("NULL")}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "UPDATE \"posts\" SET \"modified_at\" = NULL, \"title\" = $1"; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+SoftDelete
+
+@ Return: DELETE turned into an UPDATE of the column, and SELECT filtering deleted rows unless WithTrashed
+*/
+func TestSoftDelete(t *testing.T) {
+	factory := gqbd.NewFactory(gqbd.PostgreSQL).WithSoftDelete("deleted_at", "users")
+
+	query, args, err := factory.Delete("users").Where("id = ?", 7).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "UPDATE \"users\" SET \"deleted_at\" = CURRENT_TIMESTAMP WHERE id = $1 AND \"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = factory.Select("users", "id").Where("active = ?", true).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\" FROM \"users\" WHERE active = $1 AND \"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = factory.Select("users", "id").Where("role = ? OR owner = ?", "admin", true).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\" FROM \"users\" WHERE (role = $1 OR owner = $2) AND \"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = factory.Delete("users").Where("id = ? OR email = ?", 7, "a@example.com").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "UPDATE \"users\" SET \"deleted_at\" = CURRENT_TIMESTAMP WHERE (id = $1 OR email = $2) AND \"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = factory.Select("users", "users.id").
+		LeftJoin("teams", "teams.id = users.team_id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"users\".\"id\" FROM \"users\" LEFT JOIN \"teams\" ON teams.id = users.team_id WHERE \"users\".\"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = factory.Select("users", "id").WithTrashed().Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "SELECT \"id\" FROM \"users\""; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = factory.Select("teams", "id").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "SELECT \"id\" FROM \"teams\""; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+WithVersion
+
+@ Return: UPDATE bumping the version column and matching the version read by the caller
+*/
+func TestWithVersion(t *testing.T) {
+	query, args, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		Set(map[string]interface{}{"name": "Alice"}).
+		Where("id = ?", 7).
+		WithVersion("version", 3).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "UPDATE \"users\" SET \"name\" = $1, \"version\" = \"version\" + 1 WHERE id = $2 AND \"version\" = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Alice", 7, 3}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		WithVersion("version", 3).
+		Set(map[string]interface{}{"name": "Alice"}).
+		Where("id = ?", 7).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "UPDATE \"users\" SET \"name\" = $1, \"version\" = \"version\" + 1 WHERE \"version\" = $2 AND id = $3"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Alice", 3, 7}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").WithVersion("version", 3).Build(); err == nil {
+		t.Errorf("expected error for non-UPDATE builder")
+	}
+}
+
+/*
+InsertStruct
+
+@ Return: INSERT of every db-tagged field except auto-generated and excluded columns
+*/
+func TestInsertStruct(t *testing.T) {
+	type User struct {
+		ID     int64   `db:"id,auto"`
+		Email  string  `db:"email"`
+		Active bool    `db:"active"`
+		Bio    *string `db:"bio"`
+		Note   string  `db:"note"`
+	}
+
+	query, args, err := gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		InsertStruct(&User{ID: 9, Email: "a@example.com"}, gqbd.ExcludeColumns("note")).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"users\" (\"active\", \"bio\", \"email\") VALUES ($1, $2, $3)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{false, nil, "a@example.com"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildInsert(gqbd.PostgreSQL, "users").
+		InsertStruct(User{Email: "a@example.com"}, gqbd.SkipZeroValues()).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "INSERT INTO \"users\" (\"email\") VALUES ($1)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a@example.com"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").InsertStruct(User{}).Build(); err == nil {
+		t.Errorf("expected error for non-INSERT builder")
+	}
+}
+
+/*
+UpdateStruct
+
+@ Return: UPDATE of the selected db-tagged fields, never writing auto-generated columns
+*/
+func TestUpdateStruct(t *testing.T) {
+	type User struct {
+		ID     int64  `db:"id,auto"`
+		Email  string `db:"email"`
+		Name   string `db:"name"`
+		Active bool   `db:"active"`
+	}
+	user := User{ID: 7, Email: "a@example.com"}
+
+	query, args, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		UpdateStruct(user).
+		Where("id = ?", user.ID).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "UPDATE \"users\" SET \"active\" = $1, \"email\" = $2, \"name\" = $3 WHERE id = $4"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{false, "a@example.com", "", int64(7)}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, _, err = gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		UpdateStruct(&user, gqbd.SkipZeroValues()).
+		Where("id = ?", user.ID).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "UPDATE \"users\" SET \"email\" = $1 WHERE id = $2"; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	query, _, err = gqbd.BuildUpdate(gqbd.PostgreSQL, "users").
+		UpdateStruct(user, gqbd.IncludeColumns("name", "active"), gqbd.ExcludeColumns("active")).
+		Where("id = ?", user.ID).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "UPDATE \"users\" SET \"name\" = $1 WHERE id = $2"; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	if _, _, err := gqbd.BuildUpdate(gqbd.PostgreSQL, "users").UpdateStruct(user, gqbd.IncludeColumns("missing")).Build(); err == nil {
+		t.Errorf("expected error for unknown included column")
+	}
+}
+
+/*
+ColumnsOf
+
+@ Return: SELECT column list taken from the db tags of a struct type
+*/
+func TestColumnsOf(t *testing.T) {
+	type Audit struct {
+		CreatedAt string `db:"created_at"`
+	}
+	type User struct {
+		ID    int64  `db:"id,auto"`
+		Email string `db:"email"`
+		Audit
+		Secret string `db:"-"`
+	}
+
+	if columns := gqbd.ColumnsOf[*User](); !reflect.DeepEqual(columns, []string{"id", "email", "created_at"}) {
+		t.Errorf("unexpected columns: %v", columns)
+	}
+
+	query, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", gqbd.ColumnsOf[User]()...).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"email\", \"created_at\" FROM \"users\""
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
+
+/*
+WhereAny
+
+@ Return: "column = ANY($n)" binding the whole slice as one array parameter
+*/
+func TestWhereAny(t *testing.T) {
+	ids := []int64{1, 2, 3}
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		WhereEq("active", true).
+		WhereAny("id", ids).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE \"active\" = $1 AND \"id\" = ANY($2)"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{true, ids}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.MariaDB, "users").WhereAny("id", ids).Build(); err == nil {
+		t.Error("expected error for db type without arrays")
+	}
+}
+
+/*
+ToSql
+
+@ Return: Builders usable wherever squirrel's Sqlizer interface is accepted, rendering the same as Build
+*/
+func TestToSql(t *testing.T) {
+	// Same shape as squirrel.Sqlizer.
+	type sqlizer interface {
+		ToSql() (string, []interface{}, error)
+	}
+	active := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("active = ?", true)
+	admins := gqbd.BuildSelect(gqbd.PostgreSQL, "admins", "id")
+
+	for _, s := range []sqlizer{active, gqbd.BuildUnion(gqbd.PostgreSQL, active, admins)} {
+		query, args, err := s.ToSql()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectedQuery, expectedArgs, _ := s.(interface {
+			Build() (string, []interface{}, error)
+		}).Build()
+		if query != expectedQuery || !reflect.DeepEqual(args, expectedArgs) {
+			t.Errorf("expected %s %v, got %s %v", expectedQuery, expectedArgs, query, args)
+		}
+	}
+}
+
+/*
+DebugSQL
+
+@ Return: Arguments written inline as PostgreSQL literals behind a not-for-execution comment
+*/
+func TestDebugSQL(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		Where("name = ? AND active = ? AND created_at > ? AND score > ?", "O'Brien", true, created, 1.5).
+		WhereAny("id", []int64{1, 2}).
+		Where("deleted_at IS NOT DISTINCT FROM ?", nil)
+
+	expected := "/* gqbd DebugSQL: not for execution */ SELECT \"id\" FROM \"users\" WHERE name = 'O''Brien' AND active = TRUE AND created_at > '2024-05-01 12:30:00Z' AND score > 1.5 AND \"id\" = ANY(ARRAY[1, 2]) AND deleted_at IS NOT DISTINCT FROM NULL"
+	if got := qb.DebugSQL(); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	qb = gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		Where("note <> '$1' AND id = ?", 5).
+		WhereRaw("owner = $1", "ann")
+	expected = "/* gqbd DebugSQL: not for execution */ SELECT \"id\" FROM \"users\" WHERE note <> '$1' AND id = 5 AND owner = 'ann'"
+	if got := qb.DebugSQL(); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if got := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("id = ?").DebugSQL(); !strings.HasPrefix(got, "/* gqbd DebugSQL: ") {
+		t.Errorf("expected build error in a comment, got %s", got)
+	}
+}
+
+/*
+String and LogValue
+
+@ Return: Built query with the argument count, and structured log fields without argument values
+*/
+func TestBuilderStringLogValue(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Where("email = ? AND active = ?", "a@example.com", true).Named("find_user")
+
+	expected := "SELECT \"id\" FROM \"users\" WHERE email = $1 AND active = $2 (2 args)"
+	if got := fmt.Sprint(qb); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	var buf strings.Builder
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("running", "qb", qb)
+	out := buf.String()
+	for _, want := range []string{"qb.kind=SELECT", "qb.table=users", "qb.name=find_user", "qb.args=2", `qb.query="SELECT \"id\" FROM`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in log output %s", want, out)
+		}
+	}
+	if strings.Contains(out, "a@example.com") {
+		t.Errorf("expected argument values to be left out, got %s", out)
+	}
+
+	broken := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("id = ?")
+	if got := broken.String(); !strings.Contains(got, "has 1 placeholders but 0 args") {
+		t.Errorf("expected build error, got %s", got)
+	}
+}
+
+/*
+CacheShape
+
+@ Return: SQL cached per shape key with fresh args on every build, and an error when the args do not fit the shape
+*/
+func TestCacheShape(t *testing.T) {
+	cache := gqbd.NewShapeCache()
+	find := func(email string, ids ...interface{}) *gqbd.QueryBuilder {
+		return gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email").
+			Where("email = ?", email).
+			WhereIn("id", ids).
+			OrderBy("id", "ASC", nil).
+			Limit(10).
+			CacheShape(cache, "find_users")
+	}
+
+	expectedQuery := "SELECT \"id\", \"email\" FROM \"users\" WHERE email = $1 AND \"id\" IN ($2, $3) ORDER BY \"id\" ASC LIMIT $4"
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		query, args, err := find(email, 1, 2).Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if query != expectedQuery {
+			t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+		}
+		expectedArgs := []interface{}{email, 1, 2, 10}
+		if !reflect.DeepEqual(args, expectedArgs) {
+			t.Errorf("expected args %v, got %v", expectedArgs, args)
+		}
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %+v", stats)
+	}
+
+	mismatched := []*gqbd.QueryBuilder{
+		find("c@example.com", 1, 2, 3),
+		gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "email").
+			Where("email = ?", "c@example.com").
+			Where("deleted_at IS NULL").
+			WhereIn("id", []interface{}{1, 2}).
+			OrderBy("id", "ASC", nil).
+			Limit(10).
+			CacheShape(cache, "find_users"),
+		gqbd.BuildSelect(gqbd.MariaDB, "users", "id", "email").
+			Where("email = ?", "c@example.com").
+			WhereIn("id", []interface{}{1, 2}).
+			OrderBy("id", "ASC", nil).
+			Limit(10).
+			CacheShape(cache, "find_users"),
+	}
+	for i, qb := range mismatched {
+		if _, _, err := qb.Build(); err == nil || !strings.Contains(err.Error(), "cached for a different query") {
+			t.Errorf("case %d: expected shape mismatch error, got %v", i, err)
+		}
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").CacheShape(cache, "").Build(); err == nil {
+		t.Errorf("expected error for an empty key")
+	}
+}
+
+/*
+Get and Release
+
+@ Return: Pooled builders that start empty after a release, and args that stay valid after the builder is released
+*/
+func TestGetRelease(t *testing.T) {
+	qb := gqbd.Get(gqbd.PostgreSQL, "users", "id").
+		LeftJoin("orders o", "o.user_id = users.id").
+		Where("status = ?", "active").
+		GroupBy("id").
+		OrderBy("id", "DESC", nil)
+	_, args, err := qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	qb.Release()
+
+	if !reflect.DeepEqual(args, []interface{}{"active"}) {
+		t.Errorf("expected args to survive Release, got %v", args)
+	}
+
+	for i := 0; i < 10; i++ {
+		reused := gqbd.Get(gqbd.PostgreSQL, "accounts").Where("id = ?", i)
+		query, args, err := reused.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectedQuery := "SELECT * FROM \"accounts\" WHERE id = $1"
+		if query != expectedQuery {
+			t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+		}
+		if !reflect.DeepEqual(args, []interface{}{i}) {
+			t.Errorf("expected args [%d], got %v", i, args)
+		}
+		reused.Release()
+	}
+
+	frozen := gqbd.Get(gqbd.PostgreSQL, "users").Where("id = ?", 1).Freeze()
+	frozen.Release()
+	if _, args, err := frozen.Build(); err != nil || len(args) != 1 {
+		t.Errorf("expected frozen builder to be left intact, got %v, %v", args, err)
+	}
+}
+
+/*
+BuildTo
+
+@ Return: Queries appended to a writer with their args, and nothing written when the build fails
+*/
+func TestBuildTo(t *testing.T) {
+	var script strings.Builder
+	var args []interface{}
+	for _, qb := range []*gqbd.QueryBuilder{
+		gqbd.BuildUpdate(gqbd.PostgreSQL, "users").Set(map[string]interface{}{"active": false}).Where("id = ?", 1),
+		gqbd.BuildDelete(gqbd.PostgreSQL, "sessions").Where("user_id = ?", 1),
+	} {
+		queryArgs, err := qb.BuildTo(&script)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		script.WriteString(";\n")
+		args = append(args, queryArgs...)
+	}
+
+	expected := "UPDATE \"users\" SET \"active\" = $1 WHERE id = $2;\nDELETE FROM \"sessions\" WHERE user_id = $1;\n"
+	if script.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, script.String())
+	}
+	expectedArgs := []interface{}{false, 1, 1}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+
+	var empty strings.Builder
+	if _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users").Where("id = ?").BuildTo(&empty); err == nil || empty.Len() != 0 {
+		t.Errorf("expected build error and no output, got %v and %q", err, empty.String())
+	}
+}
+
+/*
+BuildWithOffset and WhereFragment
+
+@ Return: Placeholders numbered after those of an externally written query, for whole queries and WHERE fragments
+*/
+func TestBuildWithOffset(t *testing.T) {
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").
+		Where("status = ?", "active").
+		WhereRaw("created_at > $1", "2024-01-01").
+		Limit(10)
+
+	query, args, err := qb.BuildWithOffset(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE status = $3 AND created_at > $4 LIMIT $5"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	expectedArgs := []interface{}{"active", "2024-01-01", 10}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, args)
+	}
+	if query, _, _ := qb.Build(); !strings.Contains(query, "status = $1") {
+		t.Errorf("expected BuildWithOffset to leave the builder unchanged, got %s", query)
+	}
+	if _, _, err := qb.BuildWithOffset(0); err == nil {
+		t.Errorf("expected error for start index 0")
+	}
+
+	filters := gqbd.BuildSelect(gqbd.PostgreSQL, "orders").
+		Where("total > ?", 100).
+		WhereIn("status", []interface{}{"paid", "shipped"}).
+		SoftDelete("deleted_at")
+	f, err := filters.WhereFragment()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	external := "SELECT o.id FROM orders o JOIN customers c ON c.id = o.customer_id WHERE c.region = $1 AND c.tier = $2 AND "
+	query = external + f.Render(gqbd.PostgreSQL, 3)
+	expectedQuery = external + "total > $3 AND \"status\" IN ($4, $5) AND \"deleted_at\" IS NULL"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(f.Args, []interface{}{100, "paid", "shipped"}) {
+		t.Errorf("unexpected fragment args %v", f.Args)
+	}
+
+	if _, err := qb.WhereFragment(); err == nil {
+		t.Errorf("expected error for WhereRaw() conditions")
+	}
+}
+
+/*
+Cond
+
+@ Return: One set of filters attached to a list query and a count query, and rendered as a fragment without global scopes
+*/
+func TestCond(t *testing.T) {
+	status := "paid"
+	filters := gqbd.NewCond().
+		WhereEq("tenant_id", 7).
+		WhereIf(status != "", "status = ?", status).
+		WhereBetween("total", 10, 100)
+
+	list := gqbd.BuildSelect(gqbd.PostgreSQL, "orders", "id", "total").WhereCond(filters).OrderBy("id", "DESC", nil).Limit(20)
+	query, args, err := list.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"total\" FROM \"orders\" WHERE \"tenant_id\" = $1 AND status = $2 AND \"total\" BETWEEN $3 AND $4 ORDER BY \"id\" DESC LIMIT $5"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, "paid", 10, 100, 20}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	count := gqbd.BuildDelete(gqbd.MariaDB, "orders").WhereCond(filters.And(gqbd.NewCond().WhereIn("id", []interface{}{1, 2})))
+	query, args, err = count.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "DELETE FROM `orders` WHERE `tenant_id` = ? AND status = ? AND `total` BETWEEN ? AND ? AND `id` IN (?, ?)"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7, "paid", 10, 100, 1, 2}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	gqbd.RegisterScope("cond", "tenant", func(qb *gqbd.QueryBuilder) *gqbd.QueryBuilder { return qb.WhereEq("tenant_id", 42) })
+	defer gqbd.UnregisterScope("cond", "tenant")
+	f, err := filters.Fragment(gqbd.PostgreSQL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedFragment := "\"tenant_id\" = ? AND status = ? AND \"total\" BETWEEN ? AND ?"
+	if f.SQL != expectedFragment {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedFragment, f.SQL)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "orders").WhereCond(gqbd.NewCond().Where("id = ?")).Build(); err == nil {
+		t.Errorf("expected error for a missing argument")
+	}
+}
+
+/*
+Scope and RegisterScope
+
+@ Return: Local scopes applied in order, and global table scopes applied on build unless disabled per query
+*/
+func TestScopes(t *testing.T) {
+	active := func(qb *gqbd.QueryBuilder) *gqbd.QueryBuilder { return qb.WhereEq("active", true) }
+	recent := func(days int) func(*gqbd.QueryBuilder) *gqbd.QueryBuilder {
+		return func(qb *gqbd.QueryBuilder) *gqbd.QueryBuilder {
+			return qb.Where("created_at > now() - make_interval(days => ?)", days)
+		}
+	}
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id").Scope(active, recent(7)).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE \"active\" = $1 AND created_at > now() - make_interval(days => $2)"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{true, 7}) {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	gqbd.RegisterScope("scoped_orders", "tenant", func(qb *gqbd.QueryBuilder) *gqbd.QueryBuilder { return qb.WhereEq("tenant_id", 42) })
+	gqbd.RegisterScope("scoped_orders", "not_archived", func(qb *gqbd.QueryBuilder) *gqbd.QueryBuilder { return qb.WhereEq("archived", false) })
+	defer gqbd.UnregisterScope("scoped_orders", "tenant")
+	defer gqbd.UnregisterScope("scoped_orders", "not_archived")
+
+	qb := gqbd.BuildSelect(gqbd.PostgreSQL, "scoped_orders", "id").Where("total > ?", 10)
+	for i := 0; i < 2; i++ {
+		query, args, err = qb.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectedQuery = "SELECT \"id\" FROM \"scoped_orders\" WHERE total > $1 AND \"tenant_id\" = $2 AND \"archived\" = $3"
+		if query != expectedQuery {
+			t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+		}
+		if !reflect.DeepEqual(args, []interface{}{10, 42, false}) {
+			t.Errorf("unexpected args %v", args)
+		}
+	}
+
+	query, _, err = qb.BuildCount()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(query, "\"tenant_id\" = $2") {
+		t.Errorf("expected tenant scope in count query, got %s", query)
+	}
+
+	query, _, _ = gqbd.BuildDelete(gqbd.PostgreSQL, "scoped_orders").Where("id = ?", 1).WithoutScopes("not_archived").Build()
+	expectedQuery = "DELETE FROM \"scoped_orders\" WHERE id = $1 AND \"tenant_id\" = $2"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	either := gqbd.BuildSelect(gqbd.PostgreSQL, "scoped_orders", "id").Where("status = ? OR owner_id = ?", "open", 3)
+	query, _, err = either.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "SELECT \"id\" FROM \"scoped_orders\" WHERE (status = $1 OR owner_id = $2) AND \"tenant_id\" = $3 AND \"archived\" = $4"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+
+	f, err := either.WhereFragment()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedFragment := "(status = ? OR owner_id = ?) AND \"tenant_id\" = ? AND \"archived\" = ?"
+	if f.SQL != expectedFragment {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedFragment, f.SQL)
+	}
+
+	stmt, err := either.Statement()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query, _, err = stmt.Render(); err != nil || query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s (%v)", expectedQuery, query, err)
+	}
+
+	query, _, _ = qb.WithoutScopes().Build()
+	expectedQuery = "SELECT \"id\" FROM \"scoped_orders\" WHERE total > $1"
+	if query != expectedQuery {
+		t.Errorf("expected:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+}
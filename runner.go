@@ -0,0 +1,116 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Runner executes a builder against a database handle, so callers do not pass the built query and args around.
+type Runner struct {
+	qb   *QueryBuilder
+	db   Executor
+	opts []ExecOption
+}
+
+/*
+RunWith
+
+@ db: Database handle, e.g. *sql.DB, *sql.Tx, *sql.Conn or *Session
+@ opts: Execution options, e.g. WithMetrics
+@ Return: *Runner executing the builder against db
+*/
+func (qb *QueryBuilder) RunWith(db Executor, opts ...ExecOption) *Runner {
+	return &Runner{qb: qb, db: db, opts: opts}
+}
+
+/*
+Exec
+
+@ ctx: Context for the statement
+@ Return: Result of the statement and error if any, as QueryBuilder.Exec
+*/
+func (r *Runner) Exec(ctx context.Context) (sql.Result, error) {
+	return r.qb.Exec(ctx, r.db, r.opts...)
+}
+
+/*
+Query
+
+@ ctx: Context for the query
+@ Return: Result rows, which the caller must close, and error if any
+*/
+func (r *Runner) Query(ctx context.Context) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := r.run(ctx, func(ctx context.Context, ex Executor, query string, args []interface{}) error {
+		var err error
+		rows, err = ex.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// Row is the result of Runner.QueryRow. Errors from building or running the query are returned by Scan.
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+/*
+QueryRow
+
+@ ctx: Context for the query
+@ Return: *Row holding the first result row
+*/
+func (r *Runner) QueryRow(ctx context.Context) *Row {
+	var row *sql.Row
+	err := r.run(ctx, func(ctx context.Context, ex Executor, query string, args []interface{}) error {
+		row = ex.QueryRowContext(ctx, query, args...)
+		return nil
+	})
+	return &Row{row: row, err: err}
+}
+
+/*
+Scan
+
+@ dest: Destinations of the row's columns
+@ Return: Error from the query or from scanning; sql.ErrNoRows if the query returned no row
+*/
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+/*
+run
+
+@ ctx: Context for the query
+@ fn: Function running the built query
+@ Return: Error from building the query, from fn, or for options that only apply to Exec
+*/
+func (r *Runner) run(ctx context.Context, fn func(ctx context.Context, ex Executor, query string, args []interface{}) error) error {
+	cfg := execConfig{}
+	for _, opt := range r.opts {
+		opt(&cfg)
+	}
+	if err := r.qb.beforeBuild(cfg); err != nil {
+		return err
+	}
+	query, args, err := r.qb.Build()
+	if err != nil {
+		return err
+	}
+	if cfg.lockKey != "" {
+		return fmt.Errorf("SerializeBy() can only be used with Exec")
+	}
+	// SET LOCAL hints need a transaction that is still open while the rows are read.
+	if !inTx(r.db) && len(HintsFromContext(ctx)) > 0 && (r.qb.dbType == PostgreSQL || r.qb.dbType == CockroachDB) {
+		return fmt.Errorf("hints on db type %v require RunWith on a *sql.Tx to read rows", r.qb.dbType)
+	}
+	return r.qb.execute(ctx, r.db, cfg, query, args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+		return -1, fn(ctx, ex, query, args)
+	})
+}
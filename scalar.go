@@ -0,0 +1,150 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+/*
+Pluck
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder; its column list is replaced by column
+@ column: Column to read
+@ Return: Value of column in every result row, and error if any
+*/
+func Pluck[T any](ctx context.Context, db Executor, qb *QueryBuilder, column string) ([]T, error) {
+	if qb.op != "SELECT" {
+		return nil, fmt.Errorf("Pluck() can only be used with SELECT operation")
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		return nil, err
+	}
+	plucked := qb.Clone()
+	plucked.columns = []clause{{sql: safeCol}}
+	plucked.implicitStar = false
+	rows, err := plucked.RunWith(db).Query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []T
+	for rows.Next() {
+		var v T
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+/*
+CountExec
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder
+@ Return: Number of rows the query matches, counted with BuildCount, and error if any
+*/
+func CountExec(ctx context.Context, db Executor, qb *QueryBuilder) (int64, error) {
+	query, args, err := qb.BuildCount()
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = qb.queryScalar(ctx, db, query, args, &n)
+	return n, err
+}
+
+/*
+SumExec
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder without GROUP BY, HAVING or DISTINCT; ORDER BY, LIMIT and OFFSET are dropped
+@ column: Column to sum
+@ Return: SUM(column) over the matching rows, 0 when there are none, and error if any
+*/
+func SumExec(ctx context.Context, db Executor, qb *QueryBuilder, column string) (float64, error) {
+	if qb.op != "SELECT" {
+		return 0, fmt.Errorf("SumExec() can only be used with SELECT operation")
+	}
+	if len(qb.groupBy) > 0 || len(qb.having) > 0 || qb.distinct {
+		return 0, fmt.Errorf("SumExec() cannot be used with GROUP BY, HAVING or DISTINCT")
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		return 0, err
+	}
+	sum := qb.Clone()
+	sum.columns = []clause{{sql: "SUM(" + safeCol + ")"}}
+	sum.implicitStar = false
+	sum.orderBy = nil
+	sum.limit = 0
+	sum.maxLimit = 0
+	sum.offset = 0
+	query, args, err := sum.Build()
+	if err != nil {
+		return 0, err
+	}
+	var total sql.NullFloat64
+	err = qb.queryScalar(ctx, db, query, args, &total)
+	return total.Float64, err
+}
+
+/*
+ExistsExec
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder
+@ Return: Whether the query matches at least one row, checked with EXISTS, and error if any
+*/
+func ExistsExec(ctx context.Context, db Executor, qb *QueryBuilder) (bool, error) {
+	inner := qb
+	if len(qb.orderBy) > 0 && qb.limit == 0 && qb.offset == 0 {
+		// MSSQL rejects ORDER BY in a subquery, and it does not change the answer.
+		inner = qb.Clone()
+		inner.orderBy = nil
+	}
+	sub, err := inner.asSubquery(qb.dbType, false)
+	if err != nil {
+		return false, err
+	}
+	from := ""
+	if qb.dbType == Oracle {
+		from = " FROM dual"
+	}
+	// CASE keeps the result an integer on databases without a boolean select list.
+	w := newQueryWriter(qb.dbType)
+	w.write("SELECT CASE WHEN EXISTS ")
+	w.writeClause(sub)
+	w.write(" THEN 1 ELSE 0 END" + from)
+	query, args, err := w.result()
+	if err != nil {
+		return false, err
+	}
+	var exists int64
+	err = qb.queryScalar(ctx, db, query, args, &exists)
+	return exists == 1, err
+}
+
+/*
+queryScalar
+
+@ ctx: Context for the query
+@ db: Database handle
+@ query: Built query returning one row with one column
+@ args: Query arguments
+@ dest: Destination of the value
+@ Return: Error from the query or from scanning. The builder's hooks see the query
+*/
+func (qb *QueryBuilder) queryScalar(ctx context.Context, db Executor, query string, args []interface{}, dest interface{}) error {
+	return qb.execute(ctx, db, execConfig{}, query, args, func(ctx context.Context, ex Executor, query string) (int64, error) {
+		return -1, ex.QueryRowContext(ctx, query, args...).Scan(dest)
+	})
+}
@@ -0,0 +1,296 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// ScanOption customizes how ScanAll maps rows to structs.
+type ScanOption func(*scanConfig)
+
+type scanConfig struct {
+	key      string // Parent column identifying a parent row
+	children string // Tag of the parent's slice field receiving child rows
+}
+
+/*
+DedupeBy
+
+@ key: Parent column identifying a parent, usually its primary key
+@ children: `db` tag of the parent's slice field receiving the child rows, e.g. "orders"
+@ Return: ScanOption merging rows of a one-to-many JOIN into one parent per key, with each
+row's child columns appended to the children field. Columns are matched to parent fields first;
+child columns that are all NULL (a LEFT JOIN without a match) add no child
+*/
+func DedupeBy(key, children string) ScanOption {
+	return func(cfg *scanConfig) {
+		cfg.key = key
+		cfg.children = children
+	}
+}
+
+/*
+ScanAll
+
+@ rows: Result rows; they are closed before ScanAll returns
+@ dest: Pointer to a slice of structs or struct pointers with `db:"column"` tags
+@ opts: Scan options, e.g. DedupeBy
+@ Return: Error if a column has no matching field or scanning fails
+*/
+func ScanAll(rows *sql.Rows, dest interface{}, opts ...ScanOption) error {
+	defer rows.Close()
+	cfg := scanConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	sliceVal := reflect.ValueOf(dest)
+	if sliceVal.Kind() != reflect.Pointer || sliceVal.IsNil() || sliceVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ScanAll() expects a pointer to a slice, got %T", dest)
+	}
+	sliceVal = sliceVal.Elem()
+	elemType := sliceVal.Type().Elem()
+	parentType := elemType
+	if parentType.Kind() == reflect.Pointer {
+		parentType = parentType.Elem()
+	}
+	if parentType.Kind() != reflect.Struct {
+		return fmt.Errorf("ScanAll() expects a slice of structs, got %T", dest)
+	}
+
+	parentFields := map[string][]int{}
+	for _, f := range structFields(parentType) {
+		parentFields[f.column] = f.index
+	}
+	var childField []int
+	var childType reflect.Type
+	childFields := map[string][]int{}
+	if cfg.children != "" {
+		var ok bool
+		if childField, ok = parentFields[cfg.children]; !ok {
+			return fmt.Errorf("DedupeBy() children field %q not found in %v", cfg.children, parentType)
+		}
+		delete(parentFields, cfg.children)
+		sliceType := parentType.FieldByIndex(childField).Type
+		if sliceType.Kind() != reflect.Slice {
+			return fmt.Errorf("DedupeBy() children field %q must be a slice", cfg.children)
+		}
+		childType = sliceType.Elem()
+		if childType.Kind() == reflect.Pointer {
+			childType = childType.Elem()
+		}
+		if childType.Kind() != reflect.Struct {
+			return fmt.Errorf("DedupeBy() children field %q must be a slice of structs", cfg.children)
+		}
+		for _, f := range structFields(childType) {
+			childFields[f.column] = f.index
+		}
+		keyIndex, ok := parentFields[cfg.key]
+		if !ok {
+			return fmt.Errorf("DedupeBy() key field %q not found in %v", cfg.key, parentType)
+		}
+		if keyType := parentType.FieldByIndex(keyIndex).Type; !keyType.Comparable() || keyType.Kind() == reflect.Pointer {
+			return fmt.Errorf("DedupeBy() key field %q must be a comparable non-pointer value", cfg.key)
+		}
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	// Child columns are scanned into pointers so that NULLs from an unmatched LEFT JOIN are detected.
+	isChild := make([]bool, len(columns))
+	for i, col := range columns {
+		if _, ok := parentFields[col]; ok {
+			continue
+		}
+		if _, ok := childFields[col]; ok {
+			isChild[i] = true
+			continue
+		}
+		return fmt.Errorf("no destination field for column %q", col)
+	}
+
+	seen := map[interface{}]int{} // Parent key -> index in the slice
+	for rows.Next() {
+		parent := reflect.New(parentType).Elem()
+		targets := make([]interface{}, len(columns))
+		for i, col := range columns {
+			if isChild[i] {
+				targets[i] = reflect.New(reflect.PointerTo(childType.FieldByIndex(childFields[col]).Type)).Interface()
+				continue
+			}
+			targets[i] = parent.FieldByIndex(parentFields[col]).Addr().Interface()
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+
+		if childField != nil {
+			key := parent.FieldByIndex(parentFields[cfg.key]).Interface()
+			index, ok := seen[key]
+			if !ok {
+				if elemType.Kind() == reflect.Pointer {
+					parent = parent.Addr()
+				}
+				sliceVal.Set(reflect.Append(sliceVal, parent))
+				index = sliceVal.Len() - 1
+				seen[key] = index
+			}
+			// Appending may move the slice, so the parent is looked up again on every row.
+			parent = reflect.Indirect(sliceVal.Index(index))
+			if child, ok := scannedChild(childType, childFields, columns, isChild, targets); ok {
+				children := parent.FieldByIndex(childField)
+				if children.Type().Elem().Kind() == reflect.Pointer {
+					child = child.Addr()
+				}
+				children.Set(reflect.Append(children, child))
+			}
+			continue
+		}
+
+		if elemType.Kind() == reflect.Pointer {
+			parent = parent.Addr()
+		}
+		sliceVal.Set(reflect.Append(sliceVal, parent))
+	}
+	return rows.Err()
+}
+
+/*
+scannedChild
+
+@ childType: Child struct type
+@ childFields: Child field indexes by column
+@ columns: Result columns
+@ isChild: Whether each column belongs to the child
+@ targets: Scan targets of the row
+@ Return: Addressable child struct, and false if every child column was NULL
+*/
+func scannedChild(childType reflect.Type, childFields map[string][]int, columns []string, isChild []bool, targets []interface{}) (reflect.Value, bool) {
+	child := reflect.New(childType).Elem()
+	found := false
+	for i, col := range columns {
+		if !isChild[i] {
+			continue
+		}
+		ptr := reflect.ValueOf(targets[i]).Elem()
+		if ptr.IsNil() {
+			continue
+		}
+		found = true
+		child.FieldByIndex(childFields[col]).Set(ptr.Elem())
+	}
+	return child, found
+}
+
+/*
+All
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder, or a write with RETURNING
+@ Return: Every result row scanned into T, a struct or pointer to struct with `db:"column"` tags, and error if any
+*/
+func All[T any](ctx context.Context, db Executor, qb *QueryBuilder) ([]T, error) {
+	rows, err := qb.RunWith(db).Query(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []T
+	if err := ScanAll(rows, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+/*
+One
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder; LIMIT 1 is added when it has no limit
+@ Return: First result row scanned into T, and sql.ErrNoRows if there is none
+*/
+func One[T any](ctx context.Context, db Executor, qb *QueryBuilder) (T, error) {
+	var zero T
+	if qb.op == "SELECT" && qb.limit == 0 {
+		qb = qb.Clone().Limit(1)
+	}
+	result, err := All[T](ctx, db, qb)
+	if err != nil {
+		return zero, err
+	}
+	if len(result) == 0 {
+		return zero, sql.ErrNoRows
+	}
+	return result[0], nil
+}
+
+/*
+Iter
+
+@ ctx: Context for the query
+@ db: Database handle to run the query against
+@ qb: SELECT builder, or a write with RETURNING
+@ Return: Sequence scanning one row at a time into T, a struct or pointer to struct with `db:"column"` tags.
+An error ends the sequence after being yielded once; breaking out of the loop closes the rows
+*/
+func Iter[T any](ctx context.Context, db Executor, qb *QueryBuilder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		elemType := reflect.TypeFor[T]()
+		structType := elemType
+		if structType.Kind() == reflect.Pointer {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			yield(zero, fmt.Errorf("Iter() expects a struct or pointer to struct, got %v", elemType))
+			return
+		}
+		rows, err := qb.RunWith(db).Query(ctx)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+		columns, err := rows.Columns()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		fields := map[string][]int{}
+		for _, f := range structFields(structType) {
+			fields[f.column] = f.index
+		}
+		for _, col := range columns {
+			if _, ok := fields[col]; !ok {
+				yield(zero, fmt.Errorf("no destination field for column %q", col))
+				return
+			}
+		}
+		targets := make([]interface{}, len(columns))
+		for rows.Next() {
+			row := reflect.New(structType)
+			for i, col := range columns {
+				targets[i] = row.Elem().FieldByIndex(fields[col]).Addr().Interface()
+			}
+			if err := rows.Scan(targets...); err != nil {
+				yield(zero, err)
+				return
+			}
+			if elemType.Kind() != reflect.Pointer {
+				row = row.Elem()
+			}
+			if !yield(row.Interface().(T), nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
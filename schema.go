@@ -0,0 +1,142 @@
+package gqbd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TableSchema describes a registered table.
+type TableSchema struct {
+	Columns      []string // Known column names, empty to skip column checks
+	PartitionKey string   // Column the table is partitioned by, empty if not partitioned
+	PrimaryKey   string   // Column identifying a row, used by Fixtures to delete the rows it inserted
+}
+
+// SchemaRegistry holds table definitions used to validate builders.
+type SchemaRegistry struct {
+	mu     sync.RWMutex
+	tables map[string]TableSchema
+}
+
+/*
+NewSchemaRegistry
+
+@ Return: Empty *SchemaRegistry
+*/
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{tables: make(map[string]TableSchema)}
+}
+
+/*
+Register
+
+@ table: Table name as passed to the builders
+@ schema: Table definition
+@ Return: *SchemaRegistry for chaining
+*/
+func (r *SchemaRegistry) Register(table string, schema TableSchema) *SchemaRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tables[table] = schema
+	return r
+}
+
+/*
+Table
+
+@ table: Table name
+@ Return: Registered table definition and whether it exists
+*/
+func (r *SchemaRegistry) Table(table string) (TableSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.tables[table]
+	return schema, ok
+}
+
+/*
+checkColumns
+
+@ columns: Column names written by the query
+@ Return: Error if a registry is attached and a column is not registered for the table
+*/
+func (qb *QueryBuilder) checkColumns(columns []string) error {
+	if qb.registry == nil {
+		return nil
+	}
+	schema, ok := qb.registry.Table(qb.tableName)
+	if !ok || len(schema.Columns) == 0 {
+		return nil
+	}
+	known := make(map[string]bool, len(schema.Columns))
+	for _, col := range schema.Columns {
+		known[col] = true
+	}
+	for _, col := range columns {
+		if !known[col] {
+			return fmt.Errorf("unknown column %s for table %s", col, qb.tableName)
+		}
+	}
+	return nil
+}
+
+/*
+checkPartitionFilter
+
+@ Return: Warning message when a SELECT on a partitioned table has no filter on the partition key
+*/
+func (qb *QueryBuilder) checkPartitionFilter() string {
+	if qb.registry == nil || qb.op != "SELECT" {
+		return ""
+	}
+	schema, ok := qb.registry.Table(qb.tableName)
+	if !ok || schema.PartitionKey == "" {
+		return ""
+	}
+	for _, cond := range qb.conditions {
+		if referencesColumn(qb.dbType, cond.sql, schema.PartitionKey) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("select on partitioned table %s has no filter on partition key %s", qb.tableName, schema.PartitionKey)
+}
+
+/*
+referencesColumn
+
+@ dbType: Database type
+@ sql: SQL fragment to inspect
+@ column: Column name to look for
+@ Return: Whether the fragment mentions the column, quoted or bare
+*/
+func referencesColumn(dbType DBType, sql, column string) bool {
+	if column == "" {
+		return false
+	}
+	if quoted, err := EscapeIdentifier(dbType, column); err == nil && strings.Contains(sql, quoted) {
+		return true
+	}
+	for i := 0; i < len(sql); {
+		j := strings.Index(sql[i:], column)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(column)
+		if (start == 0 || !identifierByte(sql[start-1])) && (end == len(sql) || !identifierByte(sql[end])) {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+/*
+identifierByte
+
+@ b: Byte next to a column name
+@ Return: Whether b continues an identifier or quotes one, so the name is only part of another identifier
+*/
+func identifierByte(b byte) bool {
+	return b == '_' || b == '"' || b == '`' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
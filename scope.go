@@ -0,0 +1,129 @@
+package gqbd
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// namedScope is a global scope registered for a table.
+type namedScope struct {
+	name string
+	fn   func(*QueryBuilder) *QueryBuilder
+}
+
+var (
+	scopesMu sync.RWMutex
+	scopes   = map[string][]namedScope{}
+)
+
+/*
+RegisterScope
+
+@ table: Table name as passed to the builders
+@ name: Name of the scope, used by WithoutScopes; replaces any scope registered under the same name for the table
+@ fn: Modifier applied to every SELECT, UPDATE and DELETE builder of the table when it is built,
+e.g. a tenant or soft-delete filter
+@ Return: None
+*/
+func RegisterScope(table, name string, fn func(*QueryBuilder) *QueryBuilder) {
+	scopesMu.Lock()
+	defer scopesMu.Unlock()
+	list := slices.DeleteFunc(slices.Clone(scopes[table]), func(s namedScope) bool { return s.name == name })
+	scopes[table] = append(list, namedScope{name: name, fn: fn})
+}
+
+/*
+UnregisterScope
+
+@ table: Table name
+@ name: Name of the scope to remove
+@ Return: None
+*/
+func UnregisterScope(table, name string) {
+	scopesMu.Lock()
+	defer scopesMu.Unlock()
+	list := slices.DeleteFunc(slices.Clone(scopes[table]), func(s namedScope) bool { return s.name == name })
+	if len(list) == 0 {
+		delete(scopes, table)
+		return
+	}
+	scopes[table] = list
+}
+
+/*
+Scope
+
+@ fns: Modifiers applied to the builder in order, e.g. reusable filters such as Active or Recent(days)
+@ Return: *QueryBuilder returned by the last modifier
+*/
+func (qb *QueryBuilder) Scope(fns ...func(*QueryBuilder) *QueryBuilder) *QueryBuilder {
+	qb = qb.mutable()
+	for _, fn := range fns {
+		if qb.err != nil {
+			return qb
+		}
+		qb = fn(qb)
+	}
+	return qb
+}
+
+/*
+WithoutScopes
+
+@ names: Global scopes not to apply to this builder; all of them when empty
+@ Return: *QueryBuilder built without the given scopes
+*/
+func (qb *QueryBuilder) WithoutScopes(names ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if len(names) == 0 {
+		qb.noScopes = true
+		return qb
+	}
+	if qb.scopesOff == nil {
+		qb.scopesOff = make(map[string]bool)
+	}
+	for _, name := range names {
+		qb.scopesOff[name] = true
+	}
+	return qb
+}
+
+/*
+withScopes
+
+@ Return: Copy of the builder with the global scopes of its table applied, or the builder itself when none apply.
+Conditions with a top-level OR are parenthesized once a scope adds a condition
+*/
+func (qb *QueryBuilder) withScopes() *QueryBuilder {
+	if qb.noScopes || qb.op == "INSERT" {
+		return qb
+	}
+	scopesMu.RLock()
+	list := scopes[qb.tableName]
+	scopesMu.RUnlock()
+	var scoped *QueryBuilder
+	for _, s := range list {
+		if qb.scopesOff[s.name] {
+			continue
+		}
+		if scoped == nil {
+			scoped = qb.Clone()
+			scoped.frozen = false
+			scoped.noScopes = true
+		}
+		if scoped = s.fn(scoped); scoped == nil {
+			return &QueryBuilder{err: fmt.Errorf("scope %s of table %s returned nil", s.name, qb.tableName)}
+		}
+		if scoped.err != nil {
+			return scoped
+		}
+	}
+	if scoped == nil {
+		return qb
+	}
+	if len(scoped.conditions) > len(qb.conditions) {
+		scoped.conditions = groupConditions(scoped.conditions)
+	}
+	return scoped
+}
@@ -0,0 +1,78 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Session runs statements on a single pooled connection, so temporary tables, session settings and
+// advisory locks created by one statement are seen by the next. It is an Executor for Exec, Columns and DryRun.
+type Session struct {
+	*sql.Conn
+	discard bool
+}
+
+/*
+WithSession
+
+@ ctx: Context for acquiring the connection
+@ db: Database handle, usually *sql.DB
+@ fn: Function running the session's statements
+@ Return: Error from fn, or from returning the connection to the pool
+*/
+func WithSession(ctx context.Context, db connPinner, fn func(s *Session) error) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	s := &Session{Conn: conn}
+	defer func() {
+		if releaseErr := s.release(); err == nil {
+			err = releaseErr
+		}
+	}()
+	return fn(s)
+}
+
+/*
+Run
+
+@ ctx: Context for the statements
+@ builders: Statements to execute in order on the session's connection
+@ Return: Error of the first statement that fails; later statements are not run
+*/
+func (s *Session) Run(ctx context.Context, builders ...*QueryBuilder) error {
+	for _, qb := range builders {
+		if _, err := qb.Exec(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Discard
+
+@ Return: None. The connection is closed when the session ends instead of going back to the pool,
+so temporary tables and settings left behind are not seen by other callers
+*/
+func (s *Session) Discard() {
+	s.discard = true
+}
+
+/*
+release
+
+@ Return: Error from closing the connection
+*/
+func (s *Session) release() error {
+	if !s.discard {
+		return s.Conn.Close()
+	}
+	// Reporting the connection as bad makes database/sql close it rather than reuse it.
+	s.Conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	return nil
+}
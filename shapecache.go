@@ -0,0 +1,149 @@
+package gqbd
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ShapeCache memoizes the SQL built for a builder shape: the same clauses in the same order, with only the
+// bound values changing between requests. On a hit Build only collects the args, without writing any SQL. The key
+// names the shape and is chosen by the caller, who must not reuse it for different clauses; as a safeguard a builder
+// whose DBType, clause counts or number of args differs from the cached shape fails to build instead of returning
+// the wrong SQL. It is safe for concurrent use.
+type ShapeCache struct {
+	entries sync.Map // Key -> *shapeEntry
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// shapeEntry is the SQL cached for one shape.
+type shapeEntry struct {
+	layout shapeLayout
+	query  string
+}
+
+// shapeLayout is what a ShapeCache hit checks against the cached shape; comparing it costs no rendering.
+type shapeLayout struct {
+	dbType  DBType
+	op      string
+	clauses [9]int // columns, joins, conditions, group by, having, order by, data, rows, limit and offset
+	args    int
+}
+
+// shapeRef is the cache and key a builder was attached to with CacheShape.
+type shapeRef struct {
+	cache *ShapeCache
+	key   string
+}
+
+// ShapeCacheStats counts ShapeCache lookups.
+type ShapeCacheStats struct {
+	Hits   int64 // Builds that reused cached SQL
+	Misses int64 // Builds that rendered and stored the SQL
+}
+
+/*
+NewShapeCache
+
+@ Return: Empty *ShapeCache
+*/
+func NewShapeCache() *ShapeCache {
+	return &ShapeCache{}
+}
+
+/*
+Stats
+
+@ Return: Lookup counts since the cache was created
+*/
+func (c *ShapeCache) Stats() ShapeCacheStats {
+	return ShapeCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+/*
+Forget
+
+@ key: Shape key to drop, e.g. after changing how the shape is built
+@ Return: None
+*/
+func (c *ShapeCache) Forget(key string) {
+	c.entries.Delete(key)
+}
+
+/*
+CacheShape
+
+@ cache: Cache holding the SQL of the shape
+@ key: Name of the shape, unique per distinct set of clauses
+@ Return: *QueryBuilder whose Build reuses the cached SQL and only collects the args.
+Build returns an error if the builder does not match the cached shape. BuildNamed and ArgsTyped do not use the cache
+*/
+func (qb *QueryBuilder) CacheShape(cache *ShapeCache, key string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if cache == nil || key == "" {
+		qb.err = fmt.Errorf("CacheShape() requires a cache and a non-empty key")
+		return qb
+	}
+	qb.shape = &shapeRef{cache: cache, key: key}
+	return qb
+}
+
+/*
+layout
+
+@ args: Number of args the builder binds
+@ Return: Layout of the builder's clauses, compared on a cache hit
+*/
+func (qb *QueryBuilder) layout(args int) shapeLayout {
+	bounds := 0
+	if qb.limit > 0 || qb.maxLimit > 0 {
+		bounds++
+	}
+	if qb.offset > 0 {
+		bounds += 2
+	}
+	return shapeLayout{
+		dbType: qb.dbType,
+		op:     qb.op,
+		clauses: [9]int{
+			len(qb.columns), len(qb.joins), len(qb.conditions), len(qb.groupBy), len(qb.having), len(qb.orderBy),
+			len(qb.data) + len(qb.adjustments), len(qb.rows), bounds,
+		},
+		args: args,
+	}
+}
+
+/*
+buildShape
+
+@ Return: Cached query string of the builder's shape and the builder's args.
+On a miss the query is built in full and stored
+*/
+func (qb *QueryBuilder) buildShape() (string, []interface{}, error) {
+	cache, key := qb.shape.cache, qb.shape.key
+	if v, ok := cache.entries.Load(key); ok {
+		entry := v.(*shapeEntry)
+		shape := *qb
+		shape.argsOnly = true
+		_, args, err := shape.buildStatement()
+		if err != nil {
+			return "", nil, err
+		}
+		if entry.layout != qb.layout(len(args)) {
+			return "", nil, fmt.Errorf("shape %q was cached for a different query; use a different key", key)
+		}
+		cache.hits.Add(1)
+		return entry.query, args, nil
+	}
+	query, args, err := qb.buildStatement()
+	if err != nil {
+		return "", nil, err
+	}
+	cache.misses.Add(1)
+	cache.entries.Store(key, &shapeEntry{layout: qb.layout(len(args)), query: query})
+	return query, args, nil
+}
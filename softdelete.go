@@ -0,0 +1,105 @@
+package gqbd
+
+import (
+	"fmt"
+	"slices"
+)
+
+/*
+SoftDelete
+
+@ column: Timestamp column marking deleted rows, e.g. "deleted_at"
+@ Return: *QueryBuilder whose DELETE sets the column to CURRENT_TIMESTAMP instead of removing rows,
+and whose SELECT skips rows with the column set unless WithTrashed is called
+*/
+func (qb *QueryBuilder) SoftDelete(column string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.softDelete = safeCol
+	return qb
+}
+
+/*
+WithSoftDelete
+
+@ column: Timestamp column marking deleted rows
+@ Return: BuilderOption enabling soft deletes, as SoftDelete does
+*/
+func WithSoftDelete(column string) BuilderOption {
+	return func(qb *QueryBuilder) {
+		qb.SoftDelete(column)
+	}
+}
+
+/*
+WithTrashed
+
+@ Return: *QueryBuilder whose SELECT includes soft-deleted rows
+*/
+func (qb *QueryBuilder) WithTrashed() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.withTrashed = true
+	return qb
+}
+
+/*
+softDeleteColumn
+
+@ Return: Soft-delete column, qualified with the table when the query has joins
+*/
+func (qb *QueryBuilder) softDeleteColumn() string {
+	if len(qb.joins) > 0 {
+		return qb.table + "." + qb.softDelete
+	}
+	return qb.softDelete
+}
+
+/*
+selectConditions
+
+@ Return: WHERE conditions of a SELECT, followed by the soft-delete filter unless WithTrashed is set.
+Conditions with a top-level OR are parenthesized so the filter applies to each branch
+*/
+func (qb *QueryBuilder) selectConditions() []clause {
+	if qb.softDelete == "" || qb.withTrashed {
+		return qb.conditions
+	}
+	return append(slices.Clip(groupConditions(qb.conditions)), clause{sql: qb.softDeleteColumn() + " IS NULL"})
+}
+
+/*
+softDeleteConditions
+
+@ Return: WHERE conditions of a soft DELETE, followed by the filter skipping rows that are already deleted
+*/
+func (qb *QueryBuilder) softDeleteConditions() []clause {
+	return append(slices.Clip(groupConditions(qb.conditions)), clause{sql: qb.softDelete + " IS NULL"})
+}
+
+/*
+buildSoftDelete
+
+@ Return: UPDATE marking the matching rows that are not yet deleted, arguments slice, and error if any
+*/
+func (qb *QueryBuilder) buildSoftDelete() (string, []interface{}, error) {
+	if len(qb.joins) > 0 {
+		return "", nil, fmt.Errorf("Using() cannot be combined with SoftDelete()")
+	}
+	w := qb.newWriter()
+	w.write("UPDATE " + qb.table + qb.indexHint + " SET " + qb.softDelete + " = CURRENT_TIMESTAMP WHERE ")
+	w.writeClauses(qb.softDeleteConditions(), " AND ")
+	if err := qb.writeReturningNothing(w); err != nil {
+		return "", nil, err
+	}
+	return w.result()
+}
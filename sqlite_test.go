@@ -0,0 +1,174 @@
+package gqbd_test
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/donghquinn/gqbd"
+)
+
+/*
+BuildSelect
+
+@ Return: Final SELECT query string with "?" placeholders and LIMIT -1 before a bare OFFSET
+*/
+func TestBuildSelectSQLite(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.SQLite, "users", "id", "name").
+		Where("status = ?", "active").
+		OrderBy("name", "ASC", nil).
+		Offset(20).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\", \"name\" FROM \"users\" WHERE status = ? ORDER BY \"name\" ASC LIMIT -1 OFFSET ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 20}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+Upsert
+
+@ Return: ON CONFLICT with excluded values and RETURNING; a constraint target is dropped with a warning
+*/
+func TestUpsertSQLite(t *testing.T) {
+	query, args, err := gqbd.BuildInsert(gqbd.SQLite, "users").
+		Values(map[string]interface{}{"email": "a@b.c", "name": "Alice"}).
+		OnConflict("email").
+		DoUpdate("name").
+		Returning("id").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "INSERT INTO \"users\" (\"email\", \"name\") VALUES (?, ?) ON CONFLICT (\"email\") DO UPDATE SET \"name\" = EXCLUDED.\"name\" RETURNING id"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a@b.c", "Alice"}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	qb := gqbd.BuildInsert(gqbd.SQLite, "users").
+		Values(map[string]interface{}{"email": "a@b.c"}).
+		OnConflictConstraint("users_email_key").
+		DoNothing()
+	query, _, err = qb.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery = "INSERT INTO \"users\" (\"email\") VALUES (?) ON CONFLICT DO NOTHING"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if warnings := qb.Warnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning, got %v", warnings)
+	}
+}
+
+/*
+Union
+
+@ Return: Unparenthesized parts, with limited parts wrapped in a derived table
+*/
+func TestUnionSQLite(t *testing.T) {
+	latest := gqbd.BuildSelect(gqbd.SQLite, "posts", "id").
+		OrderBy("id", "DESC", nil).
+		Limit(5)
+	pinned := gqbd.BuildSelect(gqbd.SQLite, "posts", "id").
+		Where("pinned = ?", 1)
+
+	query, args, err := gqbd.BuildUnion(gqbd.SQLite, latest, pinned).Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT * FROM (SELECT \"id\" FROM \"posts\" ORDER BY \"id\" DESC LIMIT ?) AS gqbd_union_1 UNION SELECT \"id\" FROM \"posts\" WHERE pinned = ?"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{5, 1}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+/*
+BuildSQLite
+
+@ Return: PostgreSQL builder rendered for SQLite with "?" placeholders and translated functions
+*/
+func TestBuildSQLiteCompat(t *testing.T) {
+	query, args, err := gqbd.BuildSelect(gqbd.PostgreSQL, "users", "id", "name").
+		Where("name ILIKE ?", "al%").
+		Where("created_at < NOW()").
+		WhereRaw("age BETWEEN $2 AND $1::int", 65, 18).
+		OrderBy("name", "ASC", nil).
+		Limit(10).
+		BuildSQLite()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := `SELECT "id", "name" FROM "users" WHERE name LIKE ? AND created_at < CURRENT_TIMESTAMP AND age BETWEEN ? AND ? ORDER BY "name" ASC LIMIT ?`
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"al%", 18, 65, 10}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.PostgreSQL, "sales", "region").GroupByCube("region").BuildSQLite(); err == nil {
+		t.Error("expected error for CUBE in SQLite compatibility mode")
+	}
+	if _, _, err := gqbd.BuildSelect(gqbd.MariaDB, "users").BuildSQLite(); err == nil {
+		t.Error("expected error for non-PostgreSQL builder")
+	}
+}
+
+/*
+NamedStruct
+
+@ Return: :name placeholders bound from db-tagged struct fields, ignoring fields the condition does not use
+*/
+func TestNamedStructSQLite(t *testing.T) {
+	type UserFilter struct {
+		Status string `db:"status"`
+		OrgID  int    `db:"org_id"`
+		Name   string `db:"name"`
+	}
+	filter := UserFilter{Status: "active", OrgID: 7}
+
+	query, args, err := gqbd.BuildSelect(gqbd.SQLite, "users", "id").
+		Where("status = :status AND org_id = :org_id", gqbd.NamedStruct(&filter)).
+		BuildNamed()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedQuery := "SELECT \"id\" FROM \"users\" WHERE status = :status AND org_id = :org_id"
+	if query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{sql.Named("status", "active"), sql.Named("org_id", 7)}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	query, args, err = gqbd.BuildSelect(gqbd.SQLite, "users", "id").
+		Where("org_id = :org_id", gqbd.NamedStruct(filter)).
+		ToSql()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedQuery = "SELECT \"id\" FROM \"users\" WHERE org_id = ?"; query != expectedQuery {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{7}) {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if _, _, err := gqbd.BuildSelect(gqbd.SQLite, "users").Where("team = :team", gqbd.NamedStruct(filter)).Build(); err == nil {
+		t.Error("expected error for placeholder without a field")
+	}
+}
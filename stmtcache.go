@@ -0,0 +1,231 @@
+package gqbd
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// preparer is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type preparer interface {
+	Executor
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// StmtCache is an Executor that keeps the most recently used prepared statements, keyed by the built SQL,
+// so hot queries skip re-preparation. Pass it to Exec, RunWith, All and the other helpers in place of the
+// database handle. It is safe for concurrent use.
+type StmtCache struct {
+	db    preparer
+	size  int
+	mu    sync.Mutex
+	order *list.List               // Least recently used at the back
+	stmts map[string]*list.Element // Query -> element holding a *cachedStmt
+	stats StmtCacheStats
+}
+
+// cachedStmt is an entry of StmtCache. An evicted statement is closed once the last caller using it releases it.
+type cachedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // Callers currently running the statement
+	evicted bool // Removed from the cache; closed when refs drops to 0
+}
+
+// StmtCacheStats counts StmtCache lookups.
+type StmtCacheStats struct {
+	Hits      int64 // Queries run on an already prepared statement
+	Misses    int64 // Queries that had to be prepared
+	Evictions int64 // Statements closed to make room
+	Size      int   // Statements currently cached
+}
+
+/*
+HitRate
+
+@ Return: Share of lookups served from the cache, between 0 and 1; 0 before the first lookup
+*/
+func (s StmtCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+/*
+NewStmtCache
+
+@ db: Database handle preparing the statements, usually *sql.DB
+@ size: Largest number of statements kept prepared; the least recently used one is closed beyond that
+@ Return: *StmtCache, and error if size is not positive
+*/
+func NewStmtCache(db preparer, size int) (*StmtCache, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("statement cache size must be positive, got %d", size)
+	}
+	return &StmtCache{db: db, size: size, order: list.New(), stmts: make(map[string]*list.Element)}, nil
+}
+
+/*
+ExecContext
+
+@ ctx: Context for the statement
+@ query: Query, prepared on first use
+@ args: Query arguments
+@ Return: Result of the statement and error if any
+*/
+func (c *StmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	entry, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(entry)
+	return entry.stmt.ExecContext(ctx, args...)
+}
+
+/*
+QueryContext
+
+@ ctx: Context for the query
+@ query: Query, prepared on first use
+@ args: Query arguments
+@ Return: Result rows and error if any
+*/
+func (c *StmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	entry, err := c.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	// Rows keep the statement's connection alive on their own, so the entry is released when the query returns.
+	defer c.release(entry)
+	return entry.stmt.QueryContext(ctx, args...)
+}
+
+/*
+QueryRowContext
+
+@ ctx: Context for the query
+@ query: Query, prepared on first use
+@ args: Query arguments
+@ Return: First result row. A preparation error is reported by Scan, as with *sql.DB
+*/
+func (c *StmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	entry, err := c.prepare(ctx, query)
+	if err != nil {
+		// *sql.Row cannot be built with an error; running the query unprepared reports it through Scan.
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	defer c.release(entry)
+	return entry.stmt.QueryRowContext(ctx, args...)
+}
+
+/*
+Stats
+
+@ Return: Lookup counters and current size
+*/
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+/*
+Close
+
+@ Return: First error from closing the cached statements; the cache is empty afterwards.
+Statements still running are closed when they finish
+*/
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		if err := c.evict(e.Value.(*cachedStmt)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.order.Init()
+	clear(c.stmts)
+	return firstErr
+}
+
+/*
+prepare
+
+@ ctx: Context for preparing
+@ query: Query
+@ Return: Cached or newly prepared statement, held until release is called, and error if preparing failed
+*/
+func (c *StmtCache) prepare(ctx context.Context, query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if e, ok := c.stmts[query]; ok {
+		c.order.MoveToFront(e)
+		c.stats.Hits++
+		entry := e.Value.(*cachedStmt)
+		entry.refs++
+		c.mu.Unlock()
+		return entry, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Preparing happens outside the lock; if two callers race, the statement cached first is kept.
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.stmts[query]; ok {
+		stmt.Close()
+		c.order.MoveToFront(e)
+		entry := e.Value.(*cachedStmt)
+		entry.refs++
+		return entry, nil
+	}
+	entry := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.stmts[query] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		evicted := oldest.Value.(*cachedStmt)
+		delete(c.stmts, evicted.query)
+		c.evict(evicted)
+		c.stats.Evictions++
+	}
+	return entry, nil
+}
+
+/*
+release
+
+@ entry: Statement returned by prepare
+@ Return: None. Closes the statement if it was evicted while in use and this was its last user
+*/
+func (c *StmtCache) release(entry *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+/*
+evict
+
+@ entry: Statement removed from the cache; c.mu must be held
+@ Return: Error from closing the statement, nil if it is still in use and will be closed on release
+*/
+func (c *StmtCache) evict(entry *cachedStmt) error {
+	entry.evicted = true
+	if entry.refs > 0 {
+		return nil
+	}
+	return entry.stmt.Close()
+}
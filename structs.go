@@ -0,0 +1,304 @@
+package gqbd
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// structField maps a tagged struct field to its column.
+type structField struct {
+	column string
+	index  []int
+	auto   bool // tagged `db:"column,auto"`: generated by the database and never written
+}
+
+// structFieldCache caches the tagged fields of each struct type.
+var structFieldCache sync.Map // reflect.Type -> []structField
+
+/*
+structFields
+
+@ t: Struct type
+@ Return: Fields tagged with `db:"column"`, including those of embedded structs; `db:"-"` and untagged fields are skipped.
+The "auto" tag option, as in `db:"id,auto"`, marks columns generated by the database
+*/
+func structFields(t reflect.Type) []structField {
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+	var fields []structField
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fieldIndex := append(append([]int{}, index...), i)
+			tag, hasTag := f.Tag.Lookup("db")
+			name, options, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if f.Anonymous && !hasTag {
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, fieldIndex)
+				}
+				continue
+			}
+			if name == "" || !f.IsExported() {
+				continue
+			}
+			auto := slices.Contains(strings.Split(options, ","), "auto")
+			fields = append(fields, structField{column: name, index: fieldIndex, auto: auto})
+		}
+	}
+	walk(t, nil)
+	structFieldCache.Store(t, fields)
+	return fields
+}
+
+/*
+ColumnsOf
+
+@ Return: Columns of the `db`-tagged fields of T, a struct or pointer to struct, in field order,
+e.g. BuildSelect(dbType, "users", ColumnsOf[User]()...). The mapping is cached per type.
+Panics if T is not a struct type, since that is a programming error
+*/
+func ColumnsOf[T any]() []string {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("ColumnsOf() expects a struct type, got %v", t))
+	}
+	fields := structFields(t)
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.column
+	}
+	return columns
+}
+
+/*
+structValue
+
+@ v: Struct or pointer to struct
+@ Return: Dereferenced struct value and error if v is not a struct
+*/
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("expected a struct, got nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("expected a struct, got %T", v)
+	}
+	return rv, nil
+}
+
+// StructOption customizes how struct fields are mapped to columns.
+type StructOption func(*structConfig)
+
+type structConfig struct {
+	includeZero bool
+	skipAuto    bool     // skip fields tagged with the "auto" option
+	include     []string // if set, the only columns mapped
+	exclude     []string // columns never mapped
+}
+
+/*
+IncludeZeroValues
+
+@ Return: StructOption keeping fields with zero values instead of skipping them
+*/
+func IncludeZeroValues() StructOption {
+	return func(cfg *structConfig) {
+		cfg.includeZero = true
+	}
+}
+
+/*
+SkipZeroValues
+
+@ Return: StructOption skipping fields with zero values, for builders that keep them by default such as InsertStruct
+*/
+func SkipZeroValues() StructOption {
+	return func(cfg *structConfig) {
+		cfg.includeZero = false
+	}
+}
+
+/*
+IncludeColumns
+
+@ columns: Columns to map; every other field is left out
+@ Return: StructOption limiting the mapping to the given columns, which must be tagged fields of the struct
+*/
+func IncludeColumns(columns ...string) StructOption {
+	return func(cfg *structConfig) {
+		cfg.include = append(cfg.include, columns...)
+	}
+}
+
+/*
+ExcludeColumns
+
+@ columns: Columns to leave out
+@ Return: StructOption skipping the fields mapped to the given columns
+*/
+func ExcludeColumns(columns ...string) StructOption {
+	return func(cfg *structConfig) {
+		cfg.exclude = append(cfg.exclude, columns...)
+	}
+}
+
+// skipAutoColumns skips fields tagged with the "auto" option, for builders writing rows.
+func skipAutoColumns(cfg *structConfig) {
+	cfg.skipAuto = true
+}
+
+/*
+structColumns
+
+@ v: Struct or pointer to struct
+@ opts: Struct mapping options
+@ Return: Column names and values of the tagged fields, and error if v is not a struct
+*/
+func structColumns(v interface{}, opts []StructOption) ([]string, []interface{}, error) {
+	cfg := structConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := structFields(rv.Type())
+	for _, col := range cfg.include {
+		if !slices.ContainsFunc(fields, func(f structField) bool { return f.column == col }) {
+			return nil, nil, fmt.Errorf("column %s is not a tagged field of %v", col, rv.Type())
+		}
+	}
+	var columns []string
+	var values []interface{}
+	for _, f := range fields {
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			continue // field of a nil embedded pointer
+		}
+		if (fv.IsZero() && !cfg.includeZero) || (f.auto && cfg.skipAuto) || slices.Contains(cfg.exclude, f.column) ||
+			(cfg.include != nil && !slices.Contains(cfg.include, f.column)) {
+			continue
+		}
+		columns = append(columns, f.column)
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				values = append(values, nil)
+				continue
+			}
+			fv = fv.Elem()
+		}
+		values = append(values, fv.Interface())
+	}
+	return columns, values, nil
+}
+
+/*
+WhereStruct
+
+@ filter: Struct or pointer to struct with `db:"column"` tags
+@ opts: Struct mapping options; zero values are skipped unless IncludeZeroValues is given
+@ Return: *QueryBuilder with one equality condition per mapped field; nil values match with IS NULL
+*/
+func (qb *QueryBuilder) WhereStruct(filter interface{}, opts ...StructOption) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	columns, values, err := structColumns(filter, opts)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	for i, col := range columns {
+		safeCol, err := EscapeIdentifier(qb.dbType, col)
+		if err != nil {
+			qb.err = err
+			return qb
+		}
+		if values[i] == nil {
+			qb.conditions = append(qb.conditions, clause{sql: safeCol + " IS NULL"})
+			continue
+		}
+		qb.conditions = append(qb.conditions, clause{sql: qb.collate(safeCol, values[i]) + " = ?", args: []interface{}{values[i]}, column: col})
+	}
+	return qb
+}
+
+/*
+InsertStruct
+
+@ v: Struct or pointer to struct with `db:"column"` tags
+@ opts: Struct mapping options; zero values are inserted unless SkipZeroValues is given, and fields
+tagged `db:"column,auto"` or listed in ExcludeColumns are left to the database
+@ Return: *QueryBuilder with the mapped columns set for INSERT, as Values does
+*/
+func (qb *QueryBuilder) InsertStruct(v interface{}, opts ...StructOption) *QueryBuilder {
+	return qb.structData("InsertStruct", "INSERT", v, opts)
+}
+
+/*
+UpdateStruct
+
+@ v: Struct or pointer to struct with `db:"column"` tags
+@ opts: Struct mapping options; IncludeColumns and ExcludeColumns select the fields to write, and
+SkipZeroValues writes only non-zero fields. Fields tagged `db:"column,auto"` are never written
+@ Return: *QueryBuilder with the mapped columns set for UPDATE, as Set does
+*/
+func (qb *QueryBuilder) UpdateStruct(v interface{}, opts ...StructOption) *QueryBuilder {
+	return qb.structData("UpdateStruct", "UPDATE", v, opts)
+}
+
+/*
+structData
+
+@ method: Calling method, for error messages
+@ op: Operation the builder must have
+@ v: Struct or pointer to struct
+@ opts: Struct mapping options applied after IncludeZeroValues
+@ Return: *QueryBuilder with data set from the struct's writable fields
+*/
+func (qb *QueryBuilder) structData(method, op string, v interface{}, opts []StructOption) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != op {
+		qb.err = fmt.Errorf("%s() can only be used with %s operation", method, op)
+		return qb
+	}
+	columns, values, err := structColumns(v, append([]StructOption{IncludeZeroValues(), skipAutoColumns}, opts...))
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	if len(columns) == 0 {
+		qb.err = fmt.Errorf("%s() found no columns to write in %T", method, v)
+		return qb
+	}
+	data := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		data[col] = values[i]
+	}
+	qb.data = data
+	return qb
+}
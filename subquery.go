@@ -0,0 +1,175 @@
+package gqbd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var aliasSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+/*
+BuildSelectFrom
+
+@ sub: SELECT builder used as the FROM source
+@ alias: Alias for the derived table; generated as sub_N when empty
+@ columns: Columns to select
+@ Return: *QueryBuilder with SELECT operation over the subquery
+*/
+func BuildSelectFrom(sub *QueryBuilder, alias string, columns ...string) *QueryBuilder {
+	generated := alias == ""
+	if generated {
+		alias = "sub_1"
+	}
+	qb := BuildSelect(sub.dbType, alias, columns...)
+	if qb.err != nil {
+		return qb
+	}
+	if generated {
+		qb.aliases = append(qb.aliases, alias)
+	}
+	c, err := sub.asSubquery(qb.dbType, true)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.fromSub = &c
+	return qb
+}
+
+/*
+SelectSubquery
+
+@ sub: SELECT builder returning a single value per row
+@ alias: Output column name; generated as sub_N when empty
+@ Return: *QueryBuilder with the scalar subquery added to the SELECT list
+*/
+func (qb *QueryBuilder) SelectSubquery(sub *QueryBuilder, alias string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	c, err := sub.asSubquery(qb.dbType, false)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	if alias == "" {
+		alias = qb.generateAlias("sub", "")
+	}
+	safeAlias, err := EscapeIdentifier(qb.dbType, alias)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	c.sql += " AS " + safeAlias
+	qb.addColumn(c)
+	return qb
+}
+
+/*
+GeneratedAliases
+
+@ Return: Aliases generated for subqueries and aggregates without an explicit alias, in the order they were added.
+Aggregate aliases such as count_id are written only when the builder is a derived table, e.g. of BuildSelectFrom,
+where every column needs a name; elsewhere they name the aggregate for SplitAggregateFilters
+*/
+func (qb *QueryBuilder) GeneratedAliases() []string {
+	return append([]string(nil), qb.aliases...)
+}
+
+/*
+asSubquery
+
+@ dbType: Database type of the outer query
+@ derived: Whether the subquery is a derived table, whose unaliased aggregates get their generated aliases
+@ Return: Parenthesized SELECT with "?" placeholders, and error if the builder cannot be embedded
+*/
+func (qb *QueryBuilder) asSubquery(dbType DBType, derived bool) (clause, error) {
+	if qb = qb.withScopes(); qb.err != nil {
+		return clause{}, qb.err
+	}
+	if qb.op != "SELECT" {
+		return clause{}, fmt.Errorf("subquery must be a SELECT, got %s", qb.op)
+	}
+	if qb.dbType != dbType {
+		return clause{}, fmt.Errorf("subquery db type %v does not match %v", qb.dbType, dbType)
+	}
+	if dialectOf(qb.dbType).native != nil {
+		for _, cond := range qb.conditions {
+			if cond.native {
+				return clause{}, fmt.Errorf("WhereRaw() conditions cannot be used in a subquery for db type: %v", qb.dbType)
+			}
+		}
+	}
+	w := newQueryWriter(qb.dbType)
+	w.embed = true
+	w.derived = derived
+	qb.writeSelect(w)
+	return clause{sql: "(" + w.String() + ")", args: w.args}, nil
+}
+
+/*
+generateAlias
+
+@ prefix: Alias prefix, e.g. the aggregate function or "sub"
+@ column: Aggregated column, empty for subqueries
+@ Return: Deterministic alias such as count_id or sub_1, recorded in GeneratedAliases
+*/
+func (qb *QueryBuilder) generateAlias(prefix, column string) string {
+	var alias string
+	if prefix == "sub" {
+		n := 1
+		for _, a := range qb.aliases {
+			if strings.HasPrefix(a, "sub_") {
+				n++
+			}
+		}
+		alias = fmt.Sprintf("sub_%d", n)
+	} else {
+		parts := []string{aliasPart(prefix)}
+		if part := aliasPart(column); part != "" {
+			parts = append(parts, part)
+		}
+		alias = strings.Join(parts, "_")
+		base := alias
+		for n := 2; slices.Contains(qb.aliases, alias); n++ {
+			alias = fmt.Sprintf("%s_%d", base, n)
+		}
+	}
+	qb.aliases = append(qb.aliases, alias)
+	return alias
+}
+
+func aliasPart(s string) string {
+	return strings.Trim(aliasSanitizer.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+/*
+InsertFromSelect
+
+@ table: Table to insert into
+@ columns: Columns filled from the SELECT list, in order
+@ sub: SELECT builder producing the rows; its placeholders are renumbered after the INSERT's
+@ Return: *QueryBuilder with INSERT ... SELECT operation; table defaults are not applied
+*/
+func InsertFromSelect(table string, columns []string, sub *QueryBuilder) *QueryBuilder {
+	qb := BuildInsert(sub.dbType, table)
+	if qb.err != nil {
+		return qb
+	}
+	if len(columns) == 0 {
+		qb.err = fmt.Errorf("InsertFromSelect() requires at least one column")
+		return qb
+	}
+	c, err := sub.asSubquery(qb.dbType, false)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	c.sql = strings.TrimSuffix(strings.TrimPrefix(c.sql, "("), ")")
+	qb.insertCols = slices.Clone(columns)
+	qb.insertSelect = &c
+	return qb
+}
@@ -0,0 +1,120 @@
+package gqbd
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Timestamps configures the columns filled automatically on INSERT and UPDATE.
+type Timestamps struct {
+	CreatedAt string           // Column set on INSERT, "created_at" when empty, "-" to disable
+	UpdatedAt string           // Column set on UPDATE, "updated_at" when empty, "-" to disable
+	Now       func() time.Time // Go-side clock; nil writes CURRENT_TIMESTAMP so the database's time is used
+}
+
+/*
+column
+
+@ name: Configured column name
+@ fallback: Default column name
+@ Return: Column to fill, empty if disabled
+*/
+func (t *Timestamps) column(name, fallback string) string {
+	switch name {
+	case "":
+		return fallback
+	case "-":
+		return ""
+	}
+	return name
+}
+
+/*
+value
+
+@ Return: Current time from Now, or an Expression for the database's CURRENT_TIMESTAMP
+*/
+func (t *Timestamps) value() interface{} {
+	if t.Now != nil {
+		return t.Now()
+	}
+	return Expr("CURRENT_TIMESTAMP")
+}
+
+/*
+Timestamps
+
+@ t: Timestamp columns and clock
+@ Return: *QueryBuilder setting the created-at column on INSERT and the updated-at column on UPDATE,
+unless the statement sets them itself
+*/
+func (qb *QueryBuilder) Timestamps(t Timestamps) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	qb.timestamps = &t
+	return qb
+}
+
+/*
+WithTimestamps
+
+@ t: Timestamp columns and clock
+@ Return: BuilderOption enabling automatic timestamps, as Timestamps does
+*/
+func WithTimestamps(t Timestamps) BuilderOption {
+	return func(qb *QueryBuilder) {
+		qb.timestamps = &t
+	}
+}
+
+/*
+withCreatedAt
+
+@ cols: Insert columns
+@ rows: Insert rows
+@ Return: Columns and rows with the created-at column appended when it is enabled and missing
+*/
+func (qb *QueryBuilder) withCreatedAt(cols []string, rows [][]interface{}) ([]string, [][]interface{}) {
+	col := qb.timestamps.column(qb.timestamps.CreatedAt, "created_at")
+	if col == "" || slices.Contains(cols, col) {
+		return cols, rows
+	}
+	value := qb.timestamps.value()
+	stamped := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		stamped[i] = append(slices.Clone(row), value)
+	}
+	return append(slices.Clone(cols), col), stamped
+}
+
+/*
+updateData
+
+@ Return: Data of the UPDATE with the Increment, Decrement and WithVersion adjustments applied,
+and the updated-at column added when it is enabled and missing
+*/
+func (qb *QueryBuilder) updateData() map[string]interface{} {
+	col := ""
+	if qb.timestamps != nil {
+		col = qb.timestamps.column(qb.timestamps.UpdatedAt, "updated_at")
+	}
+	_, stamped := qb.data[col]
+	if _, adjusted := qb.adjustments[col]; adjusted {
+		stamped = true
+	}
+	if len(qb.adjustments) == 0 && (col == "" || stamped) {
+		return qb.data
+	}
+	data := make(map[string]interface{}, len(qb.data)+len(qb.adjustments)+1)
+	maps.Copy(data, qb.data)
+	for column, expr := range qb.adjustments {
+		data[column] = expr
+	}
+	if col != "" && !stamped {
+		data[col] = qb.timestamps.value()
+	}
+	return data
+}
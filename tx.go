@@ -0,0 +1,227 @@
+package gqbd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Tx is a transaction that runs builders and manages savepoints in the syntax of its database type.
+// It is an Executor, so builders can also be passed to Exec, DryRun or RunWith directly.
+type Tx struct {
+	*sql.Tx
+	dbType  DBType
+	batches int // RunBatch calls so far, numbering their savepoints
+}
+
+/*
+WithTx
+
+@ ctx: Context for the transaction
+@ db: Database handle, usually *sql.DB or *sql.Conn
+@ dbType: Database type, used for savepoint syntax
+@ opts: Transaction options, nil for the driver's defaults
+@ fn: Function running the transaction's statements
+@ Return: Error from fn, after which the transaction is rolled back, or from committing.
+If fn panics the transaction is rolled back and the panic continues
+*/
+func WithTx(ctx context.Context, db txBeginner, dbType DBType, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
+	sqlTx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	tx := &Tx{Tx: sqlTx, dbType: dbType}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+	return fn(tx)
+}
+
+/*
+Run
+
+@ qb: Builder to run in the transaction
+@ opts: Execution options
+@ Return: *Runner bound to the transaction
+*/
+func (tx *Tx) Run(qb *QueryBuilder, opts ...ExecOption) *Runner {
+	return qb.RunWith(tx, opts...)
+}
+
+/*
+SavePoint
+
+@ ctx: Context for the statement
+@ name: Savepoint name
+@ Return: Error if the savepoint could not be created
+*/
+func (tx *Tx) SavePoint(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, "SavePoint", name, "SAVEPOINT %s", "SAVE TRANSACTION %s")
+}
+
+/*
+RollbackTo
+
+@ ctx: Context for the statement
+@ name: Savepoint created with SavePoint
+@ Return: Error if the rollback failed. The transaction stays open and the savepoint can be reused
+*/
+func (tx *Tx) RollbackTo(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, "RollbackTo", name, "ROLLBACK TO SAVEPOINT %s", "ROLLBACK TRANSACTION %s")
+}
+
+/*
+ReleaseSavePoint
+
+@ ctx: Context for the statement
+@ name: Savepoint created with SavePoint
+@ Return: Error if the release failed. MSSQL and Oracle have no RELEASE; the savepoint is kept until the transaction ends
+*/
+func (tx *Tx) ReleaseSavePoint(ctx context.Context, name string) error {
+	if tx.dbType == MSSQL || tx.dbType == Oracle {
+		_, err := EscapeIdentifier(tx.dbType, name)
+		return err
+	}
+	return tx.savepoint(ctx, "ReleaseSavePoint", name, "RELEASE SAVEPOINT %s", "")
+}
+
+/*
+savepoint
+
+@ ctx: Context for the statement
+@ method: Calling method, for error messages
+@ name: Savepoint name
+@ format: Statement format with the escaped name
+@ mssqlFormat: Statement format on MSSQL
+@ Return: Error from escaping the name or from the statement
+*/
+func (tx *Tx) savepoint(ctx context.Context, method, name, format, mssqlFormat string) error {
+	if tx.dbType == ClickHouse {
+		return fmt.Errorf("%s() is not supported for db type: %v", method, tx.dbType)
+	}
+	safeName, err := EscapeIdentifier(tx.dbType, name)
+	if err != nil {
+		return err
+	}
+	if tx.dbType == MSSQL {
+		format = mssqlFormat
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(format, safeName))
+	return err
+}
+
+/*
+inTx
+
+@ db: Database handle
+@ Return: Whether db is a *sql.Tx or *Tx
+*/
+func inTx(db Executor) bool {
+	switch db.(type) {
+	case *sql.Tx, *Tx:
+		return true
+	}
+	return false
+}
+
+// BatchPolicy decides what RunBatch does when a statement fails.
+type BatchPolicy int
+
+const (
+	AbortAll   BatchPolicy = iota // Stop at the first failing statement and return its error
+	SkipFailed                    // Undo the failing statement, record it in the report and continue
+)
+
+// batchSavePoint is the prefix of the savepoint RunBatch sets before each statement; each call adds its own number
+// so a RunBatch started from within another batch's statement does not release the outer savepoint.
+const batchSavePoint = "gqbd_batch_"
+
+// BatchFailure is a statement RunBatch could not run.
+type BatchFailure struct {
+	Index int    // Position of the statement in the batch
+	Query string // Built query, empty if building failed
+	Err   error
+}
+
+// BatchReport summarizes a RunBatch call.
+type BatchReport struct {
+	Succeeded    int   // Statements that ran
+	RowsAffected int64 // Rows affected by the statements that ran
+	Failed       []BatchFailure
+}
+
+/*
+Err
+
+@ Return: The failures joined into one error, nil if every statement ran
+*/
+func (r *BatchReport) Err() error {
+	errs := make([]error, len(r.Failed))
+	for i, f := range r.Failed {
+		errs[i] = fmt.Errorf("statement %d: %w", f.Index, f.Err)
+	}
+	return errors.Join(errs...)
+}
+
+/*
+RunBatch
+
+@ ctx: Context for the statements
+@ policy: AbortAll to stop at the first failure, SkipFailed to undo it and continue
+@ stmts: Statements to run in order, e.g. builders or the Batch values of BuildBatches
+@ Return: *BatchReport of the statements that ran and failed, and error from the first failure under AbortAll
+or from managing the savepoints. Each statement runs under a savepoint, so a failure only undoes that statement
+and the transaction stays usable
+*/
+func (tx *Tx) RunBatch(ctx context.Context, policy BatchPolicy, stmts ...Sqlizer) (*BatchReport, error) {
+	report := &BatchReport{}
+	tx.batches++
+	savePoint := batchSavePoint + strconv.Itoa(tx.batches)
+	for i, stmt := range stmts {
+		query, args, err := stmt.ToSql()
+		if err != nil {
+			report.Failed = append(report.Failed, BatchFailure{Index: i, Err: err})
+			if policy == AbortAll {
+				return report, fmt.Errorf("batch statement %d: %w", i, err)
+			}
+			continue
+		}
+		if err := tx.SavePoint(ctx, savePoint); err != nil {
+			return report, err
+		}
+		var result sql.Result
+		if qb, ok := stmt.(*QueryBuilder); ok {
+			result, err = qb.Exec(ctx, tx)
+		} else {
+			result, err = tx.ExecContext(ctx, query, args...)
+		}
+		if err != nil {
+			if rbErr := tx.RollbackTo(ctx, savePoint); rbErr != nil {
+				return report, errors.Join(err, rbErr)
+			}
+			report.Failed = append(report.Failed, BatchFailure{Index: i, Query: query, Err: err})
+			if policy == AbortAll {
+				return report, fmt.Errorf("batch statement %d: %w", i, err)
+			}
+			continue
+		}
+		if err := tx.ReleaseSavePoint(ctx, savePoint); err != nil {
+			return report, err
+		}
+		report.Succeeded++
+		if n, err := result.RowsAffected(); err == nil {
+			report.RowsAffected += n
+		}
+	}
+	return report, nil
+}
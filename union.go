@@ -0,0 +1,203 @@
+package gqbd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnionBuilder combines SELECT builders with UNION / UNION ALL.
+type UnionBuilder struct {
+	dbType  DBType
+	parts   []*QueryBuilder
+	ops     []string // operator placed before parts[i+1]
+	orderBy []clause
+	limit   int
+	offset  int
+	err     error
+}
+
+/*
+BuildUnion
+
+@ dbType: Database type
+@ parts: SELECT builders combined with UNION
+@ Return: *UnionBuilder
+*/
+func BuildUnion(dbType DBType, parts ...*QueryBuilder) *UnionBuilder {
+	return newUnion(dbType, "UNION", parts)
+}
+
+/*
+BuildUnionAll
+
+@ dbType: Database type
+@ parts: SELECT builders combined with UNION ALL
+@ Return: *UnionBuilder
+*/
+func BuildUnionAll(dbType DBType, parts ...*QueryBuilder) *UnionBuilder {
+	return newUnion(dbType, "UNION ALL", parts)
+}
+
+func newUnion(dbType DBType, op string, parts []*QueryBuilder) *UnionBuilder {
+	ub := &UnionBuilder{dbType: dbType}
+	for _, part := range parts {
+		ub.add(op, part)
+	}
+	return ub
+}
+
+/*
+Union
+
+@ part: SELECT builder appended with UNION
+@ Return: *UnionBuilder
+*/
+func (ub *UnionBuilder) Union(part *QueryBuilder) *UnionBuilder {
+	return ub.add("UNION", part)
+}
+
+/*
+UnionAll
+
+@ part: SELECT builder appended with UNION ALL
+@ Return: *UnionBuilder
+*/
+func (ub *UnionBuilder) UnionAll(part *QueryBuilder) *UnionBuilder {
+	return ub.add("UNION ALL", part)
+}
+
+func (ub *UnionBuilder) add(op string, part *QueryBuilder) *UnionBuilder {
+	if ub.err != nil {
+		return ub
+	}
+	if part.op != "SELECT" {
+		ub.err = fmt.Errorf("union part must be a SELECT, got %s", part.op)
+		return ub
+	}
+	if part.dbType != ub.dbType {
+		ub.err = fmt.Errorf("union part db type %v does not match %v", part.dbType, ub.dbType)
+		return ub
+	}
+	if len(ub.parts) > 0 {
+		ub.ops = append(ub.ops, op)
+	}
+	ub.parts = append(ub.parts, part)
+	return ub
+}
+
+/*
+OrderBy
+
+@ column: Result column to order the combined rows by
+@ direction: Order direction ("ASC" or "DESC")
+@ Return: *UnionBuilder with ORDER BY added after the last part
+*/
+func (ub *UnionBuilder) OrderBy(column, direction string) *UnionBuilder {
+	if ub.err != nil {
+		return ub
+	}
+	safeCol, err := EscapeIdentifier(ub.dbType, column)
+	if err != nil {
+		ub.err = err
+		return ub
+	}
+	ub.orderBy = append(ub.orderBy, clause{sql: safeCol + " " + ValidateDirection(direction)})
+	return ub
+}
+
+/*
+Limit
+
+@ limit: Maximum number of combined rows to return
+@ Return: *UnionBuilder with LIMIT set
+*/
+func (ub *UnionBuilder) Limit(limit int) *UnionBuilder {
+	ub.limit = limit
+	return ub
+}
+
+/*
+Offset
+
+@ offset: Number of combined rows to skip
+@ Return: *UnionBuilder with OFFSET set
+*/
+func (ub *UnionBuilder) Offset(offset int) *UnionBuilder {
+	ub.offset = offset
+	return ub
+}
+
+/*
+Warnings
+
+@ Return: Problems found in the parts that do not prevent the union from being built
+*/
+func (ub *UnionBuilder) Warnings() []string {
+	var warnings []string
+	for i, part := range ub.parts {
+		warnings = append(warnings, part.Warnings()...)
+		// MariaDB/Mysql and SQLite may drop ORDER BY inside a union part unless it also has a LIMIT.
+		if ub.dbType != PostgreSQL && len(part.orderBy) > 0 && part.limit == 0 {
+			warnings = append(warnings, fmt.Sprintf("union part %d has ORDER BY without LIMIT, which may be ignored for db type %v; use UnionBuilder.OrderBy", i+1, ub.dbType))
+		}
+	}
+	return warnings
+}
+
+/*
+ToSql
+
+@ Return: Same as Build, so *UnionBuilder satisfies Sqlizer
+*/
+func (ub *UnionBuilder) ToSql() (string, []interface{}, error) {
+	return ub.Build()
+}
+
+/*
+Build
+
+@ Return: Combined query with each part parenthesized (wrapped in a derived table on SQLite, MSSQL and Oracle when needed),
+arguments slice in part order, and error if any
+*/
+func (ub *UnionBuilder) Build() (string, []interface{}, error) {
+	if ub.err != nil {
+		return "", nil, ub.err
+	}
+	if len(ub.parts) < 2 {
+		return "", nil, fmt.Errorf("union requires at least two parts, got %d", len(ub.parts))
+	}
+	w := newQueryWriter(ub.dbType)
+	for i, part := range ub.parts {
+		if part = part.withScopes(); part.err != nil {
+			return "", nil, part.err
+		}
+		if part.strict {
+			if warnings := part.Warnings(); len(warnings) > 0 {
+				return "", nil, fmt.Errorf("strict mode: %s", strings.Join(warnings, "; "))
+			}
+		}
+		if i > 0 {
+			w.write(" " + ub.ops[i-1] + " ")
+		}
+		switch {
+		case w.dialect.Features().ParenthesizedUnion:
+			w.write("(")
+			part.writeSelect(w)
+			w.write(")")
+		case len(part.orderBy) > 0 || part.limit > 0 || part.offset > 0:
+			// Other dialects do not accept ordered parts; a derived table keeps the part's own ORDER BY/LIMIT.
+			w.write("SELECT * FROM (")
+			part.writeSelect(w)
+			w.write(")" + tableAlias(ub.dbType, fmt.Sprintf("gqbd_union_%d", i+1)))
+		default:
+			part.writeSelect(w)
+		}
+	}
+	if len(ub.orderBy) > 0 {
+		w.write(" ORDER BY ")
+		w.writeClauses(ub.orderBy, ", ")
+	}
+	_, tail := w.dialect.Limit(LimitSpec{Limit: ub.limit, Offset: ub.offset, Ordered: len(ub.orderBy) > 0, Compound: true})
+	w.writeFragment(tail)
+	return w.String(), w.args, nil
+}
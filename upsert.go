@@ -0,0 +1,228 @@
+package gqbd
+
+import "fmt"
+
+// conflictSpec describes the ON CONFLICT / ON DUPLICATE KEY part of an INSERT.
+type conflictSpec struct {
+	columns    []string // escaped conflict target columns
+	constraint string   // unescaped constraint name
+	update     []string // unescaped columns updated from the proposed row
+	doNothing  bool
+}
+
+/*
+OnConflict
+
+@ columns: Conflict target columns (PostgreSQL, SQLite); MariaDB/Mysql apply the action to any unique key
+@ Return: *QueryBuilder with the conflict target set
+*/
+func (qb *QueryBuilder) OnConflict(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("OnConflict() can only be used with INSERT operation")
+		return qb
+	}
+	safeCols, err := escapeIdentifiers(qb.dbType, columns)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.conflictSpec().columns = safeCols
+	return qb
+}
+
+/*
+OnConflictConstraint
+
+@ constraint: Name of the unique constraint used as conflict target (PostgreSQL);
+ignored with a warning on MariaDB/Mysql and SQLite
+@ Return: *QueryBuilder with the conflict target set
+*/
+func (qb *QueryBuilder) OnConflictConstraint(constraint string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("OnConflictConstraint() can only be used with INSERT operation")
+		return qb
+	}
+	qb.conflictSpec().constraint = constraint
+	return qb
+}
+
+/*
+DoUpdate
+
+@ columns: Columns overwritten with the proposed row's values on conflict
+@ Return: *QueryBuilder with the conflict action set
+*/
+func (qb *QueryBuilder) DoUpdate(columns ...string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("DoUpdate() can only be used with INSERT operation")
+		return qb
+	}
+	qb.conflictSpec().update = columns
+	return qb
+}
+
+/*
+DoNothing
+
+@ Return: *QueryBuilder skipping rows that conflict with an existing row
+*/
+func (qb *QueryBuilder) DoNothing() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("DoNothing() can only be used with INSERT operation")
+		return qb
+	}
+	qb.conflictSpec().doNothing = true
+	return qb
+}
+
+/*
+OrIgnore
+
+@ Return: *QueryBuilder skipping rows that conflict with an existing row, written as INSERT IGNORE (MariaDB/Mysql)
+or ON CONFLICT DO NOTHING (PostgreSQL, SQLite, CockroachDB)
+*/
+func (qb *QueryBuilder) OrIgnore() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("OrIgnore() can only be used with INSERT operation")
+		return qb
+	}
+	switch qb.dbType {
+	case MariaDB, Mysql:
+		qb.insertIgnore = true
+	default:
+		if _, err := dialectOf(qb.dbType).Upsert(Upsert{DoNothing: true}); err != nil {
+			qb.err = fmt.Errorf("OrIgnore() is not supported for db type: %v", qb.dbType)
+			return qb
+		}
+		qb.conflictSpec().doNothing = true
+	}
+	return qb
+}
+
+/*
+Replace
+
+@ Return: *QueryBuilder replacing conflicting rows, written as REPLACE INTO (MariaDB, Mysql, SQLite) or, on
+PostgreSQL and CockroachDB, as an upsert overwriting every inserted column, which requires OnConflict()
+or OnConflictConstraint()
+*/
+func (qb *QueryBuilder) Replace() *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "INSERT" {
+		qb.err = fmt.Errorf("Replace() can only be used with INSERT operation")
+		return qb
+	}
+	qb.replace = true
+	return qb
+}
+
+/*
+insertVerb
+
+@ Return: Statement keyword of the INSERT ("INSERT", "INSERT IGNORE" or "REPLACE"), and error if
+Replace is combined with an incompatible conflict action
+*/
+func (qb *QueryBuilder) insertVerb() (string, error) {
+	if !qb.replace {
+		if qb.insertIgnore {
+			return "INSERT IGNORE", nil
+		}
+		return "INSERT", nil
+	}
+	if qb.insertIgnore {
+		return "", fmt.Errorf("Replace() cannot be combined with OrIgnore()")
+	}
+	switch qb.dbType {
+	case MariaDB, Mysql, SQLite:
+		if qb.conflict != nil {
+			return "", fmt.Errorf("Replace() cannot be combined with OnConflict() for db type: %v", qb.dbType)
+		}
+		return "REPLACE", nil
+	}
+	if qb.conflict == nil || (len(qb.conflict.columns) == 0 && qb.conflict.constraint == "") {
+		return "", fmt.Errorf("Replace() requires OnConflict() or OnConflictConstraint() for db type: %v", qb.dbType)
+	}
+	if qb.conflict.doNothing || len(qb.conflict.update) > 0 {
+		return "", fmt.Errorf("Replace() cannot be combined with DoUpdate() or DoNothing()")
+	}
+	return "INSERT", nil
+}
+
+func (qb *QueryBuilder) conflictSpec() *conflictSpec {
+	if qb.conflict == nil {
+		qb.conflict = &conflictSpec{}
+	}
+	return qb.conflict
+}
+
+/*
+checkConflictTarget
+
+@ Return: Warning message when a constraint target is given to a database that cannot use it
+*/
+func (qb *QueryBuilder) checkConflictTarget() string {
+	if qb.conflict == nil || qb.conflict.constraint == "" || dialectOf(qb.dbType).Features().ConflictConstraint {
+		return ""
+	}
+	return fmt.Sprintf("OnConflictConstraint(%s) is ignored for db type %v; the conflict action applies to every unique key", qb.conflict.constraint, qb.dbType)
+}
+
+/*
+writeConflict
+
+@ w: Writer for the INSERT statement
+@ insertCols: Unescaped insert columns, passed to the dialect (MariaDB/Mysql use the first as a no-op update target for DoNothing)
+@ Return: Error if the conflict clause is incomplete
+*/
+func (qb *QueryBuilder) writeConflict(w *queryWriter, insertCols []string) error {
+	spec := qb.conflict
+	if spec == nil {
+		return nil
+	}
+	if qb.replace {
+		// The emulated REPLACE overwrites every inserted column of the conflicting row.
+		replaced := *spec
+		replaced.update = insertCols
+		spec = &replaced
+	}
+	if !spec.doNothing && len(spec.update) == 0 {
+		return fmt.Errorf("OnConflict() requires DoUpdate() or DoNothing()")
+	}
+	safeInsertCols, err := escapeIdentifiers(qb.dbType, insertCols)
+	if err != nil {
+		return err
+	}
+	safeUpdate, err := escapeIdentifiers(qb.dbType, spec.update)
+	if err != nil {
+		return err
+	}
+	var safeConstraint string
+	if spec.constraint != "" && w.dialect.Features().ConflictConstraint {
+		if safeConstraint, err = EscapeIdentifier(qb.dbType, spec.constraint); err != nil {
+			return err
+		}
+	}
+	sql, err := w.dialect.Upsert(Upsert{
+		Columns:         safeInsertCols,
+		ConflictColumns: spec.columns,
+		Constraint:      safeConstraint,
+		Update:          safeUpdate,
+		DoNothing:       spec.doNothing,
+	})
+	if err != nil {
+		return err
+	}
+	w.write(sql)
+	return nil
+}
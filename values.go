@@ -0,0 +1,131 @@
+package gqbd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValuesTable is an inline table of rows, usable as a FROM or JOIN source.
+type ValuesTable struct {
+	alias   string
+	columns []string
+	rows    [][]interface{}
+}
+
+/*
+Values
+
+@ alias: Alias of the table
+@ columns: Column names of the table
+@ rows: Rows of the table, each with one value per column
+@ Return: *ValuesTable for BuildSelectValues and JoinValues
+*/
+func Values(alias string, columns []string, rows [][]interface{}) *ValuesTable {
+	return &ValuesTable{alias: alias, columns: columns, rows: rows}
+}
+
+/*
+render
+
+@ dbType: Database type of the query using the table
+@ Return: Parenthesized source with "?" placeholders and the alias written after it, and error if any.
+Databases with the ValuesTable feature get "(VALUES (?, ?), ...)" aliased as t(a, b); others a
+"(SELECT ? AS a, ? AS b UNION ALL SELECT ?, ?)" derived table
+*/
+func (v *ValuesTable) render(dbType DBType) (clause, string, error) {
+	if len(v.columns) == 0 || len(v.rows) == 0 {
+		return clause{}, "", fmt.Errorf("Values() requires at least one column and one row")
+	}
+	safeAlias, err := EscapeIdentifier(dbType, v.alias)
+	if err != nil {
+		return clause{}, "", err
+	}
+	safeCols, err := escapeIdentifiers(dbType, v.columns)
+	if err != nil {
+		return clause{}, "", err
+	}
+	var args []interface{}
+	for i, row := range v.rows {
+		if len(row) != len(v.columns) {
+			return clause{}, "", fmt.Errorf("Values() row %d has %d values, expected %d", i, len(row), len(v.columns))
+		}
+		args = append(args, row...)
+	}
+	tuple := strings.TrimSuffix(strings.Repeat("?, ", len(v.columns)), ", ")
+
+	if dialectOf(dbType).Features().ValuesTable {
+		tuples := strings.TrimSuffix(strings.Repeat("("+tuple+"), ", len(v.rows)), ", ")
+		return clause{sql: "(VALUES " + tuples + ")", args: args}, safeAlias + "(" + strings.Join(safeCols, ", ") + ")", nil
+	}
+	from := ""
+	if dbType == Oracle {
+		from = " FROM dual"
+	}
+	named := make([]string, len(safeCols))
+	for i, safeCol := range safeCols {
+		named[i] = "? AS " + safeCol
+	}
+	selects := make([]string, len(v.rows))
+	selects[0] = "SELECT " + strings.Join(named, ", ") + from
+	for i := 1; i < len(v.rows); i++ {
+		selects[i] = "SELECT " + tuple + from
+	}
+	return clause{sql: "(" + strings.Join(selects, " UNION ALL ") + ")", args: args}, safeAlias, nil
+}
+
+/*
+BuildSelectValues
+
+@ dbType: Database type (PostgreSQL, MariaDB, Mysql, SQLite, MSSQL, Oracle, CockroachDB, ClickHouse)
+@ v: Values table used as the FROM source
+@ columns: Columns to select
+@ Return: *QueryBuilder with SELECT operation over the rows
+*/
+func BuildSelectValues(dbType DBType, v *ValuesTable, columns ...string) *QueryBuilder {
+	qb := BuildSelect(dbType, v.alias, columns...)
+	if qb.err != nil {
+		return qb
+	}
+	source, alias, err := v.render(dbType)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.fromSub = &source
+	qb.table = alias
+	qb.valuesFrom = true
+	return qb
+}
+
+/*
+JoinValues
+
+@ kind: Join type ("LEFT", "INNER" or "RIGHT")
+@ v: Values table to join
+@ onCondition: Join condition
+@ Return: *QueryBuilder with the JOIN added
+*/
+func (qb *QueryBuilder) JoinValues(kind string, v *ValuesTable, onCondition string) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	kind = strings.ToUpper(kind)
+	if kind != "LEFT" && kind != "INNER" && kind != "RIGHT" {
+		qb.err = fmt.Errorf("unsupported join type: %s", kind)
+		return qb
+	}
+	source, alias, err := v.render(qb.dbType)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.joins = append(qb.joins, joinClause{
+		kind:      kind,
+		table:     v.alias,
+		safeTable: source.sql + tableAlias(qb.dbType, alias),
+		on:        onCondition,
+		args:      source.args,
+	})
+	return qb
+}
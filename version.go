@@ -0,0 +1,59 @@
+package gqbd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict is returned by Exec when an UPDATE using WithVersion matched no row,
+// meaning the row was changed or deleted since currentVersion was read.
+var ErrVersionConflict = errors.New("version conflict: row was modified concurrently")
+
+/*
+WithVersion
+
+@ column: Integer version column, e.g. "version"
+@ currentVersion: Version the caller read; the UPDATE only applies if the row still has it
+@ Return: *QueryBuilder adding "WHERE column = currentVersion" and "SET column = column + 1".
+Exec returns ErrVersionConflict when no row was affected
+*/
+func (qb *QueryBuilder) WithVersion(column string, currentVersion interface{}) *QueryBuilder {
+	qb = qb.mutable()
+	if qb.err != nil {
+		return qb
+	}
+	if qb.op != "UPDATE" {
+		qb.err = fmt.Errorf("WithVersion() can only be used with UPDATE operation")
+		return qb
+	}
+	safeCol, err := EscapeIdentifier(qb.dbType, column)
+	if err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.setAdjustment(column, Expr(safeCol+" + 1"))
+	qb.conditions = append(qb.conditions, clause{sql: safeCol + " = ?", args: []interface{}{currentVersion}, column: column})
+	qb.versioned = true
+	return qb
+}
+
+/*
+checkVersion
+
+@ result: Result of the executed UPDATE
+@ Return: ErrVersionConflict if the UPDATE used WithVersion and affected no row
+*/
+func (qb *QueryBuilder) checkVersion(result sql.Result) error {
+	if !qb.versioned {
+		return nil
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
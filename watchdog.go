@@ -0,0 +1,144 @@
+package gqbd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LeakReport describes a statement still running after its context was done.
+type LeakReport struct {
+	Query    string        // Statement as sent to the database
+	Cause    error         // Why the context ended, e.g. context.Canceled
+	Caller   string        // First call site outside this package, as file:line
+	Overdue  time.Duration // Time since the context ended
+	Finished bool          // Whether the statement eventually returned
+}
+
+// WatchdogStats counts statements seen by a Watchdog.
+type WatchdogStats struct {
+	Cancelled int64 // Statements whose context ended while they were running
+	Leaked    int64 // Cancelled statements still running after the grace period
+	Running   int64 // Leaked statements that have not returned yet
+}
+
+// Watchdog reports statements that keep running after their context is cancelled,
+// which usually points at a driver or call site holding a connection.
+type Watchdog struct {
+	grace     time.Duration
+	onLeak    func(LeakReport)
+	cancelled atomic.Int64
+	leaked    atomic.Int64
+	running   atomic.Int64
+	mu        sync.Mutex // serializes onLeak calls
+}
+
+/*
+NewWatchdog
+
+@ grace: How long a statement may keep running after its context ends before it is reported
+@ onLeak: Hook called when a statement exceeds the grace period, and again when it finally returns; may be nil
+@ Return: *Watchdog to pass to executions with WithWatchdog
+*/
+func NewWatchdog(grace time.Duration, onLeak func(LeakReport)) *Watchdog {
+	return &Watchdog{grace: grace, onLeak: onLeak}
+}
+
+/*
+WithWatchdog
+
+@ w: Watchdog tracking the execution
+@ Return: ExecOption reporting the statement to w if it outlives its context
+*/
+func WithWatchdog(w *Watchdog) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.watchdog = w
+	}
+}
+
+/*
+Stats
+
+@ Return: Counters of cancelled and leaked statements so far
+*/
+func (w *Watchdog) Stats() WatchdogStats {
+	return WatchdogStats{
+		Cancelled: w.cancelled.Load(),
+		Leaked:    w.leaked.Load(),
+		Running:   w.running.Load(),
+	}
+}
+
+/*
+watch
+
+@ ctx: Context of the statement
+@ query: Statement being run
+@ Return: Function to call once the statement returns
+*/
+func (w *Watchdog) watch(ctx context.Context, query string) func() {
+	caller := externalCaller()
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+		}
+		w.cancelled.Add(1)
+		cancelledAt := time.Now()
+		timer := time.NewTimer(w.grace)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return
+		case <-timer.C:
+		}
+		w.leaked.Add(1)
+		w.running.Add(1)
+		report := LeakReport{Query: query, Cause: context.Cause(ctx), Caller: caller}
+		report.Overdue = time.Since(cancelledAt)
+		w.report(report)
+		<-done
+		w.running.Add(-1)
+		report.Overdue = time.Since(cancelledAt)
+		report.Finished = true
+		w.report(report)
+	}()
+	return func() { close(done) }
+}
+
+func (w *Watchdog) report(r LeakReport) {
+	if w.onLeak == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onLeak(r)
+}
+
+/*
+externalCaller
+
+@ Return: file:line of the first stack frame outside this package, or "unknown"
+*/
+func externalCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	pkg := reflect.TypeOf((*Watchdog)(nil)).Elem().PkgPath() + "."
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkg) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}